@@ -2,13 +2,27 @@ package main
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/config"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/idempotencyrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/outboxrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/imagecase"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/outboxcase"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/webhookcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/jwt"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
-	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var wg sync.WaitGroup
@@ -24,16 +38,68 @@ func main() {
 	// initialize validator
 	validator := validation.New()
 
-	// database connection
-	conn, err := pgx.Connect(context.Background(), cfg.DatabaseURI)
+	// database connection pool
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURI)
 	if err != nil {
 		logger.Fatalf("Unable to connect to database: %v\n", err)
 	}
-	defer conn.Close(context.Background())
+	defer pool.Close()
+
+	if err := pool.Ping(context.Background()); err != nil {
+		logger.Fatalf("Unable to connect to database: %v\n", err)
+	}
 
 	logger.Info("Successfully connected to the database")
 
-	httpServer := http.NewServer(validator, conn)
+	// signing key access token
+	tokens, err := newTokenManager(cfg)
+	if err != nil {
+		logger.Fatalf("Unable to initialize JWT: %v\n", err)
+	}
+
+	// image storage
+	store, err := config.NewStorage(cfg)
+	if err != nil {
+		logger.Fatalf("Unable to initialize image storage: %v\n", err)
+	}
+
+	db := txmanager.New(pool)
+
+	// background job hapus gambar yatim (opsional)
+	if cfg.ImageGCInterval > 0 {
+		if strings.Trim(cfg.ImagePath, "/") == "" {
+			logger.Fatal("IMAGE_PATH is required when IMAGE_GC_INTERVAL is set")
+		}
+		gc := &imagecase.GarbageCollector{
+			Storage:          store,
+			References:       productrepo.NewProductRepository(db),
+			Prefix:           cfg.ImagePath,
+			GracePeriod:      cfg.ImageGCGrace,
+			QuarantinePrefix: cfg.ImageGCQuarantine,
+		}
+		go gc.Start(context.Background(), cfg.ImageGCInterval)
+	}
+
+	// relay outbox ke sistem lain (opsional)
+	if cfg.OutboxSink != "" {
+		sink, err := newOutboxSink(cfg, db)
+		if err != nil {
+			logger.Fatalf("Unable to initialize outbox sink: %v\n", err)
+		}
+		relay := outboxcase.NewRelay(outboxrepo.NewOutboxRepository(db), sink)
+		go relay.Run(context.Background(), cfg.OutboxPollInterval)
+
+		// sink webhooks hanya mengantrekan, pengirimannya (termasuk replay
+		// dead letter) dijalankan di sini
+		if dispatcher, ok := sink.(*webhookcase.Dispatcher); ok {
+			go dispatcher.Run(context.Background(), cfg.OutboxPollInterval)
+		}
+	}
+
+	// hapus respons idempotency yang sudah kedaluwarsa
+	go purgeIdempotencyKeys(context.Background(), idempotencyrepo.NewIdempotencyRepository(db), time.Hour)
+
+	httpServer := http.NewServer(validator, db, tokens, store)
 
 	wg.Add(1)
 
@@ -47,3 +113,70 @@ func main() {
 
 	wg.Wait()
 }
+
+// newTokenManager memuat private key dari JWT_PRIVATE_KEY_PATH. Di luar
+// production key boleh kosong, key sementara akan dibuat sehingga token
+// tidak berlaku lagi setelah restart.
+func newTokenManager(cfg *config.Config) (*jwt.Manager, error) {
+	var (
+		key *ecdsa.PrivateKey
+		err error
+	)
+
+	switch {
+	case cfg.JWTPrivateKeyPath != "":
+		key, err = jwt.LoadKey(cfg.JWTPrivateKeyPath)
+	case cfg.Environment == "production":
+		return nil, errors.New("JWT_PRIVATE_KEY_PATH is required in production")
+	default:
+		logger.Warn("JWT_PRIVATE_KEY_PATH not set, using an ephemeral signing key")
+		key, err = jwt.GenerateKey()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return jwt.New(key, cfg.JWTIssuer, cfg.AccessTokenTTL)
+}
+
+// newOutboxSink memilih tujuan event outbox dari OUTBOX_SINK
+func newOutboxSink(cfg *config.Config, db *txmanager.Manager) (outboxcase.Sink, error) {
+	switch cfg.OutboxSink {
+	case "webhooks":
+		// kirim ke subscription yang didaftarkan lewat /api/v1/webhooks
+		return webhookcase.NewDispatcher(webhookrepo.NewWebhookRepository(db), db), nil
+	case "http":
+		if cfg.OutboxWebhookURL == "" {
+			return nil, errors.New("OUTBOX_WEBHOOK_URL is required for the http sink")
+		}
+		return outboxcase.NewHTTPSink(cfg.OutboxWebhookURL), nil
+	case "file":
+		return outboxcase.NewFileSink(cfg.OutboxFile)
+	}
+
+	return nil, fmt.Errorf("unknown OUTBOX_SINK %q", cfg.OutboxSink)
+}
+
+// purgeIdempotencyKeys menghapus key kedaluwarsa secara berkala. Key yang
+// kedaluwarsa juga sudah diabaikan saat dipakai lagi, ini hanya menjaga
+// ukuran tabel.
+func purgeIdempotencyKeys(ctx context.Context, repo *idempotencyrepo.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := repo.PurgeExpired(ctx)
+			if err != nil {
+				logger.Errorf("purge idempotency keys fail, error: %s", err)
+				continue
+			}
+			if n > 0 {
+				logger.Infof("purged %d expired idempotency keys", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/config"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/accessrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/accesscase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// apikey membuat API key untuk integrasi, dikirim lewat header X-API-Key:
+//
+//	go run ./cmd/apikey -name pos-01 -role cashier
+func main() {
+	name := flag.String("name", "", "name of the client using the key")
+	role := flag.String("role", userModel.RoleCashier, "role granted to the key")
+	flag.Parse()
+
+	// load Config
+	cfg := config.LoadConfig()
+
+	// initialize logger
+	logger.Initialize(cfg.Environment)
+
+	if *name == "" {
+		logger.Fatal("usage: apikey -name <client> [-role <role>]")
+	}
+
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURI)
+	if err != nil {
+		logger.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	accessService := accesscase.NewAccessService(accessrepo.NewAccessRepository(txmanager.New(pool)))
+
+	key, err := accessService.CreateAPIKey(context.Background(), *name, *role)
+	if err != nil {
+		logger.Fatalf("Unable to create api key: %v\n", err)
+	}
+
+	// key hanya ditampilkan sekali, yang tersimpan di database hanya hash-nya
+	fmt.Println(key)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/config"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/imagecase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// imagegc mencari file gambar yang tidak direferensikan product_images.
+// Default hanya laporan (dry run), file baru disentuh dengan -dry-run=false:
+//
+//	go run ./cmd/imagegc
+//	go run ./cmd/imagegc -dry-run=false -grace 72h -quarantine quarantine
+func main() {
+	// load Config
+	cfg := config.LoadConfig()
+
+	dryRun := flag.Bool("dry-run", true, "only report orphaned files")
+	grace := flag.Duration("grace", cfg.ImageGCGrace, "skip files younger than this")
+	quarantine := flag.String("quarantine", cfg.ImageGCQuarantine, "move orphans under this prefix instead of deleting them")
+	flag.Parse()
+
+	// initialize logger
+	logger.Initialize(cfg.Environment)
+
+	// tanpa IMAGE_PATH seluruh storage akan di-scan
+	if strings.Trim(cfg.ImagePath, "/") == "" {
+		logger.Fatal("IMAGE_PATH is required")
+	}
+
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURI)
+	if err != nil {
+		logger.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	store, err := config.NewStorage(cfg)
+	if err != nil {
+		logger.Fatalf("Unable to initialize image storage: %v\n", err)
+	}
+
+	gc := &imagecase.GarbageCollector{
+		Storage:          store,
+		References:       productrepo.NewProductRepository(txmanager.New(pool)),
+		Prefix:           cfg.ImagePath,
+		GracePeriod:      *grace,
+		QuarantinePrefix: *quarantine,
+	}
+
+	report, err := gc.Run(context.Background(), *dryRun)
+	if err != nil {
+		logger.Fatalf("Image GC failed: %v\n", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, obj := range report.Orphans {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", obj.Key, obj.Size, obj.ModTime.Format(time.RFC3339))
+	}
+	w.Flush()
+
+	fmt.Printf("\nscanned %d, referenced %d, within grace %d, orphaned %d (%d bytes)\n",
+		report.Scanned, report.Referenced, report.TooRecent, len(report.Orphans), report.OrphanBytes)
+
+	switch {
+	case *dryRun:
+		fmt.Println("dry run: nothing removed, rerun with -dry-run=false")
+	case *quarantine != "":
+		fmt.Printf("quarantined %d under %s/, %d failed\n", report.Removed, *quarantine, report.Failed)
+	default:
+		fmt.Printf("deleted %d, %d failed\n", report.Removed, report.Failed)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
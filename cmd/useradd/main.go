@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/config"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/userrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/authcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// useradd membuat user baru, misalnya admin pertama:
+//
+//	USERADD_PASSWORD=... go run ./cmd/useradd -username admin -role admin
+func main() {
+	username := flag.String("username", "", "username for the new user")
+	role := flag.String("role", authcase.DefaultRole, "role for the new user")
+	flag.Parse()
+
+	// load Config
+	cfg := config.LoadConfig()
+
+	// initialize logger
+	logger.Initialize(cfg.Environment)
+
+	// password lewat env supaya tidak tersimpan di shell history
+	password := os.Getenv("USERADD_PASSWORD")
+	if *username == "" || password == "" {
+		logger.Fatal("usage: USERADD_PASSWORD=<password> useradd -username <name> [-role <role>]")
+	}
+
+	pool, err := pgxpool.New(context.Background(), cfg.DatabaseURI)
+	if err != nil {
+		logger.Fatalf("Unable to connect to database: %v\n", err)
+	}
+	defer pool.Close()
+
+	db := txmanager.New(pool)
+	authService := authcase.NewAuthService(userrepo.NewUserRepository(db), db, nil, 0)
+
+	id, err := authService.CreateUser(context.Background(), &userModel.User{
+		Username: *username,
+		Role:     *role,
+	}, password)
+	if err != nil {
+		logger.Fatalf("Unable to create user: %v\n", err)
+	}
+
+	logger.Infof("User %s created with id %d", *username, *id)
+}
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS outlets (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    last_receipt_no BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO outlets (code, name) VALUES ('MAIN', 'Main Outlet')
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS sales (
+    id BIGSERIAL PRIMARY KEY,
+    outlet_id BIGINT NOT NULL,
+    receipt_seq BIGINT NOT NULL,
+    receipt_no VARCHAR(50) NOT NULL UNIQUE,
+    total BIGINT NOT NULL,
+    paid BIGINT NOT NULL,
+    change_amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (outlet_id) REFERENCES outlets(id),
+    UNIQUE (outlet_id, receipt_seq)
+);
+
+CREATE TABLE IF NOT EXISTS sale_items (
+    id BIGSERIAL PRIMARY KEY,
+    sale_id BIGINT NOT NULL,
+    variant_id BIGINT,
+    variant_unit_id BIGINT,
+    product_name VARCHAR(100) NOT NULL,
+    sku TEXT,
+    unit_name TEXT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    conversion_rate INT NOT NULL,
+    base_quantity INT NOT NULL,
+    unit_price BIGINT NOT NULL,
+    line_total BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (sale_id) REFERENCES sales(id) ON DELETE CASCADE,
+    FOREIGN KEY (variant_id) REFERENCES variants(id) ON DELETE SET NULL,
+    FOREIGN KEY (variant_unit_id) REFERENCES variant_units(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items(sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_variant_id ON sale_items(variant_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS sale_items;
+DROP TABLE IF EXISTS sales;
+DROP TABLE IF EXISTS outlets;
+
+-- +goose StatementEnd
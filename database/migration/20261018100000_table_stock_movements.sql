@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id BIGSERIAL PRIMARY KEY,
+    variant_id BIGINT NOT NULL,
+    quantity INT NOT NULL, -- bertanda, dalam satuan dasar
+    balance_after INT NOT NULL,
+    reason VARCHAR(20) NOT NULL
+        CHECK (reason IN ('sale', 'return', 'adjustment', 'purchase_receipt', 'transfer', 'opname')),
+    reference_type VARCHAR(30),
+    reference_id BIGINT,
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (variant_id) REFERENCES variants(id) ON DELETE CASCADE,
+    CHECK (quantity <> 0 OR reason = 'opname')
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_variant_id
+ON stock_movements(variant_id, id DESC);
+
+-- ledger hanya boleh ditambah, tidak boleh diubah
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_stock_movements_append_only
+BEFORE UPDATE ON stock_movements
+FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- saldo awal untuk stok yang sudah ada sebelum ledger
+INSERT INTO stock_movements (variant_id, quantity, balance_after, reason, note)
+SELECT id, stock, stock, 'adjustment', 'opening balance'
+FROM variants
+WHERE stock <> 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
+
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS stock_policy VARCHAR(20) NOT NULL
+        CHECK (stock_policy IN ('block', 'allow_negative', 'warn'))
+        DEFAULT 'block';
+
+-- stok yang sedang ditahan (misal keranjang di kasir), belum keluar dari ledger
+ALTER TABLE variants
+    ADD COLUMN IF NOT EXISTS reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE variants DROP COLUMN IF EXISTS reserved;
+ALTER TABLE products DROP COLUMN IF EXISTS stock_policy;
+
+-- +goose StatementEnd
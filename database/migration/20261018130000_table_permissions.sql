@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(20) PRIMARY KEY,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    code VARCHAR(50) PRIMARY KEY,
+    description TEXT
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(20) NOT NULL,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role, permission),
+    FOREIGN KEY (role) REFERENCES roles(name) ON DELETE CASCADE,
+    FOREIGN KEY (permission) REFERENCES permissions(code) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (role) REFERENCES roles(name)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('cashier', 'Kasir: baca katalog dan transaksi penjualan'),
+    ('supervisor', 'Supervisor: arsip produk, kelola gambar dan stok'),
+    ('admin', 'Admin: kelola produk dan kategori')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO permissions (code, description) VALUES
+    ('catalog.read', 'Lihat produk, varian, kategori dan scan barcode'),
+    ('product.write', 'Tambah dan ubah produk beserta varian'),
+    ('product.archive', 'Arsipkan produk'),
+    ('product.image', 'Kelola gambar produk'),
+    ('category.write', 'Tambah, ubah dan hapus kategori'),
+    ('sale.create', 'Checkout penjualan dan reservasi stok'),
+    ('sale.read', 'Lihat penjualan'),
+    ('stock.read', 'Lihat stok dan riwayat stok'),
+    ('stock.write', 'Catat pergerakan stok dan stock opname')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('cashier', 'catalog.read'),
+    ('cashier', 'sale.create'),
+    ('cashier', 'sale.read'),
+    ('cashier', 'stock.read'),
+    ('supervisor', 'catalog.read'),
+    ('supervisor', 'product.archive'),
+    ('supervisor', 'product.image'),
+    ('supervisor', 'sale.create'),
+    ('supervisor', 'sale.read'),
+    ('supervisor', 'stock.read'),
+    ('supervisor', 'stock.write')
+ON CONFLICT DO NOTHING;
+
+-- admin mendapat semua permission
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', code FROM permissions
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+    ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
+
+-- +goose StatementEnd
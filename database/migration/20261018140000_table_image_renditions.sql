@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS product_image_renditions (
+    id BIGSERIAL PRIMARY KEY,
+    image_id BIGINT NOT NULL,
+    name VARCHAR(20) NOT NULL CHECK (name IN ('original', 'medium', 'thumbnail')),
+    url TEXT NOT NULL,
+    width INT NOT NULL CHECK (width > 0),
+    height INT NOT NULL CHECK (height > 0),
+    byte_size BIGINT NOT NULL CHECK (byte_size >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (image_id) REFERENCES product_images(id) ON DELETE CASCADE,
+    UNIQUE (image_id, name)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS product_image_renditions;
+
+-- +goose StatementEnd
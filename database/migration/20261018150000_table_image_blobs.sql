@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- satu baris per file gambar (berdasarkan SHA-256 isi file original),
+-- ref_count = jumlah product_images yang memakai file tersebut
+CREATE TABLE IF NOT EXISTS image_blobs (
+    hash CHAR(64) PRIMARY KEY,
+    ref_count INT NOT NULL DEFAULT 0 CHECK (ref_count >= 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- gambar lama (sebelum dedup) tidak punya hash
+ALTER TABLE product_images
+    ADD COLUMN content_hash CHAR(64) REFERENCES image_blobs(hash);
+
+CREATE INDEX IF NOT EXISTS idx_product_images_content_hash ON product_images(content_hash);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE product_images DROP COLUMN IF EXISTS content_hash;
+DROP TABLE IF EXISTS image_blobs;
+
+-- +goose StatementEnd
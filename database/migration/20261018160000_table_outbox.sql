@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- event ditulis di transaksi yang sama dengan perubahan datanya,
+-- lalu dikirim oleh relay worker (outboxcase.Relay)
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id BIGINT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(next_attempt_at, id) WHERE published_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS outbox;
+
+-- +goose StatementEnd
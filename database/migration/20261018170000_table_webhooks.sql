@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    url TEXT NOT NULL,
+    secret VARCHAR(100) NOT NULL,
+    event_types TEXT[] NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- pengiriman yang tetap gagal setelah semua retry
+CREATE TABLE IF NOT EXISTS webhook_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    response_status INT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    replayed_at TIMESTAMPTZ,
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dead_letters_pending ON webhook_dead_letters(created_at) WHERE replayed_at IS NULL;
+
+INSERT INTO permissions (code, description) VALUES
+    ('webhook.manage', 'Kelola webhook subscription dan dead letter')
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'webhook.manage')
+ON CONFLICT DO NOTHING;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DELETE FROM permissions WHERE code = 'webhook.manage';
+DROP TABLE IF EXISTS webhook_dead_letters;
+DROP TABLE IF EXISTS webhook_subscriptions;
+
+-- +goose StatementEnd
@@ -0,0 +1,117 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- sku, nilai option dan barcode semua varian, dirangkum per product oleh
+-- trigger di bawah supaya bisa masuk ke kolom generated search_vector
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS search_terms TEXT NOT NULL DEFAULT '';
+
+-- 'simple': tanpa stemming bahasa Inggris, nama produk lokal tetap utuh
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(search_terms, '')), 'B') ||
+        setweight(to_tsvector('simple', COALESCE(description, '')), 'C')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector
+ON products USING GIN (search_vector);
+
+-- trigram: typo (similarity) dan ILIKE '%kw%'
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm
+ON products USING GIN (name gin_trgm_ops);
+
+CREATE INDEX IF NOT EXISTS idx_products_search_terms_trgm
+ON products USING GIN (search_terms gin_trgm_ops);
+
+CREATE OR REPLACE FUNCTION refresh_product_search_terms(pid BIGINT) RETURNS VOID AS $$
+BEGIN
+    UPDATE products p
+    SET search_terms = COALESCE((
+        SELECT STRING_AGG(DISTINCT term, ' ')
+        FROM (
+            SELECT v.sku AS term
+            FROM variants v
+            WHERE v.product_id = pid AND v.sku IS NOT NULL
+            UNION ALL
+            SELECT o.value
+            FROM variant_options o
+            JOIN variants v ON v.id = o.variant_id
+            WHERE v.product_id = pid
+            UNION ALL
+            SELECT u.barcode
+            FROM variant_units u
+            JOIN variants v ON v.id = u.variant_id
+            WHERE v.product_id = pid AND u.barcode IS NOT NULL
+        ) t
+    ), '')
+    WHERE p.id = pid;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION variants_refresh_search() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM refresh_product_search_terms(NEW.product_id);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_product_search_terms(OLD.product_id);
+    ELSE
+        PERFORM refresh_product_search_terms(OLD.product_id);
+        IF NEW.product_id <> OLD.product_id THEN
+            PERFORM refresh_product_search_terms(NEW.product_id);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- option & unit: cari product lewat variant (bisa sudah terhapus saat cascade)
+CREATE OR REPLACE FUNCTION variant_children_refresh_search() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_product_search_terms(v.product_id) FROM variants v WHERE v.id = OLD.variant_id;
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.variant_id <> OLD.variant_id) THEN
+        PERFORM refresh_product_search_terms(v.product_id) FROM variants v WHERE v.id = NEW.variant_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_variants_refresh_search
+AFTER INSERT OR DELETE OR UPDATE OF sku, product_id ON variants
+FOR EACH ROW EXECUTE FUNCTION variants_refresh_search();
+
+CREATE TRIGGER trg_variant_options_refresh_search
+AFTER INSERT OR DELETE OR UPDATE OF value, variant_id ON variant_options
+FOR EACH ROW EXECUTE FUNCTION variant_children_refresh_search();
+
+CREATE TRIGGER trg_variant_units_refresh_search
+AFTER INSERT OR DELETE OR UPDATE OF barcode, variant_id ON variant_units
+FOR EACH ROW EXECUTE FUNCTION variant_children_refresh_search();
+
+-- isi untuk product yang sudah ada
+SELECT refresh_product_search_terms(id) FROM products;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_variant_units_refresh_search ON variant_units;
+DROP TRIGGER IF EXISTS trg_variant_options_refresh_search ON variant_options;
+DROP TRIGGER IF EXISTS trg_variants_refresh_search ON variants;
+DROP FUNCTION IF EXISTS variant_children_refresh_search();
+DROP FUNCTION IF EXISTS variants_refresh_search();
+DROP FUNCTION IF EXISTS refresh_product_search_terms(BIGINT);
+
+DROP INDEX IF EXISTS idx_products_search_terms_trgm;
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search_vector;
+
+ALTER TABLE products DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE products DROP COLUMN IF EXISTS search_terms;
+
+-- +goose StatementEnd
@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- keyset cursor membandingkan (kolom sort, id), NULL akan merusak urutan
+UPDATE products SET created_at = NOW() WHERE created_at IS NULL;
+UPDATE products SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE products
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET NOT NULL;
+
+-- harga termurah semua unit, dirangkum trigger supaya sort=price bisa
+-- memakai index seperti kolom lain
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS min_price BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_products_name_id ON products (name, id);
+CREATE INDEX IF NOT EXISTS idx_products_created_at_id ON products (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_updated_at_id ON products (updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_products_min_price_id ON products (min_price, id);
+
+CREATE OR REPLACE FUNCTION refresh_product_min_price(pid BIGINT) RETURNS VOID AS $$
+BEGIN
+    UPDATE products p
+    SET min_price = COALESCE((
+        SELECT MIN(u.price)
+        FROM variant_units u
+        JOIN variants v ON v.id = u.variant_id
+        WHERE v.product_id = pid
+    ), 0)
+    WHERE p.id = pid;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION variants_refresh_min_price() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_product_min_price(OLD.product_id);
+    IF TG_OP = 'UPDATE' AND NEW.product_id <> OLD.product_id THEN
+        PERFORM refresh_product_min_price(NEW.product_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- unit: cari product lewat variant (bisa sudah terhapus saat cascade)
+CREATE OR REPLACE FUNCTION variant_units_refresh_min_price() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM refresh_product_min_price(v.product_id) FROM variants v WHERE v.id = OLD.variant_id;
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.variant_id <> OLD.variant_id) THEN
+        PERFORM refresh_product_min_price(v.product_id) FROM variants v WHERE v.id = NEW.variant_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- varian baru belum punya unit, cukup dipantau saat hapus/pindah product
+CREATE TRIGGER trg_variants_refresh_min_price
+AFTER DELETE OR UPDATE OF product_id ON variants
+FOR EACH ROW EXECUTE FUNCTION variants_refresh_min_price();
+
+CREATE TRIGGER trg_variant_units_refresh_min_price
+AFTER INSERT OR DELETE OR UPDATE OF price, variant_id ON variant_units
+FOR EACH ROW EXECUTE FUNCTION variant_units_refresh_min_price();
+
+-- isi untuk product yang sudah ada
+SELECT refresh_product_min_price(id) FROM products;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER IF EXISTS trg_variant_units_refresh_min_price ON variant_units;
+DROP TRIGGER IF EXISTS trg_variants_refresh_min_price ON variants;
+DROP FUNCTION IF EXISTS variant_units_refresh_min_price();
+DROP FUNCTION IF EXISTS variants_refresh_min_price();
+DROP FUNCTION IF EXISTS refresh_product_min_price(BIGINT);
+
+DROP INDEX IF EXISTS idx_products_min_price_id;
+DROP INDEX IF EXISTS idx_products_updated_at_id;
+DROP INDEX IF EXISTS idx_products_created_at_id;
+DROP INDEX IF EXISTS idx_products_name_id;
+
+ALTER TABLE products DROP COLUMN IF EXISTS min_price;
+
+ALTER TABLE products
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at DROP NOT NULL;
+
+-- +goose StatementEnd
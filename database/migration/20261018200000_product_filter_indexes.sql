@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- filter product memakai EXISTS per product, jadi join dari sisi product_id
+CREATE INDEX IF NOT EXISTS idx_category_products_product_id
+ON category_products (product_id, category_id);
+
+CREATE INDEX IF NOT EXISTS idx_variants_product_id_stock
+ON variants (product_id, stock);
+
+CREATE INDEX IF NOT EXISTS idx_variant_units_variant_id_price
+ON variant_units (variant_id, price);
+
+-- rentang harga yang sempit lebih murah dicari dari sisi harga
+CREATE INDEX IF NOT EXISTS idx_variant_units_price
+ON variant_units (price);
+
+CREATE INDEX IF NOT EXISTS idx_variant_units_missing_barcode
+ON variant_units (variant_id) WHERE barcode IS NULL OR barcode = '';
+
+-- option dicocokkan tanpa membedakan huruf besar/kecil
+CREATE INDEX IF NOT EXISTS idx_variant_options_name_value
+ON variant_options (LOWER(name), LOWER(value), variant_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_variant_options_name_value;
+DROP INDEX IF EXISTS idx_variant_units_missing_barcode;
+DROP INDEX IF EXISTS idx_variant_units_price;
+DROP INDEX IF EXISTS idx_variant_units_variant_id_price;
+DROP INDEX IF EXISTS idx_variants_product_id_stock;
+DROP INDEX IF EXISTS idx_category_products_product_id;
+
+-- +goose StatementEnd
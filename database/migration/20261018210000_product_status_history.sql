@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS product_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT NOT NULL,
+    transition VARCHAR(20) NOT NULL,
+    from_status VARCHAR(15) NOT NULL,
+    to_status VARCHAR(15) NOT NULL,
+    -- NULL untuk api key, nama tetap disimpan walau user dihapus
+    changed_by BIGINT,
+    changed_by_name VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_status_history_product_id
+ON product_status_history(product_id, id);
+
+-- list default menyembunyikan archived
+CREATE INDEX IF NOT EXISTS idx_products_not_archived
+ON products(id) WHERE status <> 'archived';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_products_not_archived;
+DROP TABLE IF EXISTS product_status_history;
+
+-- +goose StatementEnd
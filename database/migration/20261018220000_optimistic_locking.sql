@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- naik setiap kali product / kategori diubah, dikirim ke klien sebagai ETag
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+
+-- +goose StatementEnd
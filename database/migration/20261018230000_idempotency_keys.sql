@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- respons POST yang disimpan per Idempotency-Key, dipakai ulang saat client retry
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    -- pemilik key (user / api key), key antar client tidak saling bentrok
+    scope VARCHAR(150) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    -- 0 selama request pertama masih diproses
+    status_code INT NOT NULL DEFAULT 0,
+    content_type VARCHAR(100) NOT NULL DEFAULT '',
+    body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at
+ON idempotency_keys(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- ledger juga tidak boleh dihapus, termasuk lewat TRUNCATE
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+
+CREATE TRIGGER trg_stock_movements_append_only
+BEFORE UPDATE OR DELETE ON stock_movements
+FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+CREATE TRIGGER trg_stock_movements_no_truncate
+BEFORE TRUNCATE ON stock_movements
+FOR EACH STATEMENT EXECUTE FUNCTION stock_movements_append_only();
+
+-- varian yang sudah punya riwayat stok tidak bisa dihapus diam-diam
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_variant_id_fkey;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_variant_id_fkey
+    FOREIGN KEY (variant_id) REFERENCES variants(id) ON DELETE RESTRICT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE stock_movements DROP CONSTRAINT IF EXISTS stock_movements_variant_id_fkey;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_variant_id_fkey
+    FOREIGN KEY (variant_id) REFERENCES variants(id) ON DELETE CASCADE;
+
+DROP TRIGGER IF EXISTS trg_stock_movements_no_truncate ON stock_movements;
+DROP TRIGGER IF EXISTS trg_stock_movements_append_only ON stock_movements;
+
+CREATE TRIGGER trg_stock_movements_append_only
+BEFORE UPDATE ON stock_movements
+FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- +goose StatementEnd
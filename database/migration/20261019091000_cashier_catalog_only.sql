@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- kasir hanya boleh membaca katalog
+DELETE FROM role_permissions
+WHERE role = 'cashier' AND permission <> 'catalog.read';
+
+UPDATE roles SET description = 'Kasir: baca katalog' WHERE name = 'cashier';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('cashier', 'sale.create'),
+    ('cashier', 'sale.read'),
+    ('cashier', 'stock.read')
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET description = 'Kasir: baca katalog dan transaksi penjualan' WHERE name = 'cashier';
+
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- antrean pengiriman webhook, satu row per subscription per event (atau per
+-- replay dead letter). Row dihapus setelah terkirim atau masuk dead letter.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL,
+    event_id BIGINT NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    dead_letter_id BIGINT,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    response_status INT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+    FOREIGN KEY (dead_letter_id) REFERENCES webhook_dead_letters(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at);
+
+-- event yang dikirim ulang relay tidak diantrekan dua kali
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event
+    ON webhook_deliveries(subscription_id, event_id) WHERE dead_letter_id IS NULL;
+
+-- satu replay per dead letter dalam antrean
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_dead_letter
+    ON webhook_deliveries(dead_letter_id) WHERE dead_letter_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS webhook_deliveries;
+
+-- +goose StatementEnd
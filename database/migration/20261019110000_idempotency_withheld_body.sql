@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- respons yang berisi secret hanya disimpan status-nya, retry ditolak 409
+-- karena body-nya tidak bisa diputar ulang
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS body_withheld BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS body_withheld;
+
+-- +goose StatementEnd
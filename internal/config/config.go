@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
 	"github.com/joho/godotenv"
@@ -13,6 +14,35 @@ type Config struct {
 	StoragePath string
 	Port        string
 	Environment string
+
+	// image storage: "local" (default) atau "minio"
+	StorageDriver  string
+	MinioEndpoint  string
+	MinioAccessKey string
+	MinioSecretKey string
+	MinioBucket    string
+	MinioBaseURL   string
+	MinioUseSSL    bool
+
+	// garbage collector gambar yatim, interval 0 = background job mati
+	ImageGCInterval   time.Duration
+	ImageGCGrace      time.Duration
+	ImageGCQuarantine string
+
+	// outbox relay: "" (mati), "http", "file" atau "webhooks"
+	OutboxSink         string
+	OutboxWebhookURL   string
+	OutboxFile         string
+	OutboxPollInterval time.Duration
+
+	// auth
+	JWTPrivateKeyPath string
+	JWTIssuer         string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+
+	// respons POST dengan Idempotency-Key disimpan selama ini
+	IdempotencyTTL time.Duration
 }
 
 func LoadConfig() *Config {
@@ -27,5 +57,49 @@ func LoadConfig() *Config {
 		Port:        os.Getenv("HTTP_PORT"),
 		Environment: os.Getenv("ENVIRONMENT"),
 		StoragePath: os.Getenv("STORAGE_PATH"),
+
+		StorageDriver:  getEnv("STORAGE_DRIVER", "local"),
+		MinioEndpoint:  os.Getenv("MINIO_ENDPOINT"),
+		MinioAccessKey: os.Getenv("MINIO_ACCESS_KEY"),
+		MinioSecretKey: os.Getenv("MINIO_SECRET_KEY"),
+		MinioBucket:    os.Getenv("MINIO_BUCKET"),
+		MinioBaseURL:   os.Getenv("MINIO_BASE_URL"),
+		MinioUseSSL:    os.Getenv("MINIO_USE_SSL") == "true",
+
+		ImageGCInterval:   getDuration("IMAGE_GC_INTERVAL", 0),
+		ImageGCGrace:      getDuration("IMAGE_GC_GRACE", 24*time.Hour),
+		ImageGCQuarantine: os.Getenv("IMAGE_GC_QUARANTINE"),
+
+		OutboxSink:         os.Getenv("OUTBOX_SINK"),
+		OutboxWebhookURL:   os.Getenv("OUTBOX_WEBHOOK_URL"),
+		OutboxFile:         getEnv("OUTBOX_FILE", "outbox.jsonl"),
+		OutboxPollInterval: getDuration("OUTBOX_POLL_INTERVAL", 2*time.Second),
+
+		JWTPrivateKeyPath: os.Getenv("JWT_PRIVATE_KEY_PATH"),
+		JWTIssuer:         getEnv("JWT_ISSUER", "belajar-clean-arch"),
+		AccessTokenTTL:    getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:   getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		IdempotencyTTL: getDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		logger.Fatalf("Invalid duration for %s: %v", key, err)
 	}
+	return d
 }
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/minio"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+)
+
+// NewStorage memilih driver penyimpanan gambar dari STORAGE_DRIVER
+func NewStorage(cfg *Config) (storage.Driver, error) {
+	switch cfg.StorageDriver {
+	case "local":
+		return storage.NewLocal(cfg.StoragePath), nil
+	case "minio":
+		return minio.NewMinioClient(
+			cfg.MinioEndpoint,
+			cfg.MinioAccessKey,
+			cfg.MinioSecretKey,
+			cfg.MinioBucket,
+			cfg.MinioBaseURL,
+			cfg.MinioUseSSL,
+		)
+	}
+
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.StorageDriver)
+}
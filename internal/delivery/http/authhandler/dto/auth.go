@@ -0,0 +1,48 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type PrincipalResponse struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func MapTokenResponse(pair *userModel.TokenPair) TokenResponse {
+	return TokenResponse{
+		AccessToken:      pair.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(time.Until(pair.AccessExpiresAt).Seconds()),
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
+
+func MapPrincipalResponse(p *userModel.Principal) PrincipalResponse {
+	return PrincipalResponse{
+		UserID:   p.UserID,
+		Username: p.Username,
+		Role:     p.Role,
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/authhandler/dto"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/authcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/dona-dllollin/belajar-clean-arch/utils/response"
+)
+
+type authHandler struct {
+	authService authcase.AuthService
+	validator   validation.Validation
+}
+
+func NewAuthHandler(authService authcase.AuthService, validator validation.Validation) *authHandler {
+	return &authHandler{
+		authService: authService,
+		validator:   validator,
+	}
+}
+
+// LOGIN
+func (h *authHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req dto.LoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.authService.Login(r.Context(), req.Username, req.Password)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapTokenResponse(pair))
+}
+
+// REFRESH TOKEN
+func (h *authHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.refreshRequest(w, r)
+	if !ok {
+		return
+	}
+
+	pair, err := h.authService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapTokenResponse(pair))
+}
+
+// LOGOUT
+func (h *authHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.refreshRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.authService.Logout(r.Context(), req.RefreshToken); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success")
+}
+
+// CURRENT PRINCIPAL
+func (h *authHandler) Me(w http.ResponseWriter, r *http.Request) {
+	principal := userModel.PrincipalFromContext(r.Context())
+	if principal == nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrUnauthorized)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapPrincipalResponse(principal))
+}
+
+func (h *authHandler) refreshRequest(w http.ResponseWriter, r *http.Request) (dto.RefreshRequest, bool) {
+	var req dto.RefreshRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return req, false
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+
+	return req, true
+}
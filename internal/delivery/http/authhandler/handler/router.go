@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/userrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/authcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(
+	r chi.Router,
+	db *txmanager.Manager,
+	validator validation.Validation,
+	tokens authcase.TokenIssuer,
+	refreshTTL time.Duration,
+	authenticate func(http.Handler) http.Handler,
+) {
+
+	userRepository := userrepo.NewUserRepository(db)
+	AuthUseCase := authcase.NewAuthService(userRepository, db, tokens, refreshTTL)
+	authHandler := NewAuthHandler(AuthUseCase, validator)
+
+	r.Post("/login", authHandler.Login)
+	r.Post("/refresh", authHandler.Refresh)
+	r.Post("/logout", authHandler.Logout)
+	r.With(authenticate).Get("/me", authHandler.Me)
+}
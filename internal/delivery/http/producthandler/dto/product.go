@@ -2,6 +2,7 @@ package dto
 
 import (
 	"mime/multipart"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
 )
@@ -9,33 +10,37 @@ import (
 type CreateProductRequest struct {
 	Name        string `validate:"required"`
 	Description string
+	StockPolicy string `validate:"omitempty,oneof=block allow_negative warn"`
+	Status      string `validate:"omitempty,oneof=active inactive"` // hanya saat create
 	CategoryId  []*int64
 	Images      []*multipart.FileHeader // << ini untuk upload
 	Variants    []VariantRequest        `json:"variants"`
 }
 
 type VariantRequest struct {
+	ID        int64                  `json:"id"`
 	SKU       string                 `json:"sku"`
-	Options   []VariantOptionRequest `json:"options"`
-	BaseUnit  string                 `json:"base_unit"`
-	Stock     int                    `json:"stock"`
-	CostPrice int64                  `json:"cost_price"`
-	Units     []VariantUnitRequest   `json:"units"`
+	Options   []VariantOptionRequest `json:"options" validate:"dive"`
+	BaseUnit  string                 `json:"base_unit" validate:"required"`
+	Stock     int                    `json:"stock" validate:"gte=0"`
+	CostPrice int64                  `json:"cost_price" validate:"gte=0"`
+	Units     []VariantUnitRequest   `json:"units" validate:"dive"`
 }
 
 type VariantOptionRequest struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	ID    int64  `json:"id"`
+	Name  string `json:"name" validate:"required"`
+	Value string `json:"value" validate:"required"`
 }
 
 type VariantUnitRequest struct {
-	ID             int64   // Just to be compatible to productModel
-	VariantID      int64   // Same
-	Name           string  `json:"name"`
+	ID             int64   `json:"id"`
+	VariantID      int64   `json:"-"` // Just to be compatible to productModel
+	Name           string  `json:"name" validate:"required"`
 	SKU            *string `json:"sku"`
 	Barcode        *string `json:"barcode"`
-	ConversionRate int     `json:"conversion_rate"`
-	Price          int64   `json:"price"`
+	ConversionRate int     `json:"conversion_rate" validate:"gt=0"`
+	Price          int64   `json:"price" validate:"gte=0"`
 }
 
 type CreateCategory struct {
@@ -55,6 +60,56 @@ type ListCategories struct {
 	ParentID *int64 `json:"parent_id"`
 }
 
+type CategoryTree struct {
+	ID       int64          `json:"id"`
+	Name     string         `json:"name"`
+	ParentID *int64         `json:"parent_id"`
+	Children []CategoryTree `json:"children"`
+}
+
+func MapCategoryTree(nodes []*productModel.CategoryNode) []CategoryTree {
+	tree := []CategoryTree{}
+	for _, n := range nodes {
+		tree = append(tree, CategoryTree{
+			ID:       n.ID,
+			Name:     n.Name,
+			ParentID: n.ParentID,
+			Children: MapCategoryTree(n.Children),
+		})
+	}
+
+	return tree
+}
+
+func MapCategories(categories []productModel.Category) []ListCategories {
+	res := []ListCategories{}
+	for _, c := range categories {
+		res = append(res, ListCategories{ID: c.ID, Name: c.Name, ParentID: c.ParentID})
+	}
+
+	return res
+}
+
+type CategoryBlockers struct {
+	Error        string  `json:"error"`
+	Children     []int64 `json:"children"`
+	Products     []int64 `json:"products"`
+	ProductCount int     `json:"product_count"`
+}
+
+func MapCategoryBlockers(b *productModel.CategoryBlockers) CategoryBlockers {
+	res := CategoryBlockers{
+		Error:        "category is still in use",
+		Children:     []int64{},
+		Products:     []int64{},
+		ProductCount: b.ProductCount,
+	}
+	res.Children = append(res.Children, b.Children...)
+	res.Products = append(res.Products, b.Products...)
+
+	return res
+}
+
 type ImagePayload struct {
 	ID        *int64 `json:"id,omitempty"`
 	Action    string `json:"action"`
@@ -80,3 +135,108 @@ func MapUnits(units []VariantUnitRequest) []productModel.VariantUnit {
 
 	return productUnits
 }
+
+func MapVariant(v VariantRequest) productModel.Variant {
+	return productModel.Variant{
+		ID:        v.ID,
+		SKU:       v.SKU,
+		BaseUnit:  v.BaseUnit,
+		Stock:     v.Stock,
+		CostPrice: v.CostPrice,
+		Options:   MapOptions(v.Options),
+		Units:     MapUnits(v.Units),
+	}
+}
+
+type SearchResult struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Status      string  `json:"status"`
+	Score       float64 `json:"score"`
+	Highlight   string  `json:"highlight"`
+	Snippet     string  `json:"snippet"`
+}
+
+func MapSearchResults(hits []productModel.SearchHit) []SearchResult {
+	res := []SearchResult{}
+	for _, h := range hits {
+		res = append(res, SearchResult{
+			ID:          h.Product.ID,
+			Name:        h.Product.Name,
+			Description: h.Product.Description,
+			Status:      h.Product.Status,
+			Score:       h.Score,
+			Highlight:   h.Highlight,
+			Snippet:     h.Snippet,
+		})
+	}
+
+	return res
+}
+
+type StatusChangeResponse struct {
+	ID            int64     `json:"id"`
+	ProductID     int64     `json:"product_id"`
+	Transition    string    `json:"transition"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	ChangedBy     *int64    `json:"changed_by"`
+	ChangedByName string    `json:"changed_by_name"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+func MapStatusChange(c productModel.StatusChange) StatusChangeResponse {
+	return StatusChangeResponse{
+		ID:            c.ID,
+		ProductID:     c.ProductID,
+		Transition:    string(c.Transition),
+		FromStatus:    c.FromStatus,
+		ToStatus:      c.ToStatus,
+		ChangedBy:     c.ChangedBy.UserID,
+		ChangedByName: c.ChangedBy.Name,
+		ChangedAt:     c.ChangedAt,
+	}
+}
+
+type ScanOption struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ScanResponse struct {
+	ProductID      int64        `json:"product_id"`
+	ProductName    string       `json:"product_name"`
+	VariantID      int64        `json:"variant_id"`
+	SKU            string       `json:"sku"`
+	BaseUnit       string       `json:"base_unit"`
+	Stock          int          `json:"stock"`
+	Options        []ScanOption `json:"options"`
+	UnitID         int64        `json:"unit_id"`
+	UnitName       string       `json:"unit_name"`
+	ConversionRate int          `json:"conversion_rate"`
+	Price          int64        `json:"price"`
+	MatchedBy      string       `json:"matched_by"`
+}
+
+func MapScanResponse(item *productModel.ScanItem) ScanResponse {
+	options := []ScanOption{}
+	for _, o := range item.Variant.Options {
+		options = append(options, ScanOption{Name: o.Name, Value: o.Value})
+	}
+
+	return ScanResponse{
+		ProductID:      item.ProductID,
+		ProductName:    item.ProductName,
+		VariantID:      item.Variant.ID,
+		SKU:            item.Variant.SKU,
+		BaseUnit:       item.Variant.BaseUnit,
+		Stock:          item.Variant.Stock,
+		Options:        options,
+		UnitID:         item.UnitID,
+		UnitName:       item.UnitName,
+		ConversionRate: item.ConversionRate,
+		Price:          item.Price,
+		MatchedBy:      item.MatchedBy,
+	}
+}
@@ -2,12 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/producthandler/dto"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/exportcase"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/imagecase"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
@@ -19,13 +27,15 @@ import (
 type productHandler struct {
 	productService productcase.ProductService
 	imageService   imagecase.ImageService
+	importService  importcase.ImportService
 	validator      validation.Validation
 }
 
-func NewProductHandler(productService productcase.ProductService, validator validation.Validation, imageService imagecase.ImageService) *productHandler {
+func NewProductHandler(productService productcase.ProductService, validator validation.Validation, imageService imagecase.ImageService, importService importcase.ImportService) *productHandler {
 	return &productHandler{
 		productService: productService,
 		imageService:   imageService,
+		importService:  importService,
 		validator:      validator,
 	}
 }
@@ -44,6 +54,8 @@ func (h *productHandler) StoreProduct(w http.ResponseWriter, r *http.Request) {
 	// string
 	req.Name = r.FormValue("name")
 	req.Description = r.FormValue("description")
+	req.StockPolicy = r.FormValue("stock_policy")
+	req.Status = r.FormValue("status")
 
 	// category[] -> []*int64
 	categoryValues := r.MultipartForm.Value["category_id"]
@@ -76,6 +88,8 @@ func (h *productHandler) StoreProduct(w http.ResponseWriter, r *http.Request) {
 	product := productModel.Product{
 		Name:        req.Name,
 		Description: req.Description,
+		StockPolicy: req.StockPolicy,
+		Status:      req.Status,
 		CategoryId:  req.CategoryId,
 	}
 
@@ -86,7 +100,7 @@ func (h *productHandler) StoreProduct(w http.ResponseWriter, r *http.Request) {
 	if len(files) > 0 {
 		for i, file := range files {
 			currentSortOrder := i + 1
-			url, err := h.imageService.ImageUpload(r.Context(), file)
+			image, err := h.imageService.ImageUpload(r.Context(), file)
 			if err != nil {
 				logger.Error("gagal upload gambar", err.Error())
 				errorUtils.WriteHTTPError(w, err)
@@ -94,23 +108,14 @@ func (h *productHandler) StoreProduct(w http.ResponseWriter, r *http.Request) {
 			}
 
 			// add in product model
-			product.Images = append(product.Images, productModel.ProductImage{
-				URL:       url,
-				SortOrder: currentSortOrder,
-			})
+			image.SortOrder = currentSortOrder
+			product.Images = append(product.Images, *image)
 		}
 	}
 
 	// variants → domain
 	for _, v := range req.Variants {
-		product.Variants = append(product.Variants, productModel.Variant{
-			SKU:       v.SKU,
-			BaseUnit:  v.BaseUnit,
-			Stock:     v.Stock,
-			CostPrice: v.CostPrice,
-			Options:   dto.MapOptions(v.Options),
-			Units:     dto.MapUnits(v.Units),
-		})
+		product.Variants = append(product.Variants, dto.MapVariant(v))
 	}
 
 	//	panggil service product
@@ -125,8 +130,59 @@ func (h *productHandler) StoreProduct(w http.ResponseWriter, r *http.Request) {
 
 }
 
-// GET LIST PRODUCT
-func (h *productHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+// IMPORT PRODUCT (CSV / XLSX)
+func (h *productHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10 MB
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file is required", http.StatusBadRequest)
+		return
+	}
+	file.Close()
+
+	// default dry-run, data baru disimpan dengan dry_run=false
+	dryRun := true
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := h.importService.Import(r.Context(), header, dryRun)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	switch {
+	case report.HasErrors():
+		response.JSON(w, http.StatusUnprocessableEntity, "error", report)
+	case dryRun:
+		response.JSON(w, http.StatusOK, "success", report)
+	default:
+		response.JSON(w, http.StatusCreated, "success", report)
+	}
+}
+
+// productQueryParams adalah query yang bukan filter opsi varian; query lain
+// (?color=red&size=M) dibaca sebagai nama opsi
+var productQueryParams = map[string]bool{
+	"search": true, "category_id": true, "category_match": true, "include_descendants": true,
+	"status": true, "min_price": true, "max_price": true, "stock_status": true,
+	"low_stock_threshold": true, "has_barcode": true, "created_from": true, "created_to": true,
+	"updated_from": true, "updated_to": true, "limit": true, "page": true, "sort": true,
+	"direction": true, "include_total": true, "cursor": true, "q": true, "format": true,
+}
+
+// parseProductFilter membaca query filter yang dipakai list, search dan export.
+// Error berisi pesan untuk klien (400).
+func parseProductFilter(r *http.Request) (productcase.ProductFilter, error) {
 	var filter productcase.ProductFilter
 
 	// Query params
@@ -134,17 +190,80 @@ func (h *productHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 
 	filter.Search = q.Get("search")
 
-	if catID := q.Get("category_id"); catID != "" {
-		id, err := strconv.ParseInt(catID, 10, 64)
-		if err == nil {
-			filter.CategoryID = &id
+	// category_id boleh berulang: ?category_id=1&category_id=2
+	for _, v := range q["category_id"] {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid category_id")
 		}
+		filter.CategoryIDs = append(filter.CategoryIDs, id)
 	}
+	filter.CategoryMatch = q.Get("category_match")
+
+	// ikutkan product dari seluruh sub-kategori
+	filter.IncludeDescendants, _ = strconv.ParseBool(q.Get("include_descendants"))
 
 	if status := q.Get("status"); status != "" {
 		filter.Status = &status
 	}
 
+	for param, dst := range map[string]**int64{"min_price": &filter.MinPrice, "max_price": &filter.MaxPrice} {
+		if v := q.Get(param); v != "" {
+			price, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", param)
+			}
+			*dst = &price
+		}
+	}
+
+	filter.StockState = q.Get("stock_status")
+	if v := q.Get("low_stock_threshold"); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold <= 0 {
+			return filter, errors.New("invalid low_stock_threshold")
+		}
+		filter.LowStockThreshold = threshold
+	}
+
+	// color=red&size=M, nama sama boleh berulang (salah satu). Opsi yang
+	// namanya bentrok dengan query lain ditulis option[status]=new.
+	for key, values := range q {
+		name := key
+		if strings.HasPrefix(key, "option[") && strings.HasSuffix(key, "]") {
+			name = strings.TrimSuffix(strings.TrimPrefix(key, "option["), "]")
+		} else if productQueryParams[key] {
+			continue
+		}
+		if filter.Options == nil {
+			filter.Options = map[string][]string{}
+		}
+		filter.Options[name] = append(filter.Options[name], values...)
+	}
+
+	if v := q.Get("has_barcode"); v != "" {
+		hasBarcode, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid has_barcode")
+		}
+		filter.HasBarcode = &hasBarcode
+	}
+
+	for param, dst := range map[string]**time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+		"updated_from": &filter.UpdatedFrom,
+		"updated_to":   &filter.UpdatedTo,
+	} {
+		if v := q.Get(param); v != "" {
+			t, err := parseFilterTime(v, strings.HasSuffix(param, "_to"))
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", param)
+			}
+			*dst = &t
+		}
+	}
+
 	limit, _ := strconv.Atoi(q.Get("limit"))
 	page, _ := strconv.Atoi(q.Get("page"))
 
@@ -156,13 +275,163 @@ func (h *productHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 		filter.Offset = (page - 1) * limit
 	}
 
-	products, err := h.productService.ListProducts(r.Context(), filter)
+	return filter, nil
+}
+
+// parseFilterTime menerima RFC3339 atau tanggal saja (YYYY-MM-DD, UTC).
+// Batas akhir berupa tanggal ikut menyertakan hari itu (eksklusif besoknya).
+func parseFilterTime(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return t, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+// searchCursor posisi halaman pencarian (offset, karena urut relevansi)
+type searchCursor struct {
+	Offset int `json:"o"`
+}
+
+// GET LIST PRODUCT
+func (h *productHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	filter.Sort = q.Get("sort")
+	filter.Direction = q.Get("direction")
+	filter.WithTotal, _ = strconv.ParseBool(q.Get("include_total"))
+
+	if c := q.Get("cursor"); c != "" {
+		var cursor productrepo.ProductCursor
+		if err := response.DecodeCursor(c, &cursor); err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.Cursor = &cursor
+	}
+
+	page, err := h.productService.ListProducts(r.Context(), filter)
 	if err != nil {
 		errorUtils.WriteHTTPError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, "success", products)
+	meta := response.Page{Limit: page.Limit, Total: page.Total}
+	if page.Next != nil {
+		meta.NextCursor, err = response.EncodeCursor(page.Next)
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+		meta.HasMore = true
+	}
+
+	products := page.Products
+	if products == nil {
+		products = []productModel.Product{}
+	}
+
+	response.Paginated(w, http.StatusOK, "success", products, meta)
+}
+
+// SEARCH PRODUCT (full-text + typo, urut relevansi)
+func (h *productHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := q.Get("q")
+	if strings.TrimSpace(query) == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		var cursor searchCursor
+		if err := response.DecodeCursor(c, &cursor); err != nil || cursor.Offset < 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = cursor.Offset
+	}
+
+	page, err := h.productService.SearchProducts(r.Context(), query, filter)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	meta := response.Page{Limit: page.Limit}
+	if page.NextOffset != nil {
+		meta.NextCursor, err = response.EncodeCursor(searchCursor{Offset: *page.NextOffset})
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+		meta.HasMore = true
+	}
+
+	response.Paginated(w, http.StatusOK, "success", dto.MapSearchResults(page.Hits), meta)
+}
+
+// EXPORT PRODUCT (CSV / JSONL / XLSX)
+func (h *productHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportcase.FormatCSV
+	}
+
+	contentType, ext, err := exportcase.ContentType(format)
+	if err != nil {
+		http.Error(w, "format must be csv, jsonl or xlsx", http.StatusBadRequest)
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer, err := exportcase.NewWriter(format, w)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	filename := "products-" + time.Now().Format("20060102-150405") + ext
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	err = h.productService.ExportProducts(r.Context(), filter, writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		// status sudah terkirim jika streaming sudah berjalan, cukup dicatat
+		if writer.Started() {
+			logger.Errorf("export products interrupted, error: %s", err)
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		errorUtils.WriteHTTPError(w, err)
+	}
 }
 
 // GET PRODUCT BY ID
@@ -184,6 +453,7 @@ func (h *productHandler) GetProductById(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	response.SetETag(w, product.Version)
 	response.JSON(w, http.StatusOK, "success", product)
 }
 
@@ -196,6 +466,13 @@ func (h *productHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// optimistic locking: versi dari ETag GetProductById
+	version, err := response.IfMatchVersion(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
 	// Parsing Multipart similar to Store
 	if err := r.ParseForm(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -205,6 +482,7 @@ func (h *productHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	var req dto.CreateProductRequest
 	req.Name = r.FormValue("name")
 	req.Description = r.FormValue("description")
+	req.StockPolicy = r.FormValue("stock_policy")
 
 	// category[]
 	categoryValues := r.Form["category_id"]
@@ -217,11 +495,34 @@ func (h *productHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		req.CategoryId = append(req.CategoryId, &catID)
 	}
 
+	// variants (JSON), tidak dikirim berarti varian tidak diubah
+	variantsStr, hasVariants := r.Form["variants"]
+	if hasVariants {
+		if err := json.Unmarshal([]byte(variantsStr[0]), &req.Variants); err != nil {
+			http.Error(w, "invalid variants json", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	product := productModel.Product{
 		ID:          id,
 		Name:        req.Name,
 		Description: req.Description,
+		StockPolicy: req.StockPolicy,
 		CategoryId:  req.CategoryId,
+		Version:     version,
+	}
+
+	if hasVariants {
+		product.Variants = make([]productModel.Variant, 0, len(req.Variants))
+		for _, v := range req.Variants {
+			product.Variants = append(product.Variants, dto.MapVariant(v))
+		}
 	}
 
 	err = h.productService.UpdateProduct(r.Context(), &product)
@@ -230,6 +531,11 @@ func (h *productHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// versi pasti naik satu jika cocok; If-Match "*" tidak tahu versi barunya
+	if version > 0 {
+		response.SetETag(w, version+1)
+	}
+
 	response.JSON(w, http.StatusOK, "success", product.ID)
 }
 
@@ -242,17 +548,21 @@ func (h *productHandler) UpdateImageProduct(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// optimistic locking sama dengan UpdateProduct
+	version, err := response.IfMatchVersion(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
 	// parsing Multipart
 	if err := r.ParseMultipartForm(10 << 20); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	var product productModel.Product
-	product.ID = id
-
-	// wadah untuk url gambar yang akan dihapus
-	var oldImages []string
+	// gambar yang tidak disebut di payload tidak diubah
+	var changes productModel.ImageChanges
 
 	// Handle Image
 	var payload []dto.ImagePayload
@@ -271,47 +581,40 @@ func (h *productHandler) UpdateImageProduct(w http.ResponseWriter, r *http.Reque
 				errorUtils.WriteHTTPError(w, err)
 				return
 			}
-			url, err := h.imageService.ImageUpload(r.Context(), images)
+			image, err := h.imageService.ImageUpload(r.Context(), images)
 			if err != nil {
 				errorUtils.WriteHTTPError(w, err)
 				return
 			}
 
-			product.Images = append(product.Images, productModel.ProductImage{
-				URL:       url,
-				SortOrder: p.SortOrder,
-			})
+			image.SortOrder = p.SortOrder
+			changes.Upsert = append(changes.Upsert, *image)
 		case "replace":
-			oldUrl, err := h.productService.GetProductImage(r.Context(), *p.ID)
-			if err != nil {
-				errorUtils.WriteHTTPError(w, err)
+			if p.ID == nil {
+				http.Error(w, "id is required for replace", http.StatusBadRequest)
 				return
 			}
-			oldImages = append(oldImages, oldUrl)
 			_, images, err := r.FormFile(p.FileKey)
 			if err != nil {
 				errorUtils.WriteHTTPError(w, err)
 				return
 			}
-			url, err := h.imageService.ImageUpload(r.Context(), images)
+			image, err := h.imageService.ImageUpload(r.Context(), images)
 			if err != nil {
 				errorUtils.WriteHTTPError(w, err)
 				return
 			}
 
-			product.Images = append(product.Images, productModel.ProductImage{
-				ID:        *p.ID,
-				URL:       url,
-				SortOrder: p.SortOrder,
-			})
+			image.ID = *p.ID
+			image.SortOrder = p.SortOrder
+			changes.Upsert = append(changes.Upsert, *image)
 
 		case "delete":
-			oldUrl, err := h.productService.GetProductImage(r.Context(), *p.ID)
-			if err != nil {
-				errorUtils.WriteHTTPError(w, err)
+			if p.ID == nil {
+				http.Error(w, "id is required for delete", http.StatusBadRequest)
 				return
 			}
-			oldImages = append(oldImages, oldUrl)
+			changes.Delete = append(changes.Delete, *p.ID)
 
 		default:
 			http.Error(w, "invalid action", http.StatusBadRequest)
@@ -320,21 +623,36 @@ func (h *productHandler) UpdateImageProduct(w http.ResponseWriter, r *http.Reque
 
 	}
 
-	err = h.productService.UpdateProduct(r.Context(), &product)
+	// hanya file dari baris yang benar-benar dilepas di transaksi yang dihapus
+	removed, err := h.productService.UpdateProductImages(r.Context(), id, version, changes)
 	if err != nil {
 		errorUtils.WriteHTTPError(w, err)
 		return
 	}
 
-	for _, img := range oldImages {
-		err := h.imageService.ImageDelete(r.Context(), img)
+	if version > 0 {
+		response.SetETag(w, version+1)
+	}
+
+	for _, img := range removed {
+		// file ber-hash hanya dihapus kalau tidak dipakai product lain
+		unused, err := h.productService.ReleaseImage(r.Context(), img)
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+		if !unused {
+			continue
+		}
+
+		err = h.imageService.ImageDelete(r.Context(), img)
 		if err != nil {
 			errorUtils.WriteHTTPError(w, err)
 			return
 		}
 	}
 
-	response.JSON(w, http.StatusOK, "success", product.ID)
+	response.JSON(w, http.StatusOK, "success", id)
 }
 
 // DELETE PRODUCT
@@ -346,7 +664,7 @@ func (h *productHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.productService.DeleteProduct(r.Context(), id)
+	err = h.productService.DeleteProduct(r.Context(), id, actorFromRequest(r))
 	if err != nil {
 		errorUtils.WriteHTTPError(w, err)
 		return
@@ -355,24 +673,244 @@ func (h *productHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	response.JSON(w, http.StatusOK, "success", nil)
 }
 
+// actorFromRequest: pelaku perubahan dari principal yang sudah terautentikasi
+func actorFromRequest(r *http.Request) productModel.Actor {
+	principal := userModel.PrincipalFromContext(r.Context())
+	if principal == nil {
+		return productModel.Actor{}
+	}
+
+	actor := productModel.Actor{Name: principal.Username}
+	if principal.Source == userModel.SourceJWT && principal.UserID > 0 {
+		userID := principal.UserID
+		actor.UserID = &userID
+	}
+
+	return actor
+}
+
+// CHANGE PRODUCT STATUS (activate / deactivate / archive / restore)
+func (h *productHandler) ChangeStatus(transition productModel.Transition) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+			return
+		}
+
+		change, err := h.productService.ChangeProductStatus(r.Context(), id, transition, actorFromRequest(r))
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+
+		response.JSON(w, http.StatusOK, "success", dto.MapStatusChange(*change))
+	}
+}
+
+// GET PRODUCT STATUS HISTORY
+func (h *productHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	history, err := h.productService.GetStatusHistory(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	res := []dto.StatusChangeResponse{}
+	for _, c := range history {
+		res = append(res, dto.MapStatusChange(c))
+	}
+
+	response.Paginated(w, http.StatusOK, "success", res, response.FullPage(len(res)))
+}
+
+// ADD VARIANT
+func (h *productHandler) AddVariant(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	var req dto.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variant := dto.MapVariant(req)
+	id, err := h.productService.AddVariant(r.Context(), productID, &variant)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, "success", id)
+}
+
+// GET VARIANT BY ID
+func (h *productHandler) GetVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := variantParams(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	variant, err := h.productService.GetVariantByID(r.Context(), productID, variantID)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", variant)
+}
+
+// UPDATE VARIANT
+func (h *productHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := variantParams(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	var req dto.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	variant := dto.MapVariant(req)
+	variant.ID = variantID
+	variant.ProductID = productID
+
+	if err := h.productService.UpdateVariant(r.Context(), &variant); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", variant.ID)
+}
+
+// DELETE VARIANT
+func (h *productHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, err := variantParams(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.productService.DeleteVariant(r.Context(), productID, variantID); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", nil)
+}
+
+// variantParams membaca {id} dan {variantId} dari URL
+func variantParams(r *http.Request) (int64, int64, error) {
+	productID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "variantId"), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return productID, variantID, nil
+}
+
+// SCAN BARCODE
+func (h *productHandler) ScanBarcode(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "barcode")
+	if code == "" {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	item, err := h.productService.ScanBarcode(r.Context(), code)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapScanResponse(item))
+}
+
 // GET ALL CATEGORY
 func (h *productHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
 	categories, err := h.productService.ListCategories(r.Context())
-	var res []dto.ListCategories
-	for _, c := range categories {
-		var category dto.ListCategories
-		category.ID = c.ID
-		category.Name = c.Name
-		category.ParentID = c.ParentID
-		res = append(res, category)
-	}
 	if err != nil {
 		logger.Error("failed to get list category", err.Error())
 		errorUtils.WriteHTTPError(w, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, "success", res)
+	response.Paginated(w, http.StatusOK, "success", dto.MapCategories(categories), response.FullPage(len(categories)))
+}
+
+// GET CATEGORY TREE
+func (h *productHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.productService.GetCategoryTree(r.Context())
+	if err != nil {
+		logger.Error("failed to get category tree", err.Error())
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapCategoryTree(tree))
+}
+
+// GET CATEGORY CHILDREN
+func (h *productHandler) GetCategoryChildren(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "categoryId"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	children, err := h.productService.GetCategoryChildren(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapCategories(children))
+}
+
+// GET CATEGORY ANCESTORS (breadcrumb dari root)
+func (h *productHandler) GetCategoryAncestors(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "categoryId"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	ancestors, err := h.productService.GetCategoryAncestors(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapCategories(ancestors))
 }
 
 // CREATE CATEGORY
@@ -418,6 +956,7 @@ func (s productHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	response.SetETag(w, category.Version)
 	response.JSON(w, http.StatusOK, "success", category)
 }
 
@@ -425,7 +964,14 @@ func (s productHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
 func (s productHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	var req dto.UpdateCategory
 
-	err := json.NewDecoder(r.Body).Decode(&req)
+	// optimistic locking: versi dari ETag GetCategory
+	version, err := response.IfMatchVersion(r)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
 		logger.Error("failed to decode json body", err.Error())
 		errorUtils.WriteHTTPError(w, err)
@@ -435,6 +981,7 @@ func (s productHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		ID:       req.ID,
 		Name:     req.Name,
 		ParentID: req.ParentID,
+		Version:  version,
 	}
 	err = s.productService.UpdateCategory(r.Context(), &category)
 	if err != nil {
@@ -443,6 +990,9 @@ func (s productHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if version > 0 {
+		response.SetETag(w, version+1)
+	}
 	response.JSON(w, http.StatusNoContent, "success")
 
 }
@@ -457,9 +1007,30 @@ func (s productHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 		errorUtils.WriteHTTPError(w, err)
 		return
 	}
-	err = s.productService.DeleteCategory(r.Context(), int64(categoryId))
+
+	// ?strategy=forbid_if_used|reparent|move_products_to&move_products_to={id}
+	// move_products_to={id} saja juga berarti strategi move_products_to
+	q := r.URL.Query()
+	opt := productModel.CategoryDelete{Strategy: q.Get("strategy")}
+	if target := q.Get("move_products_to"); target != "" {
+		targetID, err := strconv.ParseInt(target, 10, 64)
+		if err != nil {
+			errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+			return
+		}
+		opt.MoveProductsTo = &targetID
+		if opt.Strategy == "" {
+			opt.Strategy = productModel.CategoryDeleteMove
+		}
+	}
+
+	blockers, err := s.productService.DeleteCategory(r.Context(), int64(categoryId), opt)
 	if err != nil {
 		logger.Error(err.Error())
+		if err == errorUtils.ErrConflict && blockers != nil {
+			response.JSON(w, http.StatusConflict, "error", dto.MapCategoryBlockers(blockers))
+			return
+		}
 		errorUtils.WriteHTTPError(w, err)
 		return
 	}
@@ -1,44 +1,86 @@
 package handler
 
 import (
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	customMiddleware "github.com/dona-dllollin/belajar-clean-arch/internal/middleware"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/imagecase"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
 	"github.com/go-chi/chi/v5"
-	"github.com/jackc/pgx/v5"
 )
 
 func Routes(
 	r chi.Router,
-	db *pgx.Conn,
+	db *txmanager.Manager,
 	validator validation.Validation,
+	authz customMiddleware.Authorizer,
+	store storage.Driver,
 	imagePath string,
-	stotagePath string,
 ) {
 
 	productRepository := productrepo.NewProductRepository(db)
 	categoryRepository := productrepo.NewCategoryRepsitory(db)
 	ProductUseCase := productcase.NewProductService(productRepository, categoryRepository)
 	imageService := imagecase.ImageUploadService{
-		PublicPath:  imagePath,
-		StoragePath: stotagePath,
+		Storage:    store,
+		PublicPath: imagePath,
 	}
-	productHandler := NewProductHandler(ProductUseCase, validator, &imageService)
+	importService := importcase.NewProductImporter(productRepository, categoryRepository, db, validator)
+	productHandler := NewProductHandler(ProductUseCase, validator, &imageService, importService)
+
+	read := customMiddleware.RequirePermission(authz, userModel.PermCatalogRead)
+	write := customMiddleware.RequirePermission(authz, userModel.PermProductWrite)
+	archive := customMiddleware.RequirePermission(authz, userModel.PermProductArchive)
+	image := customMiddleware.RequirePermission(authz, userModel.PermProductImage)
+	category := customMiddleware.RequirePermission(authz, userModel.PermCategoryWrite)
 
 	// product
-	r.Get("/", productHandler.ListProducts)
-	r.Post("/", productHandler.StoreProduct)
-	r.Get("/{id}", productHandler.GetProductById)
-	r.Put("/{id}", productHandler.UpdateProduct)
-	r.Delete("/{id}", productHandler.DeleteProduct)
-	r.Put("/{id}/image", productHandler.UpdateImageProduct)
+	r.With(read).Get("/", productHandler.ListProducts)
+	r.With(write).Post("/", productHandler.StoreProduct)
+	r.With(write).Post("/import", productHandler.ImportProducts)
+	r.With(read).Get("/export", productHandler.ExportProducts)
+	r.With(read).Get("/search", productHandler.SearchProducts)
+	r.With(read).Get("/{id}", productHandler.GetProductById)
+	r.With(write).Put("/{id}", productHandler.UpdateProduct)
+	r.With(archive).Delete("/{id}", productHandler.DeleteProduct)
+	r.With(read).Get("/{id}/status-history", productHandler.GetStatusHistory)
+
+	// lifecycle
+	r.With(write).Post("/{id}/activate", productHandler.ChangeStatus(productModel.TransitionActivate))
+	r.With(write).Post("/{id}/deactivate", productHandler.ChangeStatus(productModel.TransitionDeactivate))
+	r.With(archive).Post("/{id}/archive", productHandler.ChangeStatus(productModel.TransitionArchive))
+	r.With(archive).Post("/{id}/restore", productHandler.ChangeStatus(productModel.TransitionRestore))
+	r.With(image).Put("/{id}/image", productHandler.UpdateImageProduct)
+
+	// variants
+	r.With(write).Post("/{id}/variants", productHandler.AddVariant)
+	r.With(read).Get("/{id}/variants/{variantId}", productHandler.GetVariant)
+	r.With(write).Put("/{id}/variants/{variantId}", productHandler.UpdateVariant)
+	r.With(write).Delete("/{id}/variants/{variantId}", productHandler.DeleteVariant)
 
 	//categories
-	r.Get("/categories", productHandler.ListCategories)
-	r.Post("/category", productHandler.CreateCategory)
-	r.Get("/category/{categoryId}", productHandler.GetCategory)
-	r.Put("/category", productHandler.UpdateCategory)
-	r.Delete("/category/{categoryId}", productHandler.DeleteCategory)
+	r.With(read).Get("/categories", productHandler.ListCategories)
+	r.With(read).Get("/categories/tree", productHandler.GetCategoryTree)
+	r.With(category).Post("/category", productHandler.CreateCategory)
+	r.With(read).Get("/category/{categoryId}", productHandler.GetCategory)
+	r.With(read).Get("/category/{categoryId}/children", productHandler.GetCategoryChildren)
+	r.With(read).Get("/category/{categoryId}/ancestors", productHandler.GetCategoryAncestors)
+	r.With(category).Put("/category", productHandler.UpdateCategory)
+	r.With(category).Delete("/category/{categoryId}", productHandler.DeleteCategory)
+
+}
+
+func ScanRoutes(r chi.Router, db *txmanager.Manager, authz customMiddleware.Authorizer) {
+	productRepository := productrepo.NewProductRepository(db)
+	categoryRepository := productrepo.NewCategoryRepsitory(db)
+	ProductUseCase := productcase.NewProductService(productRepository, categoryRepository)
+	productHandler := NewProductHandler(ProductUseCase, nil, nil, nil)
 
+	r.With(customMiddleware.RequirePermission(authz, userModel.PermCatalogRead)).
+		Get("/{barcode}", productHandler.ScanBarcode)
 }
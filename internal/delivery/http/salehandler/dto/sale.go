@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+)
+
+type CheckoutRequest struct {
+	OutletID int64             `json:"outlet_id" validate:"required"`
+	Paid     int64             `json:"paid" validate:"gte=0"`
+	Items    []SaleItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type SaleItemRequest struct {
+	VariantUnitID int64 `json:"variant_unit_id" validate:"required"`
+	Quantity      int   `json:"quantity" validate:"gt=0"`
+}
+
+type SaleItemResponse struct {
+	ID             int64  `json:"id"`
+	VariantID      int64  `json:"variant_id"`
+	VariantUnitID  int64  `json:"variant_unit_id"`
+	ProductName    string `json:"product_name"`
+	SKU            string `json:"sku"`
+	UnitName       string `json:"unit_name"`
+	Quantity       int    `json:"quantity"`
+	ConversionRate int    `json:"conversion_rate"`
+	BaseQuantity   int    `json:"base_quantity"`
+	UnitPrice      int64  `json:"unit_price"`
+	LineTotal      int64  `json:"line_total"`
+}
+
+type SaleResponse struct {
+	ID        int64              `json:"id"`
+	OutletID  int64              `json:"outlet_id"`
+	ReceiptNo string             `json:"receipt_no"`
+	Items     []SaleItemResponse `json:"items"`
+	Total     int64              `json:"total"`
+	Paid      int64              `json:"paid"`
+	Change    int64              `json:"change"`
+	CreatedAt time.Time          `json:"created_at"`
+
+	OversoldVariantIDs []int64 `json:"oversold_variant_ids,omitempty"`
+}
+
+func MapSale(req CheckoutRequest) saleModel.Sale {
+	sale := saleModel.Sale{
+		OutletID: req.OutletID,
+		Paid:     req.Paid,
+	}
+	for _, item := range req.Items {
+		sale.Items = append(sale.Items, saleModel.SaleItem{
+			VariantUnitID: item.VariantUnitID,
+			Quantity:      item.Quantity,
+		})
+	}
+
+	return sale
+}
+
+func MapSaleResponse(s *saleModel.Sale) SaleResponse {
+	res := SaleResponse{
+		ID:        s.ID,
+		OutletID:  s.OutletID,
+		ReceiptNo: s.ReceiptNo,
+		Items:     []SaleItemResponse{},
+		Total:     s.Total,
+		Paid:      s.Paid,
+		Change:    s.Change,
+		CreatedAt: s.CreatedAt,
+
+		OversoldVariantIDs: s.OversoldVariantIDs,
+	}
+	for _, item := range s.Items {
+		res.Items = append(res.Items, SaleItemResponse{
+			ID:             item.ID,
+			VariantID:      item.VariantID,
+			VariantUnitID:  item.VariantUnitID,
+			ProductName:    item.ProductName,
+			SKU:            item.SKU,
+			UnitName:       item.UnitName,
+			Quantity:       item.Quantity,
+			ConversionRate: item.ConversionRate,
+			BaseQuantity:   item.BaseQuantity,
+			UnitPrice:      item.UnitPrice,
+			LineTotal:      item.LineTotal,
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/salehandler/dto"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/salecase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/dona-dllollin/belajar-clean-arch/utils/response"
+	"github.com/go-chi/chi/v5"
+)
+
+type saleHandler struct {
+	saleService salecase.SaleService
+	validator   validation.Validation
+}
+
+func NewSaleHandler(saleService salecase.SaleService, validator validation.Validation) *saleHandler {
+	return &saleHandler{
+		saleService: saleService,
+		validator:   validator,
+	}
+}
+
+// CHECKOUT
+func (h *saleHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var req dto.CheckoutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sale := dto.MapSale(req)
+	result, err := h.saleService.Checkout(r.Context(), &sale)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, "success", dto.MapSaleResponse(result))
+}
+
+// GET SALE BY ID
+func (h *saleHandler) GetSale(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	sale, err := h.saleService.GetSale(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapSaleResponse(sale))
+}
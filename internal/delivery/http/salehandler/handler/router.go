@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	customMiddleware "github.com/dona-dllollin/belajar-clean-arch/internal/middleware"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/salerepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/salecase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(
+	r chi.Router,
+	db *txmanager.Manager,
+	validator validation.Validation,
+	authz customMiddleware.Authorizer,
+) {
+
+	saleRepository := salerepo.NewSaleRepository(db)
+	SaleUseCase := salecase.NewSaleService(saleRepository, db)
+	saleHandler := NewSaleHandler(SaleUseCase, validator)
+
+	r.With(customMiddleware.RequirePermission(authz, userModel.PermSaleCreate)).Post("/", saleHandler.Checkout)
+	r.With(customMiddleware.RequirePermission(authz, userModel.PermSaleRead)).Get("/{id}", saleHandler.GetSale)
+}
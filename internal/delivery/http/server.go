@@ -5,29 +5,44 @@ import (
 	"net/http"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/config"
+	authHttp "github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/authhandler/handler"
 	productHttp "github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/producthandler/handler"
+	saleHttp "github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/salehandler/handler"
+	stockHttp "github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/stockhandler/handler"
+	webhookHttp "github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/webhookhandler/handler"
 	customMiddleware "github.com/dona-dllollin/belajar-clean-arch/internal/middleware"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/accessrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/idempotencyrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/accesscase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/jwt"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/jackc/pgx/v5"
 )
 
 type Server struct {
 	engine    *chi.Mux
-	db        *pgx.Conn
+	db        *txmanager.Manager
 	validator validation.Validation
+	tokens    *jwt.Manager
+	store     storage.Driver
 	cfg       *config.Config
 }
 
 func NewServer(
 	validator validation.Validation,
-	db *pgx.Conn,
+	db *txmanager.Manager,
+	tokens *jwt.Manager,
+	store storage.Driver,
 ) *Server {
 	return &Server{
 		engine:    chi.NewRouter(),
 		db:        db,
+		tokens:    tokens,
+		store:     store,
 		cfg:       config.LoadConfig(),
 		validator: validator,
 	}
@@ -64,9 +79,41 @@ func (s Server) Run() error {
 }
 
 func (s Server) MapRoute() {
+	// identitas dari JWT atau API key statis, permission per role dari database
+	access := accesscase.NewAccessService(accessrepo.NewAccessRepository(s.db))
+	authenticate := customMiddleware.Authenticate(
+		customMiddleware.BearerToken(s.tokens),
+		customMiddleware.APIKey(access),
+	)
+
+	// retry POST dengan Idempotency-Key yang sama tidak membuat data ganda
+	idempotent := customMiddleware.Idempotency(idempotencyrepo.NewIdempotencyRepository(s.db), s.cfg.IdempotencyTTL)
+
 	s.engine.Route("/api/v1", func(r chi.Router) {
-		r.Route("/products", func(r chi.Router) {
-			productHttp.Routes(r, s.db, s.validator, s.cfg.ImagePath, s.cfg.StoragePath)
+		r.Route("/auth", func(r chi.Router) {
+			authHttp.Routes(r, s.db, s.validator, s.tokens, s.cfg.RefreshTokenTTL, authenticate)
+		})
+
+		// semua route di bawah ini wajib membawa access token
+		r.Group(func(r chi.Router) {
+			r.Use(authenticate)
+			r.Use(idempotent)
+
+			r.Route("/products", func(r chi.Router) {
+				productHttp.Routes(r, s.db, s.validator, access, s.store, s.cfg.ImagePath)
+			})
+			r.Route("/scan", func(r chi.Router) {
+				productHttp.ScanRoutes(r, s.db, access)
+			})
+			r.Route("/sales", func(r chi.Router) {
+				saleHttp.Routes(r, s.db, s.validator, access)
+			})
+			r.Route("/variants", func(r chi.Router) {
+				stockHttp.Routes(r, s.db, s.validator, access)
+			})
+			r.Route("/webhooks", func(r chi.Router) {
+				webhookHttp.Routes(r, s.db, s.validator, access)
+			})
 		})
 	})
 }
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+)
+
+type MovementRequest struct {
+	Reason        string `json:"reason" validate:"required"`
+	Quantity      int    `json:"quantity"`
+	Counted       *int   `json:"counted,omitempty"` // wajib untuk reason opname
+	ReferenceType string `json:"reference_type,omitempty"`
+	ReferenceID   *int64 `json:"reference_id,omitempty"`
+	Note          string `json:"note,omitempty"`
+}
+
+type ReservationRequest struct {
+	Quantity int `json:"quantity" validate:"gt=0"`
+}
+
+type ReservationResponse struct {
+	VariantID int64 `json:"variant_id"`
+	Quantity  int   `json:"quantity"`
+	Oversold  bool  `json:"oversold"`
+}
+
+type MovementResponse struct {
+	ID            int64     `json:"id"`
+	VariantID     int64     `json:"variant_id"`
+	Quantity      int       `json:"quantity"`
+	BalanceAfter  int       `json:"balance_after"`
+	Reason        string    `json:"reason"`
+	ReferenceType string    `json:"reference_type,omitempty"`
+	ReferenceID   *int64    `json:"reference_id,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type StockLevelResponse struct {
+	VariantID   int64 `json:"variant_id"`
+	Stock       int   `json:"stock"`
+	LedgerStock int   `json:"ledger_stock"`
+	InSync      bool  `json:"in_sync"`
+}
+
+func MapMovementResponse(m stockModel.Movement) MovementResponse {
+	return MovementResponse{
+		ID:            m.ID,
+		VariantID:     m.VariantID,
+		Quantity:      m.Quantity,
+		BalanceAfter:  m.BalanceAfter,
+		Reason:        string(m.Reason),
+		ReferenceType: m.ReferenceType,
+		ReferenceID:   m.ReferenceID,
+		Note:          m.Note,
+		CreatedAt:     m.CreatedAt,
+	}
+}
+
+func MapStockLevelResponse(l *stockModel.Level) StockLevelResponse {
+	return StockLevelResponse{
+		VariantID:   l.VariantID,
+		Stock:       l.Stock,
+		LedgerStock: l.LedgerStock,
+		InSync:      l.InSync(),
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/stockhandler/dto"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/stockcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/dona-dllollin/belajar-clean-arch/utils/response"
+	"github.com/go-chi/chi/v5"
+)
+
+type stockHandler struct {
+	stockService stockcase.StockService
+	validator    validation.Validation
+}
+
+func NewStockHandler(stockService stockcase.StockService, validator validation.Validation) *stockHandler {
+	return &stockHandler{
+		stockService: stockService,
+		validator:    validator,
+	}
+}
+
+type historyCursor struct {
+	ID int64 `json:"id"`
+}
+
+// GET STOCK HISTORY
+func (h *stockHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+
+	filter := stockrepo.HistoryFilter{VariantID: variantID, Limit: limit}
+	if page > 0 && limit > 0 {
+		filter.Offset = (page - 1) * limit
+	}
+
+	// cursor = id movement terakhir halaman sebelumnya
+	if c := q.Get("cursor"); c != "" {
+		var cursor historyCursor
+		if err := response.DecodeCursor(c, &cursor); err != nil || cursor.ID <= 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = cursor.ID
+		filter.Offset = 0
+	}
+
+	history, err := h.stockService.GetStockHistory(r.Context(), filter)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	res := []dto.MovementResponse{}
+	for _, m := range history.Movements {
+		res = append(res, dto.MapMovementResponse(m))
+	}
+
+	meta := response.Page{Limit: history.Limit, HasMore: history.HasMore}
+	if history.HasMore {
+		last := history.Movements[len(history.Movements)-1]
+		meta.NextCursor, err = response.EncodeCursor(historyCursor{ID: last.ID})
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+	}
+
+	response.Paginated(w, http.StatusOK, "success", res, meta)
+}
+
+// GET STOCK LEVEL
+func (h *stockHandler) GetStockLevel(w http.ResponseWriter, r *http.Request) {
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	level, err := h.stockService.GetStockLevel(r.Context(), variantID)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapStockLevelResponse(level))
+}
+
+// RECORD STOCK MOVEMENT
+func (h *stockHandler) RecordMovement(w http.ResponseWriter, r *http.Request) {
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	var req dto.MovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var movement *stockModel.Movement
+	if stockModel.Reason(req.Reason) == stockModel.ReasonOpname {
+		if req.Counted == nil {
+			http.Error(w, "counted is required for opname", http.StatusBadRequest)
+			return
+		}
+		movement, err = h.stockService.StockOpname(r.Context(), variantID, *req.Counted, req.Note)
+	} else {
+		movement = &stockModel.Movement{
+			VariantID:     variantID,
+			Quantity:      req.Quantity,
+			Reason:        stockModel.Reason(req.Reason),
+			ReferenceType: req.ReferenceType,
+			ReferenceID:   req.ReferenceID,
+			Note:          req.Note,
+		}
+		err = h.stockService.RecordMovement(r.Context(), movement)
+	}
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, "success", dto.MapMovementResponse(*movement))
+}
+
+// RESERVE STOCK
+func (h *stockHandler) Reserve(w http.ResponseWriter, r *http.Request) {
+	variantID, req, ok := h.reservationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	oversold, err := h.stockService.Reserve(r.Context(), variantID, req.Quantity)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.ReservationResponse{
+		VariantID: variantID,
+		Quantity:  req.Quantity,
+		Oversold:  oversold,
+	})
+}
+
+// RELEASE RESERVATION
+func (h *stockHandler) Release(w http.ResponseWriter, r *http.Request) {
+	variantID, req, ok := h.reservationRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.stockService.Release(r.Context(), variantID, req.Quantity); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", nil)
+}
+
+func (h *stockHandler) reservationRequest(w http.ResponseWriter, r *http.Request) (int64, dto.ReservationRequest, bool) {
+	var req dto.ReservationRequest
+
+	variantID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return 0, req, false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return 0, req, false
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, req, false
+	}
+
+	return variantID, req, true
+}
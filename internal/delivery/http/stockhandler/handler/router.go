@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	customMiddleware "github.com/dona-dllollin/belajar-clean-arch/internal/middleware"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/stockcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(
+	r chi.Router,
+	db *txmanager.Manager,
+	validator validation.Validation,
+	authz customMiddleware.Authorizer,
+) {
+
+	stockRepository := stockrepo.NewStockRepository(db)
+	StockUseCase := stockcase.NewStockService(stockRepository)
+	stockHandler := NewStockHandler(StockUseCase, validator)
+
+	read := customMiddleware.RequirePermission(authz, userModel.PermStockRead)
+	write := customMiddleware.RequirePermission(authz, userModel.PermStockWrite)
+	// reservasi dipakai kasir saat menyusun keranjang
+	reserve := customMiddleware.RequirePermission(authz, userModel.PermSaleCreate)
+
+	r.With(read).Get("/{id}/stock", stockHandler.GetStockLevel)
+	r.With(read).Get("/{id}/stock-history", stockHandler.GetStockHistory)
+	r.With(write).Post("/{id}/stock-movements", stockHandler.RecordMovement)
+	r.With(reserve).Post("/{id}/reserve", stockHandler.Reserve)
+	r.With(reserve).Post("/{id}/release", stockHandler.Release)
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+)
+
+type SubscriptionRequest struct {
+	URL      string   `json:"url" validate:"required,url"`
+	Events   []string `json:"events" validate:"required,min=1"`
+	IsActive *bool    `json:"is_active,omitempty"` // default true
+}
+
+type SubscriptionResponse struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // hanya saat dibuat
+	Events    []string  `json:"events"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type DeadLetterResponse struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ReplayedAt     *time.Time      `json:"replayed_at,omitempty"`
+}
+
+func MapSubscription(req SubscriptionRequest) webhookModel.Subscription {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	return webhookModel.Subscription{
+		URL:      req.URL,
+		Events:   req.Events,
+		IsActive: isActive,
+	}
+}
+
+func MapSubscriptionResponse(s webhookModel.Subscription) SubscriptionResponse {
+	return SubscriptionResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    s.Events,
+		IsActive:  s.IsActive,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func MapDeadLetterResponse(d webhookModel.DeadLetter) DeadLetterResponse {
+	return DeadLetterResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Payload:        d.Payload,
+		Attempts:       d.Attempts,
+		LastError:      d.LastError,
+		ResponseStatus: d.ResponseStatus,
+		CreatedAt:      d.CreatedAt,
+		ReplayedAt:     d.ReplayedAt,
+	}
+}
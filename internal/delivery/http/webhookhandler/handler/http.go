@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/delivery/http/webhookhandler/dto"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/webhookcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/dona-dllollin/belajar-clean-arch/utils/response"
+	"github.com/go-chi/chi/v5"
+)
+
+type webhookHandler struct {
+	webhookService webhookcase.WebhookService
+	validator      validation.Validation
+}
+
+func NewWebhookHandler(webhookService webhookcase.WebhookService, validator validation.Validation) *webhookHandler {
+	return &webhookHandler{
+		webhookService: webhookService,
+		validator:      validator,
+	}
+}
+
+// CREATE SUBSCRIPTION
+func (h *webhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	var req dto.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := dto.MapSubscription(req)
+	if err := h.webhookService.CreateSubscription(r.Context(), &sub); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	// secret hanya dikembalikan sekali, dipakai penerima untuk verifikasi signature
+	res := dto.MapSubscriptionResponse(sub)
+	res.Secret = sub.Secret
+
+	// no-store: body tidak disimpan cache maupun middleware idempotency
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusCreated, "success", res)
+}
+
+// LIST SUBSCRIPTION
+func (h *webhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.webhookService.ListSubscriptions(r.Context())
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	res := []dto.SubscriptionResponse{}
+	for _, s := range subs {
+		res = append(res, dto.MapSubscriptionResponse(s))
+	}
+
+	response.Paginated(w, http.StatusOK, "success", res, response.FullPage(len(res)))
+}
+
+// GET SUBSCRIPTION
+func (h *webhookHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	sub, err := h.webhookService.GetSubscription(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapSubscriptionResponse(*sub))
+}
+
+// UPDATE SUBSCRIPTION
+func (h *webhookHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	var req dto.SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("failed to decode json body", err.Error())
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.validator.ValidateStruct(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sub := dto.MapSubscription(req)
+	sub.ID = id
+	if err := h.webhookService.UpdateSubscription(r.Context(), &sub); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", id)
+}
+
+// DELETE SUBSCRIPTION
+func (h *webhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(r.Context(), id); err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", id)
+}
+
+// idCursor posisi halaman list yang urut id terbaru dulu
+type idCursor struct {
+	ID int64 `json:"id"`
+}
+
+// LIST DEAD LETTER
+func (h *webhookHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var filter webhookrepo.DeadLetterFilter
+
+	q := r.URL.Query()
+
+	if subStr := q.Get("subscription_id"); subStr != "" {
+		subID, err := strconv.ParseInt(subStr, 10, 64)
+		if err != nil {
+			errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+			return
+		}
+		filter.SubscriptionID = &subID
+	}
+
+	filter.IncludeReplayed = q.Get("include_replayed") == "true"
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	page, _ := strconv.Atoi(q.Get("page"))
+	filter.Limit = limit
+	if page > 0 && limit > 0 {
+		filter.Offset = (page - 1) * limit
+	}
+
+	if c := q.Get("cursor"); c != "" {
+		var cursor idCursor
+		if err := response.DecodeCursor(c, &cursor); err != nil || cursor.ID <= 0 {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = cursor.ID
+		filter.Offset = 0
+	}
+
+	result, err := h.webhookService.ListDeadLetters(r.Context(), filter)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	res := []dto.DeadLetterResponse{}
+	for _, d := range result.DeadLetters {
+		res = append(res, dto.MapDeadLetterResponse(d))
+	}
+
+	meta := response.Page{Limit: result.Limit, HasMore: result.HasMore}
+	if result.HasMore {
+		last := result.DeadLetters[len(result.DeadLetters)-1]
+		meta.NextCursor, err = response.EncodeCursor(idCursor{ID: last.ID})
+		if err != nil {
+			errorUtils.WriteHTTPError(w, err)
+			return
+		}
+	}
+
+	response.Paginated(w, http.StatusOK, "success", res, meta)
+}
+
+// GET DEAD LETTER
+func (h *webhookHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	deadLetter, err := h.webhookService.GetDeadLetter(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", dto.MapDeadLetterResponse(*deadLetter))
+}
+
+// REPLAY DEAD LETTER (diantrekan, hasilnya terlihat di dead letter)
+func (h *webhookHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+		return
+	}
+
+	deadLetter, err := h.webhookService.ReplayDeadLetter(r.Context(), id)
+	if err != nil {
+		errorUtils.WriteHTTPError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, "success", dto.MapDeadLetterResponse(*deadLetter))
+}
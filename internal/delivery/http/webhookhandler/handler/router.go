@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	customMiddleware "github.com/dona-dllollin/belajar-clean-arch/internal/middleware"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/webhookcase"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(
+	r chi.Router,
+	db *txmanager.Manager,
+	validator validation.Validation,
+	authz customMiddleware.Authorizer,
+) {
+
+	webhookRepository := webhookrepo.NewWebhookRepository(db)
+	WebhookUseCase := webhookcase.NewWebhookService(webhookRepository)
+	webhookHandler := NewWebhookHandler(WebhookUseCase, validator)
+
+	manage := customMiddleware.RequirePermission(authz, userModel.PermWebhookManage)
+
+	// subscriptions
+	r.With(manage).Get("/", webhookHandler.ListSubscriptions)
+	r.With(manage).Post("/", webhookHandler.CreateSubscription)
+	r.With(manage).Get("/{id}", webhookHandler.GetSubscription)
+	r.With(manage).Put("/{id}", webhookHandler.UpdateSubscription)
+	r.With(manage).Delete("/{id}", webhookHandler.DeleteSubscription)
+
+	// dead letters
+	r.With(manage).Get("/dead-letters", webhookHandler.ListDeadLetters)
+	r.With(manage).Get("/dead-letters/{id}", webhookHandler.GetDeadLetter)
+	r.With(manage).Post("/dead-letters/{id}/replay", webhookHandler.ReplayDeadLetter)
+}
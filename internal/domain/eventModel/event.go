@@ -0,0 +1,109 @@
+package eventModel
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event Type
+const (
+	ProductCreated  = "product.created"
+	ProductUpdated  = "product.updated"
+	ProductArchived = "product.archived"
+
+	CategoryCreated = "category.created"
+	CategoryUpdated = "category.updated"
+	CategoryDeleted = "category.deleted"
+
+	StockChanged = "stock.changed"
+)
+
+// Types adalah semua event yang bisa dipilih webhook subscription
+var Types = []string{
+	ProductCreated, ProductUpdated, ProductArchived,
+	CategoryCreated, CategoryUpdated, CategoryDeleted,
+	StockChanged,
+}
+
+// Aggregate Type
+const (
+	AggregateProduct  = "product"
+	AggregateCategory = "category"
+	AggregateVariant  = "variant"
+)
+
+// Event (satu baris outbox). ID dipakai konsumen untuk dedup karena
+// pengiriman at-least-once.
+type Event struct {
+	ID            int64
+	Type          string
+	AggregateType string
+	AggregateID   int64
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int
+}
+
+// New membuat event dengan payload di-encode ke JSON
+func New(eventType, aggregateType string, aggregateID int64, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+	}, nil
+}
+
+// Product Payload (product.created, product.updated, product.archived)
+type ProductPayload struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Status      string  `json:"status"`
+	StockPolicy string  `json:"stock_policy,omitempty"`
+	CategoryIDs []int64 `json:"category_ids,omitempty"`
+}
+
+// Category Payload
+type CategoryPayload struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name,omitempty"`
+	ParentID *int64 `json:"parent_id,omitempty"`
+}
+
+// Stock Payload (stock.changed, satu per baris ledger)
+type StockPayload struct {
+	VariantID     int64  `json:"variant_id"`
+	MovementID    int64  `json:"movement_id"`
+	Quantity      int    `json:"quantity"`
+	BalanceAfter  int    `json:"balance_after"`
+	Reason        string `json:"reason"`
+	ReferenceType string `json:"reference_type,omitempty"`
+	ReferenceID   *int64 `json:"reference_id,omitempty"`
+}
+
+// Message adalah bentuk event yang dikirim ke sink (HTTP body / baris file)
+type Message struct {
+	ID            int64           `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func (e Event) Message() Message {
+	return Message{
+		ID:            e.ID,
+		Type:          e.Type,
+		AggregateType: e.AggregateType,
+		AggregateID:   e.AggregateID,
+		OccurredAt:    e.OccurredAt,
+		Payload:       e.Payload,
+	}
+}
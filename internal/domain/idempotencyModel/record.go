@@ -0,0 +1,21 @@
+package idempotencyModel
+
+import "time"
+
+// Record (respons yang disimpan per Idempotency-Key)
+type Record struct {
+	Scope       string // pemilik key, misalnya "jwt:5" atau "api_key:pos-01"
+	Key         string
+	RequestHash string // sha256 method, path dan body request pertama
+	StatusCode  int    // 0 selama request pertama masih diproses
+	ContentType string
+	Body        []byte
+	Withheld    bool // body berisi secret dan sengaja tidak disimpan
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed berarti respons sudah tersimpan dan bisa diputar ulang
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
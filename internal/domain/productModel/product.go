@@ -7,8 +7,10 @@ type Product struct {
 	Description string
 	CategoryId  []*int64
 	Status      string // "active", "inactive", "archived"
+	StockPolicy string // "block", "allow_negative", "warn"
 	Images      []ProductImage
 	Variants    []Variant
+	Version     int64 // optimistic locking, 0 = tanpa pengecekan
 }
 
 //Product
@@ -18,8 +20,10 @@ type ProductDetail struct {
 	Description string
 	Categories  []Category
 	Status      string // "active", "inactive", "archived"
+	StockPolicy string // "block", "allow_negative", "warn"
 	Images      []ProductImage
 	Variants    []Variant
+	Version     int64 // versi saat dibaca, dikirim sebagai ETag
 }
 
 //Product Category
@@ -27,14 +31,69 @@ type Category struct {
 	ID       int64
 	Name     string
 	ParentID *int64
+	Version  int64 // optimistic locking, 0 = tanpa pengecekan
+}
+
+// Strategi hapus kategori
+const (
+	CategoryDeleteForbid   = "forbid_if_used"   // tolak jika masih punya sub-kategori / product
+	CategoryDeleteReparent = "reparent"         // sub-kategori & product pindah ke parent
+	CategoryDeleteMove     = "move_products_to" // product pindah ke kategori lain, sub-kategori ke parent
+)
+
+// Category Delete (opsi hapus kategori)
+type CategoryDelete struct {
+	Strategy       string
+	MoveProductsTo *int64 // wajib untuk CategoryDeleteMove
+}
+
+// Category Blockers (alasan kategori tidak bisa dihapus)
+type CategoryBlockers struct {
+	Children     []int64
+	Products     []int64 // maksimal CategoryBlockerLimit id
+	ProductCount int
+}
+
+const CategoryBlockerLimit = 50
+
+// Category Tree (kategori beserta seluruh turunannya)
+type CategoryNode struct {
+	ID       int64
+	Name     string
+	ParentID *int64
+	Children []*CategoryNode
 }
 
 //Product Image
 type ProductImage struct {
-	ID        int64
-	ProductID int64
-	URL       string
-	SortOrder int
+	ID         int64
+	ProductID  int64
+	URL        string // URL rendition original
+	Hash       string // SHA-256 isi file original, kosong untuk gambar lama
+	SortOrder  int
+	Renditions []ImageRendition
+}
+
+// ImageChanges perubahan gambar dari satu request; gambar yang tidak
+// disebut dibiarkan apa adanya
+type ImageChanges struct {
+	Upsert []ProductImage // ID 0 berarti gambar baru, selain itu replace
+	Delete []int64
+}
+
+const (
+	RenditionOriginal  = "original"
+	RenditionMedium    = "medium"
+	RenditionThumbnail = "thumbnail"
+)
+
+// Image Rendition (ukuran gambar yang dihasilkan saat upload)
+type ImageRendition struct {
+	Name     string // original, medium, thumbnail
+	URL      string
+	Width    int
+	Height   int
+	ByteSize int64
 }
 
 // Variant (kombinasi warna, ukuran, dll)
@@ -51,6 +110,7 @@ type Variant struct {
 
 // Varian Option (warna = merah, ukuran = M)
 type VariantOption struct {
+	ID    int64
 	Name  string
 	Value string
 }
@@ -65,3 +125,45 @@ type VariantUnit struct {
 	ConversionRate int   // pack = 5 pcs -> 5
 	Price          int64 //harga per unit
 }
+
+// Export Row (satu baris export katalog = satu unit)
+type ExportRow struct {
+	ProductID      int64
+	ProductName    string
+	Description    string
+	Status         string
+	StockPolicy    string
+	Categories     []string // path kategori, contoh "Drinks > Soda"
+	VariantID      int64
+	SKU            string
+	BaseUnit       string
+	Stock          int
+	CostPrice      int64
+	Options        []VariantOption
+	UnitID         int64
+	UnitName       string
+	ConversionRate int
+	Price          int64
+	Barcode        *string
+}
+
+// Search Hit (hasil pencarian product beserta skor relevansi)
+type SearchHit struct {
+	Product   Product // hanya ID, Name, Description, Status
+	Score     float64
+	Highlight string // nama product dengan kata yang cocok ditandai <mark>
+	Snippet   string // potongan deskripsi / sku / barcode yang cocok
+}
+
+// Scan Item (hasil scan barcode / sku di kasir)
+type ScanItem struct {
+	ProductID      int64
+	ProductName    string
+	ProductStatus  string
+	Variant        Variant // hanya berisi Options, tanpa Units
+	UnitID         int64
+	UnitName       string
+	ConversionRate int
+	Price          int64
+	MatchedBy      string // "barcode" atau "sku"
+}
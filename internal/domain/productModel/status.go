@@ -0,0 +1,70 @@
+package productModel
+
+import "time"
+
+// Status product, harus sama dengan CHECK constraint products.status
+const (
+	StatusActive   = "active"   // tampil & bisa dijual
+	StatusInactive = "inactive" // disembunyikan sementara
+	StatusArchived = "archived" // tidak dipakai lagi, tidak ikut list default
+)
+
+// Transition (perubahan status product lewat endpoint lifecycle)
+type Transition string
+
+const (
+	TransitionActivate   Transition = "activate"
+	TransitionDeactivate Transition = "deactivate"
+	TransitionArchive    Transition = "archive"
+	TransitionRestore    Transition = "restore"
+)
+
+// transitions: status asal yang diizinkan -> status tujuan.
+// Restore mengembalikan ke inactive supaya product dicek dulu sebelum aktif.
+var transitions = map[Transition]struct {
+	from []string
+	to   string
+}{
+	TransitionActivate:   {from: []string{StatusInactive}, to: StatusActive},
+	TransitionDeactivate: {from: []string{StatusActive}, to: StatusInactive},
+	TransitionArchive:    {from: []string{StatusActive, StatusInactive}, to: StatusArchived},
+	TransitionRestore:    {from: []string{StatusArchived}, to: StatusInactive},
+}
+
+func (t Transition) Valid() bool {
+	_, ok := transitions[t]
+	return ok
+}
+
+// Apply mengembalikan status tujuan. ok false berarti transisi tidak
+// diizinkan dari status from.
+func (t Transition) Apply(from string) (to string, ok bool) {
+	rule, exists := transitions[t]
+	if !exists {
+		return "", false
+	}
+
+	for _, s := range rule.from {
+		if s == from {
+			return rule.to, true
+		}
+	}
+	return "", false
+}
+
+// Actor (siapa yang melakukan perubahan). UserID nil untuk api key.
+type Actor struct {
+	UserID *int64
+	Name   string
+}
+
+// Status Change (satu baris riwayat status product)
+type StatusChange struct {
+	ID         int64
+	ProductID  int64
+	Transition Transition
+	FromStatus string
+	ToStatus   string
+	ChangedBy  Actor
+	ChangedAt  time.Time
+}
@@ -0,0 +1,37 @@
+package productModel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransition_Apply(t *testing.T) {
+	cases := []struct {
+		transition Transition
+		from       string
+		to         string
+		ok         bool
+	}{
+		{TransitionActivate, StatusInactive, StatusActive, true},
+		{TransitionActivate, StatusActive, "", false},
+		{TransitionActivate, StatusArchived, "", false},
+		{TransitionDeactivate, StatusActive, StatusInactive, true},
+		{TransitionDeactivate, StatusInactive, "", false},
+		{TransitionArchive, StatusActive, StatusArchived, true},
+		{TransitionArchive, StatusInactive, StatusArchived, true},
+		{TransitionArchive, StatusArchived, "", false},
+		{TransitionRestore, StatusArchived, StatusInactive, true},
+		{TransitionRestore, StatusActive, "", false},
+		{"publish", StatusInactive, "", false},
+	}
+
+	for _, c := range cases {
+		to, ok := c.transition.Apply(c.from)
+		assert.Equal(t, c.ok, ok, "%s dari %s", c.transition, c.from)
+		assert.Equal(t, c.to, to, "%s dari %s", c.transition, c.from)
+	}
+
+	assert.True(t, TransitionRestore.Valid())
+	assert.False(t, Transition("publish").Valid())
+}
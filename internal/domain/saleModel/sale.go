@@ -0,0 +1,34 @@
+package saleModel
+
+import "time"
+
+// Sale (satu transaksi kasir)
+type Sale struct {
+	ID        int64
+	OutletID  int64
+	ReceiptNo string // nomor struk, urut tanpa celah per outlet
+	Items     []SaleItem
+	Total     int64
+	Paid      int64
+	Change    int64
+	CreatedAt time.Time
+
+	// varian yang terjual melebihi stok karena stock policy "warn"
+	OversoldVariantIDs []int64
+}
+
+// Sale Item (satu baris struk, mengacu ke variant_units)
+type SaleItem struct {
+	ID             int64
+	SaleID         int64
+	VariantID      int64
+	VariantUnitID  int64
+	ProductName    string
+	SKU            string
+	UnitName       string
+	Quantity       int
+	ConversionRate int
+	BaseQuantity   int   // Quantity * ConversionRate (satuan dasar)
+	UnitPrice      int64 // harga per unit saat transaksi
+	LineTotal      int64
+}
@@ -0,0 +1,97 @@
+package stockModel
+
+import "time"
+
+// Reason (alasan perubahan stok)
+type Reason string
+
+const (
+	ReasonSale            Reason = "sale"
+	ReasonReturn          Reason = "return"
+	ReasonAdjustment      Reason = "adjustment"
+	ReasonPurchaseReceipt Reason = "purchase_receipt"
+	ReasonTransfer        Reason = "transfer"
+	ReasonOpname          Reason = "opname"
+)
+
+func (r Reason) Valid() bool {
+	switch r {
+	case ReasonSale, ReasonReturn, ReasonAdjustment, ReasonPurchaseReceipt, ReasonTransfer, ReasonOpname:
+		return true
+	}
+	return false
+}
+
+// Movement (satu baris ledger stok, append-only)
+type Movement struct {
+	ID            int64
+	VariantID     int64
+	Quantity      int // bertanda, dalam satuan dasar
+	BalanceAfter  int // stok varian setelah movement ini
+	Reason        Reason
+	ReferenceType string // misal "sale"
+	ReferenceID   *int64
+	Note          string
+	CreatedAt     time.Time
+}
+
+// Stock Level (stok tersimpan vs hasil penjumlahan ledger)
+type Level struct {
+	VariantID   int64
+	Stock       int
+	LedgerStock int
+}
+
+func (l Level) InSync() bool {
+	return l.Stock == l.LedgerStock
+}
+
+// Policy (aturan per product saat stok tidak cukup)
+type Policy string
+
+const (
+	PolicyBlock         Policy = "block"          // tolak penjualan
+	PolicyAllowNegative Policy = "allow_negative" // izinkan stok minus
+	PolicyWarn          Policy = "warn"           // izinkan, tapi tandai oversold
+)
+
+func (p Policy) Valid() bool {
+	switch p {
+	case PolicyBlock, PolicyAllowNegative, PolicyWarn:
+		return true
+	}
+	return false
+}
+
+// Check memutuskan apakah qty boleh diambil dari stok yang tersedia.
+// oversold bernilai true jika diizinkan walaupun stok tidak cukup.
+func (p Policy) Check(available, qty int) (allowed bool, oversold bool) {
+	if available >= qty {
+		return true, false
+	}
+
+	switch p {
+	case PolicyAllowNegative:
+		return true, false
+	case PolicyWarn:
+		return true, true
+	}
+	return false, false
+}
+
+// Decrement (permintaan pengurangan stok)
+type Decrement struct {
+	VariantID     int64
+	Quantity      int // positif, dalam satuan dasar
+	Reason        Reason
+	ReferenceType string
+	ReferenceID   *int64
+	Note          string
+	Reserved      int // jumlah reservasi yang dipakai oleh pengurangan ini
+}
+
+// Decrement Result
+type DecrementResult struct {
+	Movement Movement
+	Oversold bool // true jika policy warn mengizinkan stok tidak cukup
+}
@@ -0,0 +1,30 @@
+package stockModel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	cases := []struct {
+		policy    Policy
+		available int
+		qty       int
+		allowed   bool
+		oversold  bool
+	}{
+		{PolicyBlock, 5, 5, true, false},
+		{PolicyBlock, 1, 2, false, false},
+		{PolicyAllowNegative, 0, 3, true, false},
+		{PolicyWarn, 2, 2, true, false},
+		{PolicyWarn, 1, 2, true, true},
+		{"", 0, 1, false, false},
+	}
+
+	for _, c := range cases {
+		allowed, oversold := c.policy.Check(c.available, c.qty)
+		assert.Equal(t, c.allowed, allowed, "%s %d/%d", c.policy, c.available, c.qty)
+		assert.Equal(t, c.oversold, oversold, "%s %d/%d", c.policy, c.available, c.qty)
+	}
+}
@@ -0,0 +1,34 @@
+package userModel
+
+import "time"
+
+const (
+	RoleCashier    = "cashier"
+	RoleSupervisor = "supervisor"
+	RoleAdmin      = "admin"
+)
+
+// Kode permission, harus sama dengan isi tabel permissions
+const (
+	PermCatalogRead    = "catalog.read"
+	PermProductWrite   = "product.write"
+	PermProductArchive = "product.archive"
+	PermProductImage   = "product.image"
+	PermCategoryWrite  = "category.write"
+	PermSaleCreate     = "sale.create"
+	PermSaleRead       = "sale.read"
+	PermStockRead      = "stock.read"
+	PermStockWrite     = "stock.write"
+	PermWebhookManage  = "webhook.manage"
+)
+
+// APIKey adalah identitas statis untuk integrasi (mesin kasir, script),
+// key aslinya hanya ditampilkan sekali saat dibuat.
+type APIKey struct {
+	ID        int64
+	Name      string
+	KeyHash   string
+	Role      string
+	IsActive  bool
+	CreatedAt time.Time
+}
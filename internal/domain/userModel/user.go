@@ -0,0 +1,59 @@
+package userModel
+
+import (
+	"context"
+	"time"
+)
+
+type User struct {
+	ID           int64
+	Username     string
+	PasswordHash string
+	Role         string
+	IsActive     bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// RefreshToken disimpan dalam bentuk hash, token aslinya hanya dikirim ke
+// client sekali saat login/refresh.
+type RefreshToken struct {
+	ID        int64
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// Principal adalah identitas yang sudah terautentikasi pada sebuah request
+type Principal struct {
+	UserID   int64
+	Username string
+	Role     string
+	Source   string // "jwt" atau "api_key"
+}
+
+const (
+	SourceJWT    = "jwt"
+	SourceAPIKey = "api_key"
+)
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext mengembalikan nil jika request tidak terautentikasi
+func PrincipalFromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
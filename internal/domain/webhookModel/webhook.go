@@ -0,0 +1,48 @@
+package webhookModel
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AllEvents berarti subscription menerima semua event
+const AllEvents = "*"
+
+// Subscription (endpoint webhook milik tenant)
+type Subscription struct {
+	ID        int64
+	URL       string
+	Secret    string // kunci HMAC, hanya ditampilkan saat dibuat
+	Events    []string
+	IsActive  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Dead Letter (pengiriman yang tetap gagal setelah semua retry)
+type DeadLetter struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage // body yang dikirim
+	Attempts       int
+	LastError      string
+	ResponseStatus *int
+	CreatedAt      time.Time
+	ReplayedAt     *time.Time
+}
+
+// Delivery (antrean pengiriman ke satu subscription). DeadLetterID terisi
+// jika pengiriman ini adalah replay dead letter.
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	URL            string
+	Secret         string
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	DeadLetterID   *int64
+	Attempts       int // percobaan sebelumnya
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/jwt"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+// ErrNoCredentials dikembalikan IdentitySource jika request tidak membawa
+// kredensial jenisnya, sehingga source berikutnya dicoba
+var ErrNoCredentials = errors.New("no credentials")
+
+// IdentitySource menentukan siapa pemanggil sebuah request
+type IdentitySource interface {
+	Identify(r *http.Request) (*userModel.Principal, error)
+}
+
+type IdentitySourceFunc func(r *http.Request) (*userModel.Principal, error)
+
+func (f IdentitySourceFunc) Identify(r *http.Request) (*userModel.Principal, error) {
+	return f(r)
+}
+
+// TokenVerifier memverifikasi access token (dipenuhi oleh *jwt.Manager)
+type TokenVerifier interface {
+	Verify(token string) (*jwt.Claims, error)
+}
+
+// APIKeyIdentifier mencari principal dari API key (dipenuhi oleh accesscase)
+type APIKeyIdentifier interface {
+	IdentifyAPIKey(ctx context.Context, key string) (*userModel.Principal, error)
+}
+
+// BearerToken membaca JWT dari header "Authorization: Bearer <token>"
+func BearerToken(verifier TokenVerifier) IdentitySource {
+	return IdentitySourceFunc(func(r *http.Request) (*userModel.Principal, error) {
+		scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			return nil, ErrNoCredentials
+		}
+
+		claims, err := verifier.Verify(strings.TrimSpace(token))
+		if err != nil {
+			return nil, errorUtils.ErrUnauthorized
+		}
+
+		userID, _ := claims.UserID()
+		return &userModel.Principal{
+			UserID:   userID,
+			Username: claims.Username,
+			Role:     claims.Role,
+			Source:   userModel.SourceJWT,
+		}, nil
+	})
+}
+
+// APIKey membaca key statis dari header "X-API-Key"
+func APIKey(identifier APIKeyIdentifier) IdentitySource {
+	return IdentitySourceFunc(func(r *http.Request) (*userModel.Principal, error) {
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			return nil, ErrNoCredentials
+		}
+		return identifier.IdentifyAPIKey(r.Context(), key)
+	})
+}
+
+// Authenticate mencoba setiap source secara berurutan dan menyimpan principal
+// di context, bisa dibaca lewat userModel.PrincipalFromContext. Request tanpa
+// kredensial yang valid ditolak dengan 401.
+func Authenticate(sources ...IdentitySource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, source := range sources {
+				principal, err := source.Identify(r)
+				if errors.Is(err, ErrNoCredentials) {
+					continue
+				}
+				if err != nil {
+					if !errors.Is(err, errorUtils.ErrUnauthorized) {
+						logger.Errorf("identify request fail, error: %s", err)
+					}
+					errorUtils.WriteHTTPError(w, errorUtils.ErrUnauthorized)
+					return
+				}
+
+				ctx := userModel.WithPrincipal(r.Context(), principal)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			errorUtils.WriteHTTPError(w, errorUtils.ErrUnauthorized)
+		})
+	}
+}
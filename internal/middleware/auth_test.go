@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/jwt"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticKeys adalah tabel API key statis untuk test
+type staticKeys map[string]string
+
+func (k staticKeys) IdentifyAPIKey(ctx context.Context, key string) (*userModel.Principal, error) {
+	role, ok := k[key]
+	if !ok {
+		return nil, errorUtils.ErrUnauthorized
+	}
+	return &userModel.Principal{Username: "pos", Role: role, Source: userModel.SourceAPIKey}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	logger.Initialize("test")
+
+	key, err := jwt.GenerateKey()
+	require.NoError(t, err)
+	tokens, err := jwt.New(key, "test", time.Minute)
+	require.NoError(t, err)
+
+	valid, _, err := tokens.Issue(5, "spv", userModel.RoleSupervisor)
+	require.NoError(t, err)
+
+	var got *userModel.Principal
+	handler := Authenticate(
+		BearerToken(tokens),
+		APIKey(staticKeys{"pos-key": userModel.RoleCashier}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = userModel.PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		status   int
+		wantRole string
+	}{
+		{name: "valid token", headers: map[string]string{"Authorization": "Bearer " + valid}, status: http.StatusNoContent, wantRole: userModel.RoleSupervisor},
+		{name: "valid api key", headers: map[string]string{"X-API-Key": "pos-key"}, status: http.StatusNoContent, wantRole: userModel.RoleCashier},
+		{name: "missing credentials", status: http.StatusUnauthorized},
+		{name: "wrong scheme", headers: map[string]string{"Authorization": "Basic " + valid}, status: http.StatusUnauthorized},
+		{name: "invalid token", headers: map[string]string{"Authorization": "Bearer abc.def.ghi"}, status: http.StatusUnauthorized},
+		{name: "unknown api key", headers: map[string]string{"X-API-Key": "nope"}, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.wantRole != "" {
+				require.NotNil(t, got)
+				assert.Equal(t, tt.wantRole, got.Role)
+			} else {
+				assert.Nil(t, got)
+			}
+		})
+	}
+}
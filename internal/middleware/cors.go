@@ -13,7 +13,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 
 		// Izinkan header kustom yang mungkin dikirim oleh klien (misalnya, untuk otentikasi).
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-API-Key")
 
 		// Izinkan kredensial (seperti cookies atau header otentikasi) disertakan dalam permintaan.
 		// Jika disetel ke true, Access-Control-Allow-Origin tidak bisa '*' (harus domain spesifik).
@@ -0,0 +1,267 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/idempotencyModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader menandai respons yang diputar ulang dari store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// body di-buffer untuk hash dan dibaca ulang handler, termasuk import CSV
+	maxIdempotentBody = 32 << 20
+
+	completeAttempts = 3
+	completeDelay    = 100 * time.Millisecond
+)
+
+// IdempotencyStore menyimpan respons per Idempotency-Key
+// (dipenuhi oleh idempotencyrepo)
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, rec *idempotencyModel.Record) (*idempotencyModel.Record, error)
+	Complete(ctx context.Context, rec *idempotencyModel.Record) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// Idempotency dipasang setelah Authenticate. POST yang membawa header
+// Idempotency-Key hanya dijalankan sekali per principal: retry dengan body
+// yang sama dalam ttl mendapat status dan body respons pertama, key yang sama
+// dengan body berbeda ditolak dengan 409. Respons 5xx tidak disimpan supaya
+// client bisa mencoba lagi. Respons dengan Cache-Control: no-store (misalnya
+// berisi secret) tidak disimpan body-nya; retry ditolak dengan 409
+// ErrIdempotencyWithheld supaya tidak terlihat seperti sukses tanpa secret.
+func Idempotency(store IdempotencyStore, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					errorUtils.WriteHTTPError(w, errorUtils.ErrPayloadTooLarge)
+					return
+				}
+				errorUtils.WriteHTTPError(w, errorUtils.ErrBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			rec := &idempotencyModel.Record{
+				Scope:       idempotencyScope(r),
+				Key:         key,
+				RequestHash: requestHash(r, body),
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+
+			existing, err := store.Reserve(r.Context(), rec)
+			if err != nil {
+				if !errors.Is(err, errorUtils.ErrIdempotencyKeyInFlight) {
+					logger.Errorf("reserve idempotency key fail, error: %s", err)
+				}
+				errorUtils.WriteHTTPError(w, err)
+				return
+			}
+
+			if existing != nil {
+				switch {
+				case existing.RequestHash != rec.RequestHash:
+					errorUtils.WriteHTTPError(w, errorUtils.ErrIdempotencyKeyReused)
+				case !existing.Completed():
+					errorUtils.WriteHTTPError(w, errorUtils.ErrIdempotencyKeyInFlight)
+				case existing.Withheld:
+					errorUtils.WriteHTTPError(w, errorUtils.ErrIdempotencyWithheld)
+				default:
+					replay(w, existing)
+				}
+				return
+			}
+
+			// tetap jalan walau client sudah memutus koneksi
+			ctx := context.WithoutCancel(r.Context())
+			stored := false
+			defer func() {
+				// 5xx atau panic: key dilepas supaya retry dijalankan lagi
+				if !stored {
+					if err := store.Release(ctx, rec.Scope, rec.Key); err != nil {
+						logger.Errorf("release idempotency key fail, error: %s", err)
+					}
+				}
+			}()
+
+			rw := &recordingWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if rw.statusCode() >= http.StatusInternalServerError {
+				return
+			}
+
+			rec.StatusCode = rw.statusCode()
+			if noStore(rw.Header()) {
+				rec.Withheld = true
+			} else {
+				rec.ContentType = rw.Header().Get("Content-Type")
+				rec.Body = rw.body.Bytes()
+			}
+
+			// perubahan sudah terjadi, jadi key tidak pernah dilepas walau
+			// gagal disimpan: lebih baik retry mendapat 409 "in flight"
+			// sampai ttl habis daripada data ganda
+			stored = true
+			complete(ctx, store, rec)
+		})
+	}
+}
+
+// complete menyimpan respons dengan beberapa kali percobaan
+func complete(ctx context.Context, store IdempotencyStore, rec *idempotencyModel.Record) {
+	for attempt := 1; ; attempt++ {
+		err := store.Complete(ctx, rec)
+		if err == nil {
+			return
+		}
+
+		logger.Errorf("store idempotent response fail (attempt %d), error: %s", attempt, err)
+		if attempt == completeAttempts {
+			return
+		}
+		time.Sleep(time.Duration(attempt) * completeDelay)
+	}
+}
+
+// noStore true jika handler menandai respons tidak boleh disimpan
+func noStore(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+	return false
+}
+
+func replay(w http.ResponseWriter, rec *idempotencyModel.Record) {
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
+
+// idempotencyScope memisahkan key per pemanggil
+func idempotencyScope(r *http.Request) string {
+	principal := userModel.PrincipalFromContext(r.Context())
+	if principal == nil {
+		return "anonymous"
+	}
+	if principal.Source == userModel.SourceAPIKey {
+		return principal.Source + ":" + principal.Username
+	}
+	return fmt.Sprintf("%s:%d", principal.Source, principal.UserID)
+}
+
+// requestHash menghitung sidik request. Body multipart di-hash per field
+// karena boundary-nya bisa berbeda setiap kali client membangun ulang request.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", r.Method, r.URL.RequestURI())
+
+	if parts, ok := multipartDigest(r.Header.Get("Content-Type"), body); ok {
+		for _, p := range parts {
+			fmt.Fprintln(h, p)
+		}
+	} else {
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func multipartDigest(contentType string, body []byte) ([]string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, false
+	}
+
+	var parts []string
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return nil, false
+		}
+		parts = append(parts, fmt.Sprintf("%q %q %x", part.FormName(), part.FileName(), content.Sum(nil)))
+	}
+
+	// urutan field tidak mengubah arti form
+	sort.Strings(parts)
+	return parts, true
+}
+
+// recordingWriter meneruskan respons ke client sambil menyalinnya
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *recordingWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/idempotencyModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore adalah IdempotencyStore di memori untuk test
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyModel.Record
+	// jumlah panggilan Complete berikutnya yang dibuat gagal
+	failComplete int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]idempotencyModel.Record{}}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, rec *idempotencyModel.Record) (*idempotencyModel.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := rec.Scope + "|" + rec.Key
+	if existing, ok := s.records[id]; ok && existing.ExpiresAt.After(time.Now()) {
+		return &existing, nil
+	}
+	s.records[id] = *rec
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, rec *idempotencyModel.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failComplete > 0 {
+		s.failComplete--
+		return errors.New("db down")
+	}
+	s.records[rec.Scope+"|"+rec.Key] = *rec
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[scope+"|"+key]; ok && !rec.Completed() {
+		delete(s.records, scope+"|"+key)
+	}
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	logger.Initialize("test")
+
+	store := newMemoryStore()
+	calls := 0
+	status := http.StatusCreated
+	handler := Idempotency(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"call":%d,"echo":%q}`, calls, body)
+	}))
+
+	send := func(method, key, body string, principal *userModel.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/products/category", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if principal != nil {
+			req = req.WithContext(userModel.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	till := &userModel.Principal{Username: "pos-01", Source: userModel.SourceAPIKey}
+	other := &userModel.Principal{UserID: 7, Source: userModel.SourceJWT}
+
+	first := send(http.MethodPost, "abc", "kaos", till)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, calls)
+
+	t.Run("retry diputar ulang", func(t *testing.T) {
+		again := send(http.MethodPost, "abc", "kaos", till)
+		assert.Equal(t, http.StatusCreated, again.Code)
+		assert.Equal(t, first.Body.String(), again.Body.String())
+		assert.Equal(t, "application/json", again.Header().Get("Content-Type"))
+		assert.Equal(t, "true", again.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("body berbeda ditolak", func(t *testing.T) {
+		rec := send(http.MethodPost, "abc", "jaket", till)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("key milik principal lain terpisah", func(t *testing.T) {
+		rec := send(http.MethodPost, "abc", "jaket", other)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("tanpa key atau bukan POST tidak disimpan", func(t *testing.T) {
+		send(http.MethodPost, "", "kaos", till)
+		send(http.MethodPut, "abc", "kaos", till)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("masih diproses", func(t *testing.T) {
+		store.Reserve(context.Background(), &idempotencyModel.Record{
+			Scope: "api_key:pos-01", Key: "pending", RequestHash: requestHash(
+				httptest.NewRequest(http.MethodPost, "/api/v1/products/category", nil), []byte("kaos")),
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		rec := send(http.MethodPost, "pending", "kaos", till)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("5xx tidak disimpan", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		send(http.MethodPost, "flaky", "kaos", till)
+		status = http.StatusCreated
+		rec := send(http.MethodPost, "flaky", "kaos", till)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, 6, calls)
+	})
+
+	t.Run("Complete dicoba ulang", func(t *testing.T) {
+		store.failComplete = completeAttempts - 1
+		send(http.MethodPost, "slow-db", "kaos", till)
+		rec := send(http.MethodPost, "slow-db", "kaos", till)
+		assert.Equal(t, "true", rec.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 7, calls)
+	})
+
+	t.Run("Complete gagal tidak melepas key", func(t *testing.T) {
+		store.failComplete = completeAttempts
+		send(http.MethodPost, "db-down", "kaos", till)
+		rec := send(http.MethodPost, "db-down", "kaos", till)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.Equal(t, 8, calls)
+	})
+
+	t.Run("key terlalu panjang", func(t *testing.T) {
+		rec := send(http.MethodPost, strings.Repeat("k", maxIdempotencyKeyLength+1), "kaos", till)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestIdempotency_NoStore(t *testing.T) {
+	logger.Initialize("test")
+
+	store := newMemoryStore()
+	calls := 0
+	handler := Idempotency(store, time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"secret":"whsec_abc"}`)
+	}))
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(`{"url":"https://shop.example"}`))
+		req.Header.Set(IdempotencyKeyHeader, "hook")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send()
+	assert.Contains(t, first.Body.String(), "whsec_abc")
+
+	// secret tidak ikut disimpan, retry ditolak tanpa membuat data ganda
+	for _, rec := range store.records {
+		assert.Empty(t, rec.Body)
+		assert.True(t, rec.Withheld)
+	}
+	again := send()
+	assert.Equal(t, http.StatusConflict, again.Code)
+	assert.NotContains(t, again.Body.String(), "whsec_abc")
+	assert.Equal(t, 1, calls)
+}
+
+func TestRequestHash_Multipart(t *testing.T) {
+	form := func(boundary string, fields [][2]string) *http.Request {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		require.NoError(t, mw.SetBoundary(boundary))
+		for _, f := range fields {
+			require.NoError(t, mw.WriteField(f[0], f[1]))
+		}
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products", &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		return req
+	}
+	hash := func(r *http.Request) string {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		return requestHash(r, body)
+	}
+
+	a := hash(form("boundary-a", [][2]string{{"name", "Kaos"}, {"status", "active"}}))
+	b := hash(form("boundary-b", [][2]string{{"status", "active"}, {"name", "Kaos"}}))
+	c := hash(form("boundary-a", [][2]string{{"name", "Jaket"}, {"status", "active"}}))
+
+	assert.Equal(t, a, b, "boundary dan urutan field tidak mengubah hash")
+	assert.NotEqual(t, a, c)
+}
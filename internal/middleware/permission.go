@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+// Authorizer memeriksa apakah sebuah role memiliki permission
+// (dipenuhi oleh accesscase)
+type Authorizer interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+// RequirePermission dipasang per route di handler.Routes, setelah
+// Authenticate. Principal tanpa permission ditolak dengan 403.
+func RequirePermission(authz Authorizer, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := userModel.PrincipalFromContext(r.Context())
+			if principal == nil {
+				errorUtils.WriteHTTPError(w, errorUtils.ErrUnauthorized)
+				return
+			}
+
+			allowed, err := authz.HasPermission(r.Context(), principal.Role, permission)
+			if err != nil {
+				logger.Errorf("check permission %s fail, error: %s", permission, err)
+				errorUtils.WriteHTTPError(w, errorUtils.ErrInternal)
+				return
+			}
+			if !allowed {
+				errorUtils.WriteHTTPError(w, errorUtils.ErrForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticPermissions map[string][]string
+
+func (p staticPermissions) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	for _, perm := range p[role] {
+		if perm == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	logger.Initialize("test")
+
+	authz := staticPermissions{
+		userModel.RoleCashier:    {userModel.PermCatalogRead},
+		userModel.RoleSupervisor: {userModel.PermCatalogRead, userModel.PermProductArchive},
+	}
+	handler := RequirePermission(authz, userModel.PermProductArchive)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	tests := []struct {
+		name      string
+		principal *userModel.Principal
+		status    int
+	}{
+		{name: "allowed", principal: &userModel.Principal{Role: userModel.RoleSupervisor}, status: http.StatusNoContent},
+		{name: "forbidden", principal: &userModel.Principal{Role: userModel.RoleCashier}, status: http.StatusForbidden},
+		{name: "anonymous", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/products/1", nil)
+			if tt.principal != nil {
+				req = req.WithContext(userModel.WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package accessrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// Access Repository
+// ===========================================
+
+type AccessRepository struct {
+	db *txmanager.Manager
+}
+
+func NewAccessRepository(db *txmanager.Manager) *AccessRepository {
+	return &AccessRepository{
+		db: db,
+	}
+}
+
+// ********** Implementation Role Permissions**********
+func (conn AccessRepository) RolePermissions(ctx context.Context) (map[string][]string, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx, `SELECT role, permission FROM role_permissions`)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	perms := make(map[string][]string)
+	for rows.Next() {
+		var role, perm string
+		if err := rows.Scan(&role, &perm); err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		perms[role] = append(perms[role], perm)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, utils.MapDbError(err)
+	}
+
+	return perms, nil
+}
+
+// ********** Implementation Find API Key**********
+func (conn AccessRepository) FindAPIKey(ctx context.Context, keyHash string) (*userModel.APIKey, error) {
+	var k userModel.APIKey
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT id, name, key_hash, role, is_active, created_at FROM api_keys
+		 WHERE key_hash = $1 AND is_active`, keyHash).
+		Scan(&k.ID, &k.Name, &k.KeyHash, &k.Role, &k.IsActive, &k.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return &k, nil
+}
+
+// ********** Implementation Create API Key**********
+func (conn AccessRepository) CreateAPIKey(ctx context.Context, k *userModel.APIKey) (int64, error) {
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO api_keys (name, key_hash, role, is_active) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		k.Name, k.KeyHash, k.Role, k.IsActive).
+		Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
+	}
+	return k.ID, nil
+}
@@ -0,0 +1,18 @@
+package accessrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+)
+
+type AccessRepoInterface interface {
+	// Semua permission per role (role -> daftar kode permission)
+	RolePermissions(ctx context.Context) (map[string][]string, error)
+
+	// Get API key aktif by hash
+	FindAPIKey(ctx context.Context, keyHash string) (*userModel.APIKey, error)
+
+	// Simpan API key baru (hash)
+	CreateAPIKey(ctx context.Context, k *userModel.APIKey) (int64, error)
+}
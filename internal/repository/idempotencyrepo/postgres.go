@@ -0,0 +1,116 @@
+package idempotencyrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/idempotencyModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// Idempotency Repository
+// ===========================================
+
+type IdempotencyRepository struct {
+	db *txmanager.Manager
+}
+
+func NewIdempotencyRepository(db *txmanager.Manager) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// ********** Implementation Reserve Key**********
+func (conn IdempotencyRepository) Reserve(ctx context.Context, rec *idempotencyModel.Record) (*idempotencyModel.Record, error) {
+	var existing *idempotencyModel.Record
+
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		// key yang sudah kedaluwarsa boleh dipakai lagi
+		_, err := tx.Exec(ctx,
+			`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND expires_at <= NOW()`,
+			rec.Scope, rec.Key)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		// request yang datang bersamaan menunggu insert pertama selesai,
+		// lalu membaca row milik request pertama
+		tag, err := tx.Exec(ctx,
+			`INSERT INTO idempotency_keys (scope, key, request_hash, expires_at)
+			 VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (scope, key) DO NOTHING`,
+			rec.Scope, rec.Key, rec.RequestHash, rec.ExpiresAt)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil
+		}
+
+		var r idempotencyModel.Record
+		err = tx.QueryRow(ctx,
+			`SELECT scope, key, request_hash, status_code, content_type, body, body_withheld, created_at, expires_at
+			 FROM idempotency_keys WHERE scope = $1 AND key = $2`,
+			rec.Scope, rec.Key).
+			Scan(&r.Scope, &r.Key, &r.RequestHash, &r.StatusCode, &r.ContentType, &r.Body, &r.Withheld, &r.CreatedAt, &r.ExpiresAt)
+		if err != nil {
+			// request pertama baru saja melepas key-nya, client cukup retry
+			if err == pgx.ErrNoRows {
+				return utils.ErrIdempotencyKeyInFlight
+			}
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		existing = &r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// ********** Implementation Complete Key**********
+func (conn IdempotencyRepository) Complete(ctx context.Context, rec *idempotencyModel.Record) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE idempotency_keys SET status_code = $3, content_type = $4, body = $5, body_withheld = $6
+		 WHERE scope = $1 AND key = $2`,
+		rec.Scope, rec.Key, rec.StatusCode, rec.ContentType, rec.Body, rec.Withheld)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Release Key**********
+func (conn IdempotencyRepository) Release(ctx context.Context, scope, key string) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND status_code = 0`,
+		scope, key)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Purge Expired Key**********
+func (conn IdempotencyRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	tag, err := conn.db.Conn(ctx).Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
+	}
+	return tag.RowsAffected(), nil
+}
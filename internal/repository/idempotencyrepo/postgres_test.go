@@ -0,0 +1,92 @@
+package idempotencyrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/idempotencyModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConn membuka database test dari TEST_DATABASE_URI, test di-skip jika
+// tidak diset
+func testConn(tb testing.TB) *txmanager.Manager {
+	tb.Helper()
+
+	uri := os.Getenv("TEST_DATABASE_URI")
+	if uri == "" {
+		tb.Skip("TEST_DATABASE_URI not set")
+	}
+
+	logger.Initialize("test")
+
+	pool, err := pgxpool.New(context.Background(), uri)
+	require.NoError(tb, err)
+	tb.Cleanup(pool.Close)
+
+	return txmanager.New(pool)
+}
+
+func TestIdempotencyRepository_Lifecycle(t *testing.T) {
+	db := testConn(t)
+	repo := NewIdempotencyRepository(db)
+	ctx := context.Background()
+
+	scope := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.Pool().Exec(context.Background(), `DELETE FROM idempotency_keys WHERE scope = $1`, scope)
+	})
+
+	rec := &idempotencyModel.Record{
+		Scope:       scope,
+		Key:         "create-kaos",
+		RequestHash: fmt.Sprintf("%064d", 1),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	existing, err := repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// request kedua saat yang pertama belum selesai
+	existing, err = repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.False(t, existing.Completed())
+
+	rec.StatusCode = 201
+	rec.ContentType = "application/json"
+	rec.Body = []byte(`{"status":"success"}`)
+	require.NoError(t, repo.Complete(ctx, rec))
+
+	existing, err = repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, 201, existing.StatusCode)
+	assert.Equal(t, rec.Body, existing.Body)
+
+	// key yang sudah selesai tidak ikut dilepas
+	require.NoError(t, repo.Release(ctx, scope, rec.Key))
+	existing, err = repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	assert.NotNil(t, existing)
+
+	// key kedaluwarsa boleh dipakai lagi
+	_, err = db.Pool().Exec(ctx, `UPDATE idempotency_keys SET expires_at = NOW() - INTERVAL '1 minute' WHERE scope = $1`, scope)
+	require.NoError(t, err)
+	existing, err = repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	require.NoError(t, repo.Release(ctx, scope, rec.Key))
+	existing, err = repo.Reserve(ctx, rec)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+}
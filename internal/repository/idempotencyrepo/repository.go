@@ -0,0 +1,22 @@
+package idempotencyrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/idempotencyModel"
+)
+
+type IdempotencyRepoInterface interface {
+	// Catat key sebagai sedang diproses. Jika key sudah dipakai (dan belum
+	// kedaluwarsa) record yang ada dikembalikan dan tidak ada yang ditulis
+	Reserve(ctx context.Context, rec *idempotencyModel.Record) (*idempotencyModel.Record, error)
+
+	// Simpan respons untuk key yang sudah di-reserve
+	Complete(ctx context.Context, rec *idempotencyModel.Record) error
+
+	// Lepas key yang belum selesai supaya request bisa dicoba ulang
+	Release(ctx context.Context, scope, key string) error
+
+	// Hapus semua key yang sudah kedaluwarsa
+	PurgeExpired(ctx context.Context) (int64, error)
+}
@@ -0,0 +1,119 @@
+package outboxrepo
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+// ===========================================
+// Outbox Repository
+// ===========================================
+
+type OutboxRepository struct {
+	db *txmanager.Manager
+}
+
+func NewOutboxRepository(db *txmanager.Manager) *OutboxRepository {
+	return &OutboxRepository{
+		db: db,
+	}
+}
+
+// Append menulis event ke outbox. Harus dipanggil di dalam transaksi yang
+// sama dengan perubahan datanya supaya event tidak hilang atau terkirim
+// untuk perubahan yang di-rollback.
+func Append(ctx context.Context, tx txmanager.DBTX, eventType, aggregateType string, aggregateID int64, payload any) error {
+	event, err := eventModel.New(eventType, aggregateType, aggregateID, payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO outbox (event_type, aggregate_type, aggregate_id, payload) VALUES ($1, $2, $3, $4)`,
+		event.Type, event.AggregateType, event.AggregateID, event.Payload)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Claim Pending Event**********
+// Klaim dan commit dalam satu statement: next_attempt_at dimajukan sebesar
+// lease sehingga relay lain tidak mengambil event yang sama, dan tidak ada
+// transaksi atau row lock yang terbuka selama event dikirim.
+func (conn OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]eventModel.Event, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`UPDATE outbox SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, aggregate_type, aggregate_id, payload, created_at, attempts`,
+		limit, lease.Seconds())
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var events []eventModel.Event
+	for rows.Next() {
+		var e eventModel.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.AggregateType, &e.AggregateID, &e.Payload, &e.OccurredAt, &e.Attempts); err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, utils.MapDbError(err)
+	}
+
+	// RETURNING tidak menjamin urutan
+	slices.SortFunc(events, func(a, b eventModel.Event) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+// ********** Implementation Mark Published**********
+func (conn OutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE outbox SET published_at = NOW(), attempts = attempts + 1, last_error = NULL WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Mark Failed**********
+func (conn OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttempt time.Time) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id, reason, nextAttempt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Release Claims**********
+func (conn OutboxRepository) ReleaseClaims(ctx context.Context, ids []int64) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE outbox SET next_attempt_at = NOW() WHERE id = ANY($1) AND published_at IS NULL`, ids)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
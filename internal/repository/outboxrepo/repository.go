@@ -0,0 +1,24 @@
+package outboxrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+)
+
+type OutboxRepoInterface interface {
+	// Klaim event yang belum terkirim dan sudah waktunya dicoba. Event yang
+	// diklaim baru bisa diklaim lagi setelah lease habis, kecuali ditandai
+	// lewat MarkPublished / MarkFailed.
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]eventModel.Event, error)
+
+	MarkPublished(ctx context.Context, id int64) error
+
+	// Catat kegagalan kirim dan jadwalkan percobaan berikutnya
+	MarkFailed(ctx context.Context, id int64, reason string, nextAttempt time.Time) error
+
+	// Lepas klaim event yang tidak sempat dikirim supaya langsung bisa
+	// diklaim lagi tanpa menunggu lease habis
+	ReleaseClaims(ctx context.Context, ids []int64) error
+}
@@ -0,0 +1,161 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedCategoryChain membuat root > mid > leaf
+func seedCategoryChain(tb testing.TB, repo *CategoryRepository) (root, mid, leaf int64) {
+	tb.Helper()
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	var err error
+	root, err = repo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Tree Root %d", suffix)})
+	require.NoError(tb, err)
+	mid, err = repo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Tree Mid %d", suffix), ParentID: &root})
+	require.NoError(tb, err)
+	leaf, err = repo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Tree Leaf %d", suffix), ParentID: &mid})
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		repo.db.Pool().Exec(context.Background(), `DELETE FROM categories WHERE id = ANY($1)`, []int64{leaf, mid, root})
+	})
+	return root, mid, leaf
+}
+
+func TestCategoryRepository_PreventCycle(t *testing.T) {
+	db := testConn(t)
+	repo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	root, mid, leaf := seedCategoryChain(t, repo)
+
+	rootCategory, err := repo.FindCategory(ctx, root)
+	require.NoError(t, err)
+
+	// root ke bawah leaf (turunannya sendiri)
+	rootCategory.ParentID = &leaf
+	assert.Equal(t, errorUtils.ErrCategoryCycle, repo.UpdateCategory(ctx, rootCategory))
+
+	// parent ke dirinya sendiri
+	rootCategory.ParentID = &root
+	assert.Equal(t, errorUtils.ErrCategoryCycle, repo.UpdateCategory(ctx, rootCategory))
+
+	// leaf dipindah langsung ke bawah root: valid
+	leafCategory, err := repo.FindCategory(ctx, leaf)
+	require.NoError(t, err)
+	leafCategory.ParentID = &root
+	require.NoError(t, repo.UpdateCategory(ctx, leafCategory))
+
+	children, err := repo.FindCategoryByParent(ctx, root)
+	require.NoError(t, err)
+	assert.Len(t, children, 2)
+
+	ancestors, err := repo.FindCategoryAncestors(ctx, mid)
+	require.NoError(t, err)
+	require.Len(t, ancestors, 1)
+	assert.Equal(t, root, ancestors[0].ID)
+
+	// urut dari root
+	ancestors, err = repo.FindCategoryAncestors(ctx, leaf)
+	require.NoError(t, err)
+	require.Len(t, ancestors, 2)
+	assert.Equal(t, root, ancestors[0].ID)
+	assert.Equal(t, mid, ancestors[1].ID)
+
+	_, err = repo.FindCategory(ctx, -1)
+	assert.Equal(t, errorUtils.ErrNotFound, err)
+}
+
+func TestProductRepository_FindAllIncludeDescendants(t *testing.T) {
+	db := testConn(t)
+	productRepo := NewProductRepository(db)
+	categoryRepo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	root, _, leaf := seedCategoryChain(t, categoryRepo)
+
+	id, err := productRepo.Create(ctx, &productModel.Product{Name: "Subtree Product", CategoryId: []*int64{&leaf}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, id)
+	})
+
+	direct, _, err := productRepo.FindAll(ctx, ProductFilter{CategoryID: &root})
+	require.NoError(t, err)
+	assert.Empty(t, direct)
+
+	subtree, _, err := productRepo.FindAll(ctx, ProductFilter{CategoryID: &root, IncludeDescendants: true})
+	require.NoError(t, err)
+	require.Len(t, subtree, 1)
+	assert.Equal(t, id, subtree[0].ID)
+}
+
+func TestCategoryRepository_DeleteStrategies(t *testing.T) {
+	db := testConn(t)
+	productRepo := NewProductRepository(db)
+	repo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	categoriesOf := func(productID int64) []int64 {
+		var ids []int64
+		rows, err := db.Pool().Query(ctx, `SELECT category_id FROM category_products WHERE product_id = $1 ORDER BY category_id`, productID)
+		require.NoError(t, err)
+		for rows.Next() {
+			var id int64
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	root, mid, leaf := seedCategoryChain(t, repo)
+	productID, err := productRepo.Create(ctx, &productModel.Product{Name: "Delete Strategy", CategoryId: []*int64{&mid}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, productID)
+	})
+
+	// forbid_if_used: mid masih punya leaf & product
+	blockers, err := repo.DeleteCategory(ctx, mid, productModel.CategoryDelete{Strategy: productModel.CategoryDeleteForbid})
+	assert.Equal(t, errorUtils.ErrConflict, err)
+	require.NotNil(t, blockers)
+	assert.Equal(t, []int64{leaf}, blockers.Children)
+	assert.Equal(t, []int64{productID}, blockers.Products)
+	assert.Equal(t, 1, blockers.ProductCount)
+
+	// reparent: leaf & product pindah ke root
+	_, err = repo.DeleteCategory(ctx, mid, productModel.CategoryDelete{Strategy: productModel.CategoryDeleteReparent})
+	require.NoError(t, err)
+
+	leafCategory, err := repo.FindCategory(ctx, leaf)
+	require.NoError(t, err)
+	assert.Equal(t, root, *leafCategory.ParentID)
+	assert.Equal(t, []int64{root}, categoriesOf(productID))
+
+	// reparent root yang masih punya product ditolak
+	blockers, err = repo.DeleteCategory(ctx, root, productModel.CategoryDelete{Strategy: productModel.CategoryDeleteReparent})
+	assert.Equal(t, errorUtils.ErrConflict, err)
+	assert.Equal(t, []int64{productID}, blockers.Products)
+
+	// move_products_to: product pindah ke leaf, leaf jadi root
+	_, err = repo.DeleteCategory(ctx, root, productModel.CategoryDelete{Strategy: productModel.CategoryDeleteMove, MoveProductsTo: &leaf})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{leaf}, categoriesOf(productID))
+
+	leafCategory, err = repo.FindCategory(ctx, leaf)
+	require.NoError(t, err)
+	assert.Nil(t, leafCategory.ParentID)
+
+	_, err = repo.DeleteCategory(ctx, root, productModel.CategoryDelete{Strategy: productModel.CategoryDeleteForbid})
+	assert.Equal(t, errorUtils.ErrNotFound, err)
+}
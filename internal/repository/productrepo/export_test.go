@@ -0,0 +1,131 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_StreamExport(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	categoryRepo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	parentID, err := categoryRepo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Export Drinks %d", suffix)})
+	require.NoError(t, err)
+	childID, err := categoryRepo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Export Soda %d", suffix), ParentID: &parentID})
+	require.NoError(t, err)
+
+	barcode := fmt.Sprintf("EXP-%d", suffix)
+	id, err := repo.Create(ctx, &productModel.Product{
+		Name:       "Export Test",
+		CategoryId: []*int64{&childID},
+		Variants: []productModel.Variant{{
+			SKU:      fmt.Sprintf("EXP-SKU-%d", suffix),
+			BaseUnit: "pcs",
+			Stock:    5,
+			Options:  []productModel.VariantOption{{Name: "size", Value: "M"}},
+			Units: []productModel.VariantUnit{
+				{Name: "pack", ConversionRate: 6, Price: 17000},
+				{Name: "pcs", Barcode: &barcode, ConversionRate: 1, Price: 3000},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, id)
+		db.Pool().Exec(context.Background(), `DELETE FROM categories WHERE id = ANY($1)`, []int64{childID, parentID})
+	})
+
+	var rows []productModel.ExportRow
+	err = repo.StreamExport(ctx, ProductFilter{CategoryID: &childID}, func(row productModel.ExportRow) error {
+		rows = append(rows, row)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// unit diurutkan dari conversion_rate terkecil
+	require.Len(t, rows, 2)
+	assert.Equal(t, "pcs", rows[0].UnitName)
+	assert.Equal(t, barcode, *rows[0].Barcode)
+	assert.Equal(t, "pack", rows[1].UnitName)
+	assert.Nil(t, rows[1].Barcode)
+
+	assert.Equal(t, id, rows[0].ProductID)
+	assert.Equal(t, 5, rows[0].Stock)
+	assert.Equal(t, []string{fmt.Sprintf("Export Drinks %d > Export Soda %d", suffix, suffix)}, rows[0].Categories)
+	assert.Equal(t, "size", rows[0].Options[0].Name)
+
+	// error dari fn menghentikan iterasi
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = repo.StreamExport(ctx, ProductFilter{CategoryID: &childID}, func(productModel.ExportRow) error {
+		calls++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProductRepository_StreamExport_PagesProducts(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	categoryRepo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	categoryID, err := categoryRepo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Export Paging %d", suffix)})
+	require.NoError(t, err)
+
+	withUnits, err := repo.Create(ctx, &productModel.Product{
+		Name:       "Export Paging Units",
+		CategoryId: []*int64{&categoryID},
+		Variants: []productModel.Variant{{
+			SKU:      fmt.Sprintf("EXP-PAGE-%d", suffix),
+			BaseUnit: "pcs",
+			Units: []productModel.VariantUnit{
+				{Name: "pcs", ConversionRate: 1, Price: 3000},
+				{Name: "pack", ConversionRate: 6, Price: 17000},
+			},
+		}},
+	})
+	require.NoError(t, err)
+	withoutVariants, err := repo.Create(ctx, &productModel.Product{
+		Name:       "Export Paging Empty",
+		CategoryId: []*int64{&categoryID},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, withUnits, withoutVariants)
+		db.Pool().Exec(context.Background(), `DELETE FROM categories WHERE id = $1`, categoryID)
+	})
+
+	export := func(filter ProductFilter) []productModel.ExportRow {
+		var rows []productModel.ExportRow
+		require.NoError(t, repo.StreamExport(ctx, filter, func(row productModel.ExportRow) error {
+			rows = append(rows, row)
+			return nil
+		}))
+		return rows
+	}
+
+	// limit menghitung product, semua unit product pertama ikut
+	rows := export(ProductFilter{CategoryID: &categoryID, Limit: 1})
+	require.Len(t, rows, 2)
+	assert.Equal(t, withUnits, rows[0].ProductID)
+	assert.Equal(t, withUnits, rows[1].ProductID)
+
+	// product tanpa varian tetap diekspor
+	rows = export(ProductFilter{CategoryID: &categoryID, Limit: 1, Offset: 1})
+	require.Len(t, rows, 1)
+	assert.Equal(t, withoutVariants, rows[0].ProductID)
+	assert.Zero(t, rows[0].VariantID)
+	assert.Empty(t, rows[0].UnitName)
+}
@@ -0,0 +1,103 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_FindAll_RichFilters(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	categoryRepo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	root, mid, leaf := seedCategoryChain(t, categoryRepo)
+	prefix := fmt.Sprintf("Filter%d", time.Now().UnixNano())
+	barcode := prefix + "-BC"
+
+	// kaos: di root & leaf, merah/M, stok 3, ber-barcode
+	shirt, err := repo.Create(ctx, &productModel.Product{
+		Name:       prefix + " Kaos",
+		CategoryId: []*int64{&root, &leaf},
+		Variants: []productModel.Variant{{
+			SKU:      prefix + "-KAOS",
+			BaseUnit: "pcs",
+			Stock:    3,
+			Options:  []productModel.VariantOption{{Name: "Color", Value: "Red"}, {Name: "size", Value: "M"}},
+			Units:    []productModel.VariantUnit{{Name: "pcs", Barcode: &barcode, ConversionRate: 1, Price: 50000}},
+		}},
+	})
+	require.NoError(t, err)
+
+	// jaket: di mid saja, biru/L, stok habis, tanpa barcode
+	jacket, err := repo.Create(ctx, &productModel.Product{
+		Name:       prefix + " Jaket",
+		CategoryId: []*int64{&mid},
+		Variants: []productModel.Variant{{
+			SKU:      prefix + "-JAKET",
+			BaseUnit: "pcs",
+			Options:  []productModel.VariantOption{{Name: "color", Value: "blue"}, {Name: "size", Value: "L"}},
+			Units:    []productModel.VariantUnit{{Name: "pcs", ConversionRate: 1, Price: 250000}},
+		}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, shirt, jacket)
+	})
+
+	ids := func(filter ProductFilter) []int64 {
+		filter.Keyword = prefix
+		products, _, err := repo.FindAll(ctx, filter)
+		require.NoError(t, err)
+
+		res := []int64{}
+		for _, p := range products {
+			res = append(res, p.ID)
+		}
+
+		total, err := repo.CountAll(ctx, filter)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(res)), total)
+		return res
+	}
+
+	price := func(v int64) *int64 { return &v }
+	yes, no := true, false
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		filter ProductFilter
+		want   []int64
+	}{
+		{"kategori any", ProductFilter{CategoryIDs: []int64{leaf, mid}}, []int64{shirt, jacket}},
+		{"kategori all", ProductFilter{CategoryIDs: []int64{root, leaf}, CategoryMatchAll: true}, []int64{shirt}},
+		{"kategori all beserta turunan", ProductFilter{CategoryIDs: []int64{root, mid}, CategoryMatchAll: true, IncludeDescendants: true}, []int64{shirt, jacket}},
+		{"harga min", ProductFilter{MinPrice: price(100000)}, []int64{jacket}},
+		{"harga rentang", ProductFilter{MinPrice: price(10000), MaxPrice: price(60000)}, []int64{shirt}},
+		{"ada stok", ProductFilter{StockState: StockIn}, []int64{shirt}},
+		{"stok menipis", ProductFilter{StockState: StockLow, LowStockThreshold: 5}, []int64{shirt}},
+		{"stok menipis threshold kecil", ProductFilter{StockState: StockLow, LowStockThreshold: 2}, []int64{}},
+		{"stok habis", ProductFilter{StockState: StockOut}, []int64{jacket}},
+		{"option satu varian", ProductFilter{Options: map[string][]string{"color": {"red"}, "SIZE": {"m"}}}, []int64{shirt}},
+		{"option beda varian", ProductFilter{Options: map[string][]string{"color": {"red"}, "size": {"L"}}}, []int64{}},
+		{"option salah satu nilai", ProductFilter{Options: map[string][]string{"color": {"red", "blue"}}}, []int64{shirt, jacket}},
+		{"punya barcode", ProductFilter{HasBarcode: &yes}, []int64{shirt}},
+		{"tanpa barcode", ProductFilter{HasBarcode: &no}, []int64{jacket}},
+		{"dibuat dalam rentang", ProductFilter{CreatedFrom: &past, CreatedTo: &future}, []int64{shirt, jacket}},
+		{"diubah setelah", ProductFilter{UpdatedFrom: &future}, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.want, ids(tt.filter))
+		})
+	}
+}
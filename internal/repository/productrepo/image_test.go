@@ -0,0 +1,69 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_ImageRefCount(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	hash := fmt.Sprintf("%064d", time.Now().UnixNano())
+	image := productModel.ProductImage{
+		URL:  "/uploads/" + hash + ".jpg",
+		Hash: hash,
+		Renditions: []productModel.ImageRendition{
+			{Name: productModel.RenditionOriginal, URL: "/uploads/" + hash + ".jpg", Width: 100, Height: 100, ByteSize: 10},
+		},
+	}
+
+	// dua product memakai file yang sama
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		id, err := repo.Create(ctx, &productModel.Product{Name: "Shared Image", Images: []productModel.ProductImage{image}})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	t.Cleanup(func() {
+		deleteProducts(t, db, ids...)
+		db.Pool().Exec(context.Background(), `DELETE FROM image_blobs WHERE hash = $1`, hash)
+	})
+
+	refCount := func() int {
+		var n int
+		require.NoError(t, db.Pool().QueryRow(ctx, `SELECT ref_count FROM image_blobs WHERE hash = $1`, hash).Scan(&n))
+		return n
+	}
+	assert.Equal(t, 2, refCount())
+
+	detail, err := repo.FindByID(ctx, ids[0])
+	require.NoError(t, err)
+	require.Len(t, detail.Images, 1)
+	assert.Equal(t, hash, detail.Images[0].Hash)
+	assert.Len(t, detail.Images[0].Renditions, 1)
+
+	// hapus gambar dari product pertama: file masih dipakai product kedua
+	other := productModel.ProductImage{URL: "/uploads/other.jpg", SortOrder: 1}
+	require.NoError(t, repo.UpdateImage(ctx, ids[0], []productModel.ProductImage{other}))
+	assert.Equal(t, 1, refCount())
+
+	unused, err := repo.PurgeImageBlob(ctx, hash)
+	require.NoError(t, err)
+	assert.False(t, unused)
+
+	// referensi terakhir dilepas
+	require.NoError(t, repo.UpdateImage(ctx, ids[1], []productModel.ProductImage{other}))
+	assert.Equal(t, 0, refCount())
+
+	unused, err = repo.PurgeImageBlob(ctx, hash)
+	require.NoError(t, err)
+	assert.True(t, unused)
+}
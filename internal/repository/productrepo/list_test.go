@@ -0,0 +1,72 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_FindAll_Keyset(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("Keyset%d", time.Now().UnixNano())
+
+	// nama dan harga sengaja berlawanan urutan
+	var ids []int64
+	for i, price := range []int64{3000, 2000, 1000} {
+		id, err := repo.Create(ctx, &productModel.Product{
+			Name: fmt.Sprintf("%s %c", prefix, 'A'+i),
+			Variants: []productModel.Variant{{
+				SKU:      fmt.Sprintf("%s-%d", prefix, i),
+				BaseUnit: "pcs",
+				Units:    []productModel.VariantUnit{{Name: "pcs", ConversionRate: 1, Price: price}},
+			}},
+		})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	t.Cleanup(func() {
+		deleteProducts(t, db, ids...)
+	})
+
+	collect := func(filter ProductFilter) []int64 {
+		filter.Keyword = prefix
+		filter.Limit = 2
+
+		var got []int64
+		for pages := 0; pages < 5; pages++ {
+			products, next, err := repo.FindAll(ctx, filter)
+			require.NoError(t, err)
+			for _, p := range products {
+				got = append(got, p.ID)
+			}
+			if next == nil {
+				return got
+			}
+			assert.Len(t, products, 2)
+			filter.After = next
+		}
+		t.Fatal("cursor tidak berhenti")
+		return nil
+	}
+
+	assert.Equal(t, []int64{ids[0], ids[1], ids[2]}, collect(ProductFilter{Sort: SortName}))
+	assert.Equal(t, []int64{ids[2], ids[1], ids[0]}, collect(ProductFilter{Sort: SortName, Desc: true}))
+	assert.Equal(t, []int64{ids[2], ids[1], ids[0]}, collect(ProductFilter{Sort: SortPrice}))
+	assert.Equal(t, []int64{ids[2], ids[1], ids[0]}, collect(ProductFilter{Desc: true}))
+	assert.Equal(t, []int64{ids[0], ids[1], ids[2]}, collect(ProductFilter{Sort: SortCreatedAt}))
+
+	total, err := repo.CountAll(ctx, ProductFilter{Keyword: prefix})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+
+	_, _, err = repo.FindAll(ctx, ProductFilter{Sort: "stock"})
+	assert.Error(t, err)
+}
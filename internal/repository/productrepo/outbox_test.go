@@ -0,0 +1,64 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_Outbox(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &productModel.Product{Name: "Outbox"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Pool().Exec(context.Background(), `DELETE FROM outbox WHERE aggregate_type = 'product' AND aggregate_id = $1`, id)
+		deleteProducts(t, db, id)
+	})
+
+	_, err = repo.ChangeStatus(ctx, id, productModel.TransitionArchive, productModel.Actor{Name: "test"})
+	require.NoError(t, err)
+
+	rows, err := db.Pool().Query(ctx,
+		`SELECT event_type, payload->>'status' FROM outbox
+		WHERE aggregate_type = 'product' AND aggregate_id = $1 ORDER BY id`, id)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var types, statuses []string
+	for rows.Next() {
+		var eventType, status string
+		require.NoError(t, rows.Scan(&eventType, &status))
+		types = append(types, eventType)
+		statuses = append(statuses, status)
+	}
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []string{eventModel.ProductCreated, eventModel.ProductArchived}, types)
+	assert.Equal(t, []string{"active", "archived"}, statuses)
+}
+
+func TestProductRepository_OutboxRollback(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("Outbox Rollback %d", time.Now().UnixNano())
+
+	// kategori tidak ada: insert product gagal, event ikut batal
+	missing := int64(-1)
+	_, err := repo.Create(ctx, &productModel.Product{Name: name, CategoryId: []*int64{&missing}})
+	require.Error(t, err)
+
+	var n int
+	require.NoError(t, db.Pool().QueryRow(ctx, `SELECT COUNT(*) FROM outbox WHERE payload->>'name' = $1`, name).Scan(&n))
+	assert.Zero(t, n)
+}
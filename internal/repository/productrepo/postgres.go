@@ -4,13 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/outboxrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
 	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // ===========================================
@@ -18,10 +25,10 @@ import (
 // ===========================================
 
 type ProductRepository struct {
-	db *pgx.Conn
+	db *txmanager.Manager
 }
 
-func NewProductRepository(db *pgx.Conn) *ProductRepository {
+func NewProductRepository(db *txmanager.Manager) *ProductRepository {
 	return &ProductRepository{
 		db: db,
 	}
@@ -30,126 +37,484 @@ func NewProductRepository(db *pgx.Conn) *ProductRepository {
 // ********** Implementation Create Product**********
 func (conn ProductRepository) Create(ctx context.Context, p *productModel.Product) (int64, error) {
 
-	tx, err := conn.db.Begin(ctx)
+	var productID int64
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		// insert into table product
+		var status, stockPolicy string
+		err := tx.QueryRow(ctx,
+			`INSERT INTO products (name, description, stock_policy, status)
+         VALUES ($1, $2, COALESCE(NULLIF($3, ''), 'block'), COALESCE(NULLIF($4, ''), 'active')) RETURNING id, status, stock_policy`,
+			p.Name, p.Description, p.StockPolicy, p.Status,
+		).Scan(&productID, &status, &stockPolicy)
+		if err != nil {
+			return utils.MapDbError(err)
+		}
+
+		// insert into table categoy_product
+		if len(p.CategoryId) > 0 {
+			batch := &pgx.Batch{}
+			for _, cid := range p.CategoryId {
+				batch.Queue(
+					"INSERT INTO category_products (product_id, category_id) VALUES ($1, $2)",
+					productID, cid,
+				)
+			}
+
+			br := tx.SendBatch(ctx, batch)
+			for range p.CategoryId {
+				if _, err := br.Exec(); err != nil {
+					br.Close()
+					return err
+				}
+			}
+			br.Close()
+		}
+
+		// insert into table product_images
+		for _, img := range p.Images {
+			if _, err := conn.insertImage(ctx, productID, img); err != nil {
+				return err
+			}
+		}
+		// insert variants
+		for _, v := range p.Variants {
+			if _, err := conn.AddVariant(ctx, v, productID); err != nil {
+				return err
+			}
+		}
+
+		payload := productPayload(p)
+		payload.ID, payload.Status, payload.StockPolicy = productID, status, stockPolicy
+		return outboxrepo.Append(ctx, tx, eventModel.ProductCreated, eventModel.AggregateProduct, productID, payload)
+	})
 	if err != nil {
 		return 0, err
 	}
-	defer tx.Rollback(ctx)
 
-	// insert into table product
-	var productID int64
-	err = tx.QueryRow(ctx,
-		`INSERT INTO products (name, description)
-         VALUES ($1, $2) RETURNING id`,
-		p.Name, p.Description,
-	).Scan(&productID)
-	if err != nil {
-		return 0, utils.MapDbError(err)
-	}
+	return productID, nil
+}
+
+// ********** Implementation Add Variant Product**********
+func (conn ProductRepository) AddVariant(ctx context.Context, variant productModel.Variant, id int64) (int64, error) {
+
+	var variant_id int64
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		err := tx.QueryRow(ctx,
+			"INSERT INTO variants (product_id, sku, base_unit, stock, cost_price) VALUES ($1, $2, $3, 0, $4) RETURNING id",
+			id,
+			variant.SKU,
+			variant.BaseUnit,
+			variant.CostPrice).
+			Scan(&variant_id)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		// stok awal masuk lewat ledger
+		if variant.Stock != 0 {
+			err = stockrepo.ApplyMovement(ctx, tx, &stockModel.Movement{
+				VariantID: variant_id,
+				Quantity:  variant.Stock,
+				Reason:    stockModel.ReasonAdjustment,
+				Note:      "initial stock",
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, vOption := range variant.Options {
+			_, err = tx.Exec(ctx,
+				"INSERT INTO variant_options (variant_id, name, value) VALUES ($1, $2, $3)",
+				variant_id,
+				vOption.Name,
+				vOption.Value)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
 
-	// insert into table categoy_product
-	if len(p.CategoryId) > 0 {
-		batch := &pgx.Batch{}
-		for _, cid := range p.CategoryId {
-			batch.Queue(
-				"INSERT INTO category_products (product_id, category_id) VALUES ($1, $2)",
-				productID, cid,
-			)
 		}
 
-		br := tx.SendBatch(ctx, batch)
-		for range p.CategoryId {
-			if _, err := br.Exec(); err != nil {
-				br.Close()
-				return 0, err
+		for _, vUnit := range variant.Units {
+			_, err = tx.Exec(ctx,
+				"INSERT INTO variant_units (variant_id, name, barcode, conversion_rate, price) VALUES ($1, $2, $3, $4, $5)",
+				variant_id,
+				vUnit.Name,
+				vUnit.Barcode,
+				vUnit.ConversionRate,
+				vUnit.Price)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
 			}
+
 		}
-		br.Close()
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	// insert into table product_images
-	for _, img := range p.Images {
-		_, err = tx.Exec(ctx,
-			"INSERT INTO product_images (product_id, url, sort_order) VALUES ($1, $2, $3)",
-			productID, img.URL, img.SortOrder,
-		)
+	return variant_id, nil
+}
+
+// ********** Implementation Create Variant**********
+func (conn ProductRepository) CreateVariant(ctx context.Context, productID int64, v *productModel.Variant) (int64, error) {
+
+	var id int64
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		var exists bool
+		err := conn.db.Conn(ctx).QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists)
 		if err != nil {
-			return 0, err
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
 		}
-	}
-	// insert variants
-	for _, v := range p.Variants {
-		if err := conn.AddVariant(ctx, v, tx, productID); err != nil {
-			return 0, err
+		if !exists {
+			return utils.ErrNotFound
+		}
+
+		id, err = conn.AddVariant(ctx, *v, productID)
+		if err != nil {
+			return err
 		}
+
+		return touchProduct(ctx, conn.db.Conn(ctx), productID)
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return productID, tx.Commit(ctx)
+	return id, nil
 }
 
-// ********** Implementation Add Variant Product**********
-func (conn ProductRepository) AddVariant(ctx context.Context, variant productModel.Variant, tx pgx.Tx, id int64) error {
+// ********** Implementation Update Variant**********
+func (conn ProductRepository) UpdateVariant(ctx context.Context, v *productModel.Variant) error {
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := conn.updateVariant(ctx, *v); err != nil {
+			return err
+		}
 
-	var variant_id int64
+		return touchProduct(ctx, conn.db.Conn(ctx), v.ProductID)
+	})
+}
+
+// updateVariant update row variant lalu diff option & unit berdasarkan ID:
+// ID 0 di-insert, ID lama di-update, ID yang tidak dikirim dihapus.
+// Perubahan stok tidak ditulis langsung, tapi dicatat sebagai adjustment.
+func (conn ProductRepository) updateVariant(ctx context.Context, v productModel.Variant) error {
+	tx := conn.db.Conn(ctx)
+
+	var currentStock int
 	err := tx.QueryRow(ctx,
-		"INSERT INTO variants (product_id, sku, base_unit, stock, cost_price) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		id,
-		variant.SKU,
-		variant.BaseUnit,
-		variant.Stock,
-		variant.CostPrice).
-		Scan(&variant_id)
+		`SELECT stock FROM variants WHERE id=$1 AND product_id=$2 FOR UPDATE`,
+		v.ID, v.ProductID).Scan(&currentStock)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE variants SET sku=$1, base_unit=$2, cost_price=$3, updated_at=NOW() WHERE id=$4`,
+		v.SKU, v.BaseUnit, v.CostPrice, v.ID)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	if delta := v.Stock - currentStock; delta != 0 {
+		err = stockrepo.ApplyMovement(ctx, tx, &stockModel.Movement{
+			VariantID: v.ID,
+			Quantity:  delta,
+			Reason:    stockModel.ReasonAdjustment,
+			Note:      "variant edit",
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := conn.updateVariantOptions(ctx, v.ID, v.Options); err != nil {
 		return err
 	}
 
-	for _, vOption := range variant.Options {
-		_, err = tx.Exec(ctx,
-			"INSERT INTO variant_options (variant_id, name, value) VALUES ($1, $2, $3)",
-			variant_id,
-			vOption.Name,
-			vOption.Value)
+	return conn.updateVariantUnits(ctx, v.ID, v.Units)
+}
+
+func (conn ProductRepository) updateVariantOptions(ctx context.Context, variantID int64, options []productModel.VariantOption) error {
+	tx := conn.db.Conn(ctx)
+
+	keep := make([]int64, 0, len(options))
+	for _, opt := range options {
+		if opt.ID != 0 {
+			keep = append(keep, opt.ID)
+		}
+	}
+
+	_, err := tx.Exec(ctx,
+		`DELETE FROM variant_options WHERE variant_id = $1 AND NOT (id = ANY($2::bigint[]))`,
+		variantID, keep)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	for _, opt := range options {
+		if opt.ID == 0 {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO variant_options (variant_id, name, value) VALUES ($1, $2, $3)`,
+				variantID, opt.Name, opt.Value)
+		} else {
+			var tag pgconn.CommandTag
+			tag, err = tx.Exec(ctx,
+				`UPDATE variant_options SET name=$1, value=$2, updated_at=NOW() WHERE id=$3 AND variant_id=$4`,
+				opt.Name, opt.Value, opt.ID, variantID)
+			if err == nil && tag.RowsAffected() == 0 {
+				return utils.ErrNotFound
+			}
+		}
 		if err != nil {
 			logger.Error("Error: ", err.Error())
-			return err
+			return utils.MapDbError(err)
 		}
+	}
+	return nil
+}
 
+func (conn ProductRepository) updateVariantUnits(ctx context.Context, variantID int64, units []productModel.VariantUnit) error {
+	tx := conn.db.Conn(ctx)
+
+	keep := make([]int64, 0, len(units))
+	for _, u := range units {
+		if u.ID != 0 {
+			keep = append(keep, u.ID)
+		}
 	}
 
-	for _, vUnit := range variant.Units {
-		_, err = tx.Exec(ctx,
-			"INSERT INTO variant_units (variant_id, name, barcode, conversion_rate, price) VALUES ($1, $2, $3, $4, $5)",
-			variant_id,
-			vUnit.Name,
-			vUnit.Barcode,
-			vUnit.ConversionRate,
-			vUnit.Price)
+	_, err := tx.Exec(ctx,
+		`DELETE FROM variant_units WHERE variant_id = $1 AND NOT (id = ANY($2::bigint[]))`,
+		variantID, keep)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	for _, u := range units {
+		if u.ID == 0 {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO variant_units (variant_id, name, barcode, conversion_rate, price) VALUES ($1, $2, $3, $4, $5)`,
+				variantID, u.Name, u.Barcode, u.ConversionRate, u.Price)
+		} else {
+			var tag pgconn.CommandTag
+			tag, err = tx.Exec(ctx,
+				`UPDATE variant_units SET name=$1, barcode=$2, conversion_rate=$3, price=$4, updated_at=NOW()
+				 WHERE id=$5 AND variant_id=$6`,
+				u.Name, u.Barcode, u.ConversionRate, u.Price, u.ID, variantID)
+			if err == nil && tag.RowsAffected() == 0 {
+				return utils.ErrNotFound
+			}
+		}
 		if err != nil {
 			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+	}
+	return nil
+}
+
+// ********** Implementation Delete Variant**********
+func (conn ProductRepository) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		ids, err := lockVariants(ctx, tx,
+			`SELECT id FROM variants WHERE id = $1 AND product_id = $2 FOR UPDATE`, variantID, productID)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return utils.ErrNotFound
+		}
+
+		if err := deleteUnusedVariants(ctx, tx, ids); err != nil {
 			return err
 		}
 
+		return touchProduct(ctx, tx, productID)
+	})
+}
+
+// lockVariants mengunci varian yang akan dihapus supaya stoknya tidak
+// berubah di antara pengecekan dan penghapusan
+func lockVariants(ctx context.Context, tx txmanager.DBTX, query string, args ...any) ([]int64, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, utils.MapDbError(err)
+	}
+	return ids, nil
+}
+
+// deleteUnusedVariants menghapus varian yang belum pernah punya stok.
+// Varian dengan stok atau riwayat di stock_movements ditolak dengan
+// ErrVariantInUse supaya ledger-nya tetap utuh.
+func deleteUnusedVariants(ctx context.Context, tx txmanager.DBTX, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var inUse bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (
+			SELECT 1 FROM variants v
+			WHERE v.id = ANY($1::bigint[])
+			AND (v.stock <> 0 OR EXISTS (SELECT 1 FROM stock_movements m WHERE m.variant_id = v.id))
+		)`, ids).Scan(&inUse)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	if inUse {
+		return utils.ErrVariantInUse
 	}
 
+	if _, err := tx.Exec(ctx, `DELETE FROM variants WHERE id = ANY($1::bigint[])`, ids); err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
 	return nil
+}
+
+// ********** Implementation Find Variant By ID**********
+func (conn ProductRepository) FindVariantByID(ctx context.Context, productID, variantID int64) (*productModel.Variant, error) {
+	var v productModel.Variant
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT id, product_id, sku, base_unit, stock, cost_price FROM variants WHERE id = $1 AND product_id = $2`,
+		variantID, productID).
+		Scan(&v.ID, &v.ProductID, &v.SKU, &v.BaseUnit, &v.Stock, &v.CostPrice)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		return nil, utils.MapDbError(err)
+	}
+
+	if err := conn.fillVariant(ctx, &v); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// fillVariant mengisi option dan unit milik satu varian
+func (conn ProductRepository) fillVariant(ctx context.Context, v *productModel.Variant) error {
+	// Options
+	optQuery := `SELECT id, name, value FROM variant_options WHERE variant_id = $1 ORDER BY id`
+	optRows, err := conn.db.Conn(ctx).Query(ctx, optQuery, v.ID)
+	if err != nil {
+		return err
+	}
+	for optRows.Next() {
+		var opt productModel.VariantOption
+		if err := optRows.Scan(&opt.ID, &opt.Name, &opt.Value); err != nil {
+			optRows.Close()
+			return err
+		}
+		v.Options = append(v.Options, opt)
+	}
+	optRows.Close()
+	if err := optRows.Err(); err != nil {
+		return err
+	}
+
+	// Units
+	unitQuery := `SELECT id, name, barcode, conversion_rate, price FROM variant_units WHERE variant_id = $1 ORDER BY id`
+	unitRows, err := conn.db.Conn(ctx).Query(ctx, unitQuery, v.ID)
+	if err != nil {
+		return err
+	}
+	for unitRows.Next() {
+		var u productModel.VariantUnit
+		u.VariantID = v.ID
+		if err := unitRows.Scan(&u.ID, &u.Name, &u.Barcode, &u.ConversionRate, &u.Price); err != nil {
+			unitRows.Close()
+			return err
+		}
+		v.Units = append(v.Units, u)
+	}
+	unitRows.Close()
+
+	return unitRows.Err()
+}
 
+// productSortColumns: ekspresi kolom sort beserta tipe untuk cast nilai cursor
+var productSortColumns = map[string][2]string{
+	SortID:        {"p.id", "BIGINT"},
+	SortName:      {"p.name", "VARCHAR"},
+	SortCreatedAt: {"p.created_at", "TIMESTAMPTZ"},
+	SortUpdatedAt: {"p.updated_at", "TIMESTAMPTZ"},
+	SortPrice:     {"p.min_price", "BIGINT"},
 }
 
 // ********** Implementation FindAll Product**********
-func (conn ProductRepository) FindAll(ctx context.Context, filter ProductFilter) ([]productModel.Product, error) {
+// Keyset pagination: urut (kolom sort, id) dan halaman berikutnya dimulai
+// setelah pasangan nilai di cursor. Diambil Limit+1 baris untuk tahu masih
+// ada halaman berikutnya atau tidak.
+func (conn ProductRepository) FindAll(ctx context.Context, filter ProductFilter) ([]productModel.Product, *ProductCursor, error) {
+	sortColumn, ok := productSortColumns[filter.Sort]
+	if !ok {
+		return nil, nil, utils.ErrBadRequest
+	}
+	column, columnType := sortColumn[0], sortColumn[1]
+
 	query := `SELECT 
 			p.id,
 			p.name, 
 			p.description, 
 			p.status,
+			` + column + `::TEXT AS sort_value,
 			COALESCE(
 			JSONB_AGG(
 			DISTINCT JSONB_BUILD_OBJECT(
 			'id', pi.id,
 			'productId', pi.product_id,
 			'url', pi.url, 
-			'sortOrder', pi.sort_order
+			'hash', COALESCE(pi.content_hash, ''),
+			'sortOrder', pi.sort_order,
+			'renditions', COALESCE((
+				SELECT JSONB_AGG(JSONB_BUILD_OBJECT(
+					'name', r.name,
+					'url', r.url,
+					'width', r.width,
+					'height', r.height,
+					'byteSize', r.byte_size
+				) ORDER BY r.byte_size DESC)
+				FROM product_image_renditions r
+				WHERE r.image_id = pi.id
+			), '[]'::jsonb)
 			)
 			) FILTER (WHERE pi.id IS NOT NULL),
 			 '[]'::jsonb
@@ -166,60 +531,76 @@ func (conn ProductRepository) FindAll(ctx context.Context, filter ProductFilter)
 			LEFT JOIN category_products pc
 				ON pc.product_id = p.id`
 
-	var args []interface{}
-	var conditions []string
-
-	if filter.CategoryID != nil {
-		// query += " JOIN category_products cp ON cp.product_id = p.id"
-		conditions = append(conditions, fmt.Sprintf("pc.category_id = $%d", len(args)+1))
-		args = append(args, *filter.CategoryID)
-	}
+	conditions, args := productConditions(filter)
 
-	if filter.Keyword != "" {
-		conditions = append(conditions, fmt.Sprintf("p.name ILIKE $%d", len(args)+1))
-		args = append(args, "%"+filter.Keyword+"%")
+	direction, compare := "ASC", ">"
+	if filter.Desc {
+		direction, compare = "DESC", "<"
 	}
 
-	if filter.Status != "" {
-		conditions = append(conditions, fmt.Sprintf("p.status = $%d", len(args)+1))
-		args = append(args, filter.Status)
+	if filter.After != nil {
+		// perbandingan row (kolom, id) memakai index (kolom, id) ke dua arah
+		if column == "p.id" {
+			conditions = append(conditions, fmt.Sprintf("p.id %s $%d", compare, len(args)+1))
+			args = append(args, filter.After.ID)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("(%s, p.id) %s ($%d::%s, $%d)",
+				column, compare, len(args)+1, columnType, len(args)+2))
+			args = append(args, filter.After.Value, filter.After.ID)
+		}
 	}
 
 	if len(conditions) > 0 {
 		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
 
-	query += " GROUP BY p.id, p.name, p.description ORDER BY p.id DESC"
+	query += " GROUP BY p.id, p.name, p.description"
+	if column == "p.id" {
+		query += " ORDER BY p.id " + direction
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, p.id %s", column, direction, direction)
+	}
 
 	if filter.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
-		args = append(args, filter.Limit)
+		args = append(args, filter.Limit+1)
 	}
 
-	if filter.Offset > 0 {
+	if filter.Offset > 0 && filter.After == nil {
 		query += fmt.Sprintf(" OFFSET $%d", len(args)+1)
 		args = append(args, filter.Offset)
 	}
 
-	rows, err := conn.db.Query(ctx, query, args...)
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, args...)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
-		return nil, utils.MapDbError(err)
+		return nil, nil, utils.MapDbError(err)
 	}
 	defer rows.Close()
 
 	var (
 		imagesJSON     []byte
 		categoriesJSON []byte
+		sortValue      string
+		lastValue      string
+		next           *ProductCursor
 	)
 	// var products []Product
 	var productResponse []productModel.Product
 	for rows.Next() {
+		if filter.Limit > 0 && len(productResponse) == filter.Limit {
+			// baris ke Limit+1: masih ada halaman berikutnya
+			last := productResponse[len(productResponse)-1]
+			next = &ProductCursor{Sort: filter.Sort, Desc: filter.Desc, Value: lastValue, ID: last.ID}
+			break
+		}
+
 		var p Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Status, &imagesJSON, &categoriesJSON); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Status, &sortValue, &imagesJSON, &categoriesJSON); err != nil {
 			logger.Error("Error: ", err.Error())
-			return nil, utils.MapDbError(err)
+			return nil, nil, utils.MapDbError(err)
 		}
+		lastValue = sortValue
 		// products = append(products, p)
 
 		var (
@@ -244,62 +625,431 @@ func (conn ProductRepository) FindAll(ctx context.Context, filter ProductFilter)
 
 	if err := rows.Err(); err != nil {
 		logger.Error("Error: ", err.Error())
-		return nil, utils.MapDbError(err)
+		return nil, nil, utils.MapDbError(err)
 	}
 
-	return productResponse, nil
+	return productResponse, next, nil
 }
 
-// ********** Implementation FindByID Product**********
-func (conn ProductRepository) FindByID(ctx context.Context, id int64) (*productModel.ProductDetail, error) {
-	var p productModel.ProductDetail
-	query := `SELECT id, name, description, status FROM products WHERE id = $1`
-	err := conn.db.QueryRow(ctx, query, id).Scan(&p.ID, &p.Name, &p.Description, &p.Status)
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, utils.ErrNotFound
-		}
-		return nil, utils.MapDbError(err)
-	}
+// ********** Implementation Count Product**********
+func (conn ProductRepository) CountAll(ctx context.Context, filter ProductFilter) (int64, error) {
+	query := `SELECT COUNT(*) FROM products p`
 
-	// Get Categories
-	catQuery := `SELECT category_id, name FROM category_products WHERE product_id = $1`
-	rows, err := conn.db.Query(ctx, catQuery, id)
-	if err != nil {
-		return nil, err
+	conditions, args := productConditions(filter)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var category productModel.Category
-		if err := rows.Scan(&category.ID, &category.Name); err != nil {
-			return nil, err
-		}
-		p.Categories = append(p.Categories, category)
+
+	var total int64
+	if err := conn.db.Conn(ctx).QueryRow(ctx, query, args...).Scan(&total); err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
 	}
 
-	// Get Images
-	imgQuery := `SELECT id, url, sort_order FROM product_images WHERE product_id = $1 ORDER BY sort_order ASC`
-	imgRows, err := conn.db.Query(ctx, imgQuery, id)
-	if err != nil {
-		return nil, err
+	return total, nil
+}
+
+// productConditions menerjemahkan ProductFilter ke kondisi WHERE atas alias
+// p (products). Dipakai bersama oleh FindAll, CountAll, Search dan StreamExport.
+func productConditions(filter ProductFilter) ([]string, []interface{}) {
+	var args []interface{}
+	var conditions []string
+
+	categoryIDs := filter.CategoryIDs
+	if filter.CategoryID != nil {
+		categoryIDs = append([]int64{*filter.CategoryID}, categoryIDs...)
 	}
-	defer imgRows.Close()
-	for imgRows.Next() {
-		var img productModel.ProductImage
-		img.ProductID = p.ID
-		if err := imgRows.Scan(&img.ID, &img.URL, &img.SortOrder); err != nil {
-			return nil, err
+
+	if len(categoryIDs) > 0 {
+		if filter.CategoryMatchAll {
+			// satu EXISTS per kategori
+			for _, id := range categoryIDs {
+				conditions = append(conditions, categoryCondition(len(args)+1, filter.IncludeDescendants))
+				args = append(args, []int64{id})
+			}
+		} else {
+			conditions = append(conditions, categoryCondition(len(args)+1, filter.IncludeDescendants))
+			args = append(args, categoryIDs)
 		}
-		p.Images = append(p.Images, img)
 	}
 
-	// Get Variants
-	varQuery := `SELECT id, sku, base_unit, stock, cost_price FROM variants WHERE product_id = $1`
-	varRows, err := conn.db.Query(ctx, varQuery, id)
-	if err != nil {
-		return nil, err
+	if filter.Keyword != "" {
+		// full-text (nama, deskripsi, sku, option, barcode) atau potongan kata;
+		// ILIKE memakai index trigram
+		n := len(args) + 1
+		conditions = append(conditions, fmt.Sprintf(
+			"(p.search_vector @@ websearch_to_tsquery('simple', $%d) OR p.name ILIKE $%d OR p.search_terms ILIKE $%d)",
+			n, n+1, n+1))
+		args = append(args, filter.Keyword, "%"+escapeLike(filter.Keyword)+"%")
 	}
-	defer varRows.Close()
+
+	if filter.Status != "" {
+		conditions = append(conditions, fmt.Sprintf("p.status = $%d", len(args)+1))
+		args = append(args, filter.Status)
+	} else if filter.ExcludeArchived {
+		conditions = append(conditions, "p.status <> 'archived'")
+	}
+
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		// batas bawah & atas harus dipenuhi unit yang sama
+		var priceConditions []string
+		if filter.MinPrice != nil {
+			priceConditions = append(priceConditions, fmt.Sprintf("fu.price >= $%d", len(args)+1))
+			args = append(args, *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			priceConditions = append(priceConditions, fmt.Sprintf("fu.price <= $%d", len(args)+1))
+			args = append(args, *filter.MaxPrice)
+		}
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM variants fv
+			JOIN variant_units fu ON fu.variant_id = fv.id
+			WHERE fv.product_id = p.id AND `+strings.Join(priceConditions, " AND ")+`)`)
+	}
+
+	switch filter.StockState {
+	case StockIn:
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM variants fv WHERE fv.product_id = p.id AND fv.stock > 0)")
+	case StockLow:
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM variants fv WHERE fv.product_id = p.id AND fv.stock > 0 AND fv.stock <= $%d)", len(args)+1))
+		args = append(args, filter.LowStockThreshold)
+	case StockOut:
+		conditions = append(conditions, "NOT EXISTS (SELECT 1 FROM variants fv WHERE fv.product_id = p.id AND fv.stock > 0)")
+	}
+
+	if len(filter.Options) > 0 {
+		// urut nama supaya urutan argumen (dan query plan cache) stabil
+		names := make([]string, 0, len(filter.Options))
+		for name := range filter.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var optionConditions []string
+		for _, name := range names {
+			values := make([]string, 0, len(filter.Options[name]))
+			for _, v := range filter.Options[name] {
+				values = append(values, strings.ToLower(v))
+			}
+			optionConditions = append(optionConditions, fmt.Sprintf(
+				"EXISTS (SELECT 1 FROM variant_options fo WHERE fo.variant_id = fv.id AND LOWER(fo.name) = $%d AND LOWER(fo.value) = ANY($%d))",
+				len(args)+1, len(args)+2))
+			args = append(args, strings.ToLower(name), values)
+		}
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM variants fv
+			WHERE fv.product_id = p.id AND `+strings.Join(optionConditions, " AND ")+`)`)
+	}
+
+	if filter.HasBarcode != nil {
+		missing := `EXISTS (SELECT 1 FROM variants fv
+			JOIN variant_units fu ON fu.variant_id = fv.id
+			WHERE fv.product_id = p.id AND COALESCE(fu.barcode, '') = '')`
+		if *filter.HasBarcode {
+			conditions = append(conditions, `NOT `+missing+` AND EXISTS (SELECT 1 FROM variants fv
+				JOIN variant_units fu ON fu.variant_id = fv.id
+				WHERE fv.product_id = p.id)`)
+		} else {
+			conditions = append(conditions, missing)
+		}
+	}
+
+	for _, r := range []struct {
+		column string
+		op     string
+		value  *time.Time
+	}{
+		{"p.created_at", ">=", filter.CreatedFrom},
+		{"p.created_at", "<", filter.CreatedTo},
+		{"p.updated_at", ">=", filter.UpdatedFrom},
+		{"p.updated_at", "<", filter.UpdatedTo},
+	} {
+		if r.value != nil {
+			conditions = append(conditions, fmt.Sprintf("%s %s $%d", r.column, r.op, len(args)+1))
+			args = append(args, *r.value)
+		}
+	}
+
+	return conditions, args
+}
+
+// categoryCondition: product ada di salah satu kategori parameter $n
+// (BIGINT[]), opsional beserta sub-kategorinya
+func categoryCondition(n int, includeDescendants bool) string {
+	if includeDescendants {
+		// UNION (bukan UNION ALL) supaya data lama yang siklik tidak berputar terus
+		return fmt.Sprintf(
+			`EXISTS (SELECT 1 FROM category_products fc WHERE fc.product_id = p.id AND fc.category_id IN (
+				WITH RECURSIVE subtree AS (
+					SELECT id FROM categories WHERE id = ANY($%d)
+					UNION
+					SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+				)
+				SELECT id FROM subtree))`, n)
+	}
+
+	return fmt.Sprintf(
+		"EXISTS (SELECT 1 FROM category_products fc WHERE fc.product_id = p.id AND fc.category_id = ANY($%d))", n)
+}
+
+// escapeLike supaya % dan _ dari input dicari apa adanya
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ********** Implementation Search Product**********
+// Kandidat: cocok full-text, mirip nama (typo, pg_trgm %), atau mirip salah
+// satu sku/option/barcode (<%). Skor = rank full-text + kemiripan trigram.
+func (conn ProductRepository) Search(ctx context.Context, query string, filter ProductFilter) ([]productModel.SearchHit, error) {
+	// Keyword sudah diwakili query
+	filter.Keyword = ""
+	conditions, args := productConditions(filter)
+
+	n := len(args) + 1
+	args = append(args, query)
+	tsq := fmt.Sprintf("websearch_to_tsquery('simple', $%d)", n)
+	raw := fmt.Sprintf("$%d::TEXT", n)
+
+	conditions = append(conditions, fmt.Sprintf(
+		"(p.search_vector @@ %s OR p.name %% %s OR %s <%% p.search_terms)", tsq, raw, raw))
+
+	sql := fmt.Sprintf(`SELECT
+			p.id,
+			p.name,
+			COALESCE(p.description, ''),
+			p.status,
+			ts_rank_cd(p.search_vector, %[1]s)
+				+ similarity(p.name, %[2]s)
+				+ word_similarity(%[2]s, p.search_terms) * 0.5 AS score,
+			ts_headline('simple', p.name, %[1]s, 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+			ts_headline('simple', CONCAT_WS(' ', NULLIF(p.description, ''), NULLIF(p.search_terms, '')), %[1]s,
+				'StartSel=<mark>, StopSel=</mark>, MaxWords=20, MinWords=8, MaxFragments=2')
+		FROM products p
+		WHERE %[3]s
+		ORDER BY score DESC, p.id DESC`, tsq, raw, strings.Join(conditions, " AND "))
+
+	if filter.Limit > 0 {
+		sql += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		sql += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := conn.db.Conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	hits := []productModel.SearchHit{}
+	for rows.Next() {
+		var hit productModel.SearchHit
+		err := rows.Scan(
+			&hit.Product.ID,
+			&hit.Product.Name,
+			&hit.Product.Description,
+			&hit.Product.Status,
+			&hit.Score,
+			&hit.Highlight,
+			&hit.Snippet,
+		)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return nil, utils.MapDbError(err)
+		}
+		hits = append(hits, hit)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	return hits, nil
+}
+
+// ********** Implementation Stream Export**********
+// Satu baris per unit, dibaca langsung dari cursor query tanpa ditampung
+// di memori. Product tanpa varian atau unit tetap diekspor satu baris dengan
+// kolom varian / unit kosong. Limit & offset menghitung product, bukan baris.
+// fn dipanggil untuk setiap baris; error dari fn menghentikan iterasi.
+func (conn ProductRepository) StreamExport(ctx context.Context, filter ProductFilter, fn func(productModel.ExportRow) error) error {
+	conditions, args := productConditions(filter)
+
+	products := `SELECT p.* FROM products p`
+	if len(conditions) > 0 {
+		products += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	products += " ORDER BY p.id"
+
+	if filter.Limit > 0 {
+		products += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		products += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, filter.Offset)
+	}
+
+	query := `WITH RECURSIVE category_paths AS (
+			SELECT id, name::TEXT AS path
+			FROM categories
+			WHERE parent_id IS NULL
+			UNION ALL
+			SELECT c.id, cp.path || ' > ' || c.name
+			FROM categories c
+			JOIN category_paths cp ON cp.id = c.parent_id
+		)
+		SELECT
+			p.id,
+			p.name,
+			COALESCE(p.description, ''),
+			p.status,
+			p.stock_policy,
+			COALESCE(
+			(SELECT ARRAY_AGG(cpath.path ORDER BY cpath.path)
+			FROM category_products pc
+			JOIN category_paths cpath ON cpath.id = pc.category_id
+			WHERE pc.product_id = p.id),
+			'{}'
+			) AS categories,
+			COALESCE(v.id, 0),
+			COALESCE(v.sku, ''),
+			COALESCE(v.base_unit, ''),
+			COALESCE(v.stock, 0),
+			COALESCE(v.cost_price, 0),
+			COALESCE(
+			(SELECT JSONB_AGG(
+				JSONB_BUILD_OBJECT('id', o.id, 'name', o.name, 'value', o.value)
+				ORDER BY o.id)
+			FROM variant_options o
+			WHERE o.variant_id = v.id),
+			'[]'::jsonb
+			) AS options,
+			COALESCE(u.id, 0),
+			COALESCE(u.name, ''),
+			COALESCE(u.conversion_rate, 0),
+			COALESCE(u.price, 0),
+			u.barcode
+		FROM (` + products + `) p
+		LEFT JOIN variants v ON v.product_id = p.id
+		LEFT JOIN variant_units u ON u.variant_id = v.id
+		ORDER BY p.id, v.id, u.conversion_rate, u.id`
+
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var optionsJSON []byte
+	for rows.Next() {
+		var row productModel.ExportRow
+		err := rows.Scan(
+			&row.ProductID,
+			&row.ProductName,
+			&row.Description,
+			&row.Status,
+			&row.StockPolicy,
+			&row.Categories,
+			&row.VariantID,
+			&row.SKU,
+			&row.BaseUnit,
+			&row.Stock,
+			&row.CostPrice,
+			&optionsJSON,
+			&row.UnitID,
+			&row.UnitName,
+			&row.ConversionRate,
+			&row.Price,
+			&row.Barcode,
+		)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		if err := json.Unmarshal(optionsJSON, &row.Options); err != nil {
+			logger.Error(err.Error())
+			return utils.ErrInternal
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	return nil
+}
+
+// ********** Implementation FindByID Product**********
+func (conn ProductRepository) FindByID(ctx context.Context, id int64) (*productModel.ProductDetail, error) {
+	var p productModel.ProductDetail
+	query := `SELECT id, name, description, status, stock_policy, version FROM products WHERE id = $1`
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, id).Scan(&p.ID, &p.Name, &p.Description, &p.Status, &p.StockPolicy, &p.Version)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		return nil, utils.MapDbError(err)
+	}
+
+	// Get Categories
+	catQuery := `SELECT c.id, c.name FROM category_products cp
+		JOIN categories c ON c.id = cp.category_id
+		WHERE cp.product_id = $1`
+	rows, err := conn.db.Conn(ctx).Query(ctx, catQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category productModel.Category
+		if err := rows.Scan(&category.ID, &category.Name); err != nil {
+			return nil, err
+		}
+		p.Categories = append(p.Categories, category)
+	}
+
+	// Get Images
+	imgQuery := `SELECT id, url, COALESCE(content_hash, ''), sort_order FROM product_images WHERE product_id = $1 ORDER BY sort_order ASC`
+	imgRows, err := conn.db.Conn(ctx).Query(ctx, imgQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer imgRows.Close()
+	for imgRows.Next() {
+		var img productModel.ProductImage
+		img.ProductID = p.ID
+		if err := imgRows.Scan(&img.ID, &img.URL, &img.Hash, &img.SortOrder); err != nil {
+			return nil, err
+		}
+		p.Images = append(p.Images, img)
+	}
+	imgRows.Close()
+
+	if err := conn.fillRenditions(ctx, p.Images); err != nil {
+		return nil, err
+	}
+
+	// Get Variants
+	varQuery := `SELECT id, sku, base_unit, stock, cost_price FROM variants WHERE product_id = $1`
+	varRows, err := conn.db.Conn(ctx).Query(ctx, varQuery, id)
+	if err != nil {
+		return nil, err
+	}
+	defer varRows.Close()
 
 	// Temporarily store variants to fetch their options/units
 	for varRows.Next() {
@@ -314,183 +1064,734 @@ func (conn ProductRepository) FindByID(ctx context.Context, id int64) (*productM
 
 	// Fill Variant Options and Units
 	for i := range p.Variants {
-		// Options
-		optQuery := `SELECT name, value FROM variant_options WHERE variant_id = $1`
-		optRows, err := conn.db.Query(ctx, optQuery, p.Variants[i].ID)
-		if err != nil {
+		if err := conn.fillVariant(ctx, &p.Variants[i]); err != nil {
 			return nil, err
 		}
-		for optRows.Next() {
-			var opt productModel.VariantOption
-			if err := optRows.Scan(&opt.Name, &opt.Value); err != nil {
-				optRows.Close()
-				return nil, err
+	}
+
+	return &p, nil
+}
+
+// ********** Implementation Update Product**********
+// p.Version > 0: hanya berhasil jika versi di database masih sama
+// (ErrPreconditionFailed jika sudah diubah orang lain).
+func (conn ProductRepository) Update(ctx context.Context, p *productModel.Product) error {
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		// Update base product
+		payload := productPayload(p)
+		err := tx.QueryRow(ctx, `UPDATE products SET name=$1, description=$2,
+		stock_policy=COALESCE(NULLIF($3, ''), stock_policy), version=version+1, updated_at=NOW()
+		WHERE id=$4 AND ($5::BIGINT = 0 OR version = $5)
+		RETURNING status, stock_policy`,
+			p.Name, p.Description, p.StockPolicy, p.ID, p.Version).Scan(&payload.Status, &payload.StockPolicy)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return versionMismatch(ctx, tx, "products", p.ID)
+			}
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		// Update Categories: Delete all and re-insert
+		_, err = tx.Exec(ctx,
+			`INSERT INTO category_products (product_id, category_id) 
+		 SELECT $1, unnest($2::bigint[]) EXCEPT SELECT product_id, category_id FROM category_products
+		 WHERE product_id = $1`, p.ID, p.CategoryId)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+		_, err = tx.Exec(ctx,
+			`DELETE FROM category_products 
+		 WHERE product_id = $1 AND category_id NOT IN (SELECT unnest($2::bigint[]))`,
+			p.ID, p.CategoryId)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		// Update Images
+		if len(p.Images) > 0 {
+			err = conn.UpdateImage(ctx, p.ID, p.Images)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+		}
+
+		// Update Variants: nil berarti varian tidak ikut diubah,
+		// slice kosong berarti semua varian dihapus
+		if p.Variants != nil {
+			if err := conn.updateVariants(ctx, p.ID, p.Variants); err != nil {
+				return err
 			}
-			p.Variants[i].Options = append(p.Variants[i].Options, opt)
 		}
-		optRows.Close()
 
-		// Units
-		unitQuery := `SELECT id, name, barcode, conversion_rate, price FROM variant_units WHERE variant_id = $1`
-		unitRows, err := conn.db.Query(ctx, unitQuery, p.Variants[i].ID)
+		return outboxrepo.Append(ctx, tx, eventModel.ProductUpdated, eventModel.AggregateProduct, p.ID, payload)
+	})
+}
+
+// ********** Implementation Update Product Images**********
+// Hanya gambar yang di-diff (seperti Update); nama, deskripsi, kategori dan
+// varian tidak disentuh. Versi product tetap naik dan dicek seperti Update.
+// Hasilnya gambar yang benar-benar dilepas (dihapus atau file-nya diganti)
+// setelah transaksi commit, supaya pemanggil hanya menghapus file itu.
+func (conn ProductRepository) UpdateProductImages(ctx context.Context, productID, version int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error) {
+	var removed []productModel.ProductImage
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		payload := eventModel.ProductPayload{ID: productID}
+		err := tx.QueryRow(ctx, `UPDATE products SET version=version+1, updated_at=NOW()
+		WHERE id=$1 AND ($2::BIGINT = 0 OR version = $2)
+		RETURNING name, COALESCE(description, ''), status, stock_policy,
+			ARRAY(SELECT category_id FROM category_products WHERE product_id = $1 ORDER BY category_id)`,
+			productID, version).Scan(&payload.Name, &payload.Description, &payload.Status, &payload.StockPolicy, &payload.CategoryIDs)
 		if err != nil {
+			if err == pgx.ErrNoRows {
+				return versionMismatch(ctx, tx, "products", productID)
+			}
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		removed, err = conn.applyImageChanges(ctx, productID, changes)
+		if err != nil {
+			return err
+		}
+
+		return outboxrepo.Append(ctx, tx, eventModel.ProductUpdated, eventModel.AggregateProduct, productID, payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// applyImageChanges menerapkan add/replace/delete pada gambar product.
+// Id gambar yang bukan milik product ditolak dengan ErrNotFound.
+func (conn ProductRepository) applyImageChanges(ctx context.Context, productID int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error) {
+	tx := conn.db.Conn(ctx)
+
+	current, err := conn.GetImageByProductId(ctx, productID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, err
+	}
+	oldMap := make(map[int64]productModel.ProductImage)
+	for _, img := range current {
+		oldMap[img.ID] = img
+	}
+
+	var removed []productModel.ProductImage
+	for _, id := range changes.Delete {
+		old, ok := oldMap[id]
+		if !ok {
+			return nil, utils.ErrNotFound
+		}
+		_, err = tx.Exec(ctx, `DELETE FROM product_images WHERE id=$1`, id)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return nil, utils.MapDbError(err)
+		}
+		if err := conn.releaseImageBlob(ctx, old.Hash); err != nil {
 			return nil, err
 		}
-		for unitRows.Next() {
-			var u productModel.VariantUnit
-			u.VariantID = p.Variants[i].ID
-			if err := unitRows.Scan(&u.ID, &u.Name, &u.Barcode, &u.ConversionRate, &u.Price); err != nil {
-				unitRows.Close()
+		delete(oldMap, id)
+		removed = append(removed, old)
+	}
+
+	for _, img := range changes.Upsert {
+		if img.ID == 0 {
+			if _, err := conn.insertImage(ctx, productID, img); err != nil {
 				return nil, err
 			}
-			p.Variants[i].Units = append(p.Variants[i].Units, u)
+			continue
+		}
+
+		old, ok := oldMap[img.ID]
+		if !ok {
+			return nil, utils.ErrNotFound
+		}
+		if err := conn.retainImageBlob(ctx, img.Hash); err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec(ctx, `UPDATE product_images SET url=$1, content_hash=NULLIF($2, ''), sort_order=$3 WHERE id=$4`,
+			img.URL, img.Hash, img.SortOrder, img.ID)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return nil, utils.MapDbError(err)
+		}
+		if err := conn.releaseImageBlob(ctx, old.Hash); err != nil {
+			return nil, err
 		}
-		unitRows.Close()
+		if err := conn.replaceRenditions(ctx, img.ID, img.Renditions); err != nil {
+			return nil, err
+		}
+		removed = append(removed, old)
 	}
+	return removed, nil
+}
 
-	return &p, nil
+// versionMismatch dipanggil saat UPDATE ... AND version = $n tidak mengenai
+// baris: bedakan row yang memang tidak ada dengan versi yang sudah berubah.
+func versionMismatch(ctx context.Context, tx txmanager.DBTX, table string, id int64) error {
+	var exists bool
+	err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	if !exists {
+		return utils.ErrNotFound
+	}
+	return utils.ErrPreconditionFailed
 }
 
-// ********** Implementation Update Product**********
-func (conn ProductRepository) Update(ctx context.Context, p *productModel.Product) error {
+// touchProduct menaikkan versi product saat bagian product (varian) diubah
+// lewat endpoint lain, supaya PUT product dengan ETag lama ditolak.
+func touchProduct(ctx context.Context, tx txmanager.DBTX, productID int64) error {
+	_, err := tx.Exec(ctx, `UPDATE products SET version = version + 1, updated_at = NOW() WHERE id = $1`, productID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// productPayload isi event product dari model; status & stock policy
+// diisi pemanggil dari hasil query
+func productPayload(p *productModel.Product) eventModel.ProductPayload {
+	payload := eventModel.ProductPayload{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+	}
+	for _, cid := range p.CategoryId {
+		if cid != nil {
+			payload.CategoryIDs = append(payload.CategoryIDs, *cid)
+		}
+	}
+	return payload
+}
+
+// updateVariants diff varian product berdasarkan ID seperti UpdateImage:
+// ID 0 di-insert, ID lama di-update (beserta option & unit), yang tidak
+// dikirim dihapus (ditolak jika masih punya stok atau riwayat stok).
+func (conn ProductRepository) updateVariants(ctx context.Context, productID int64, variants []productModel.Variant) error {
+	keep := make([]int64, 0, len(variants))
+	for _, v := range variants {
+		if v.ID != 0 {
+			keep = append(keep, v.ID)
+		}
+	}
 
-	tx, err := conn.db.Begin(ctx)
+	tx := conn.db.Conn(ctx)
+	removed, err := lockVariants(ctx, tx,
+		`SELECT id FROM variants WHERE product_id = $1 AND NOT (id = ANY($2::bigint[])) FOR UPDATE`,
+		productID, keep)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback(ctx)
+	if err := deleteUnusedVariants(ctx, tx, removed); err != nil {
+		return err
+	}
+
+	for _, v := range variants {
+		if v.ID == 0 {
+			if _, err := conn.AddVariant(ctx, v, productID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		v.ProductID = productID
+		if err := conn.updateVariant(ctx, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ********** Implementation Update Image**********
+func (conn ProductRepository) UpdateImage(ctx context.Context, productId int64, images []productModel.ProductImage) error {
+	tx := conn.db.Conn(ctx)
 
-	// Update base product
-	_, err = tx.Exec(ctx, `UPDATE products SET name=$1, description=$2, updated_at=NOW() WHERE id=$3`,
-		p.Name, p.Description, p.ID)
+	oldImages, err := conn.GetImageByProductId(ctx, productId)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
 		return utils.MapDbError(err)
 	}
 
-	// Update Categories: Delete all and re-insert
-	_, err = tx.Exec(ctx,
-		`INSERT INTO category_products (product_id, category_id) 
-		 SELECT $1, unnest($2::bigint[]) EXCEPT SELECT product_id, category_id FROM category_products
-		 WHERE product_id = $1`, p.ID, p.CategoryId)
+	oldMap := make(map[int64]productModel.ProductImage)
+	for _, img := range oldImages {
+		oldMap[img.ID] = img
+	}
+	newMap := make(map[int64]productModel.ProductImage)
+	for _, img := range images {
+		newMap[img.ID] = img
+	}
+
+	for _, img := range oldMap {
+		if _, ok := newMap[img.ID]; !ok {
+			_, err = tx.Exec(ctx, `DELETE FROM product_images WHERE id=$1`, img.ID)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+			if err := conn.releaseImageBlob(ctx, img.Hash); err != nil {
+				return err
+			}
+		}
+	}
+	for _, img := range newMap {
+		if _, ok := oldMap[img.ID]; !ok {
+			if _, err := conn.insertImage(ctx, productId, img); err != nil {
+				return err
+			}
+		} else {
+			old := oldMap[img.ID]
+
+			// gambar diganti: referensi file lama dilepas, rendition lama dibuang
+			if img.URL != old.URL {
+				if err := conn.retainImageBlob(ctx, img.Hash); err != nil {
+					return err
+				}
+			} else {
+				img.Hash = old.Hash
+			}
+
+			_, err = tx.Exec(ctx, `UPDATE product_images SET url=$1, content_hash=NULLIF($2, ''), sort_order=$3 WHERE id=$4`,
+				img.URL, img.Hash, img.SortOrder, img.ID)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+
+			if img.URL != old.URL {
+				if err := conn.releaseImageBlob(ctx, old.Hash); err != nil {
+					return err
+				}
+				if err := conn.replaceRenditions(ctx, img.ID, img.Renditions); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ********** Implementation Get Image By Product ID**********
+func (conn ProductRepository) GetImageByProductId(ctx context.Context, productId int64) ([]productModel.ProductImage, error) {
+	query := `SELECT id, url, COALESCE(content_hash, ''), sort_order FROM product_images WHERE product_id = $1`
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, productId)
+	if err != nil {
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+	var images []productModel.ProductImage
+	for rows.Next() {
+		var img productModel.ProductImage
+		if err := rows.Scan(&img.ID, &img.URL, &img.Hash, &img.SortOrder); err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		images = append(images, img)
+	}
+	rows.Close()
+
+	if err := conn.fillRenditions(ctx, images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ***** Implementation Get Image By ID ******
+func (conn ProductRepository) GetImageById(ctx context.Context, id int64) (*productModel.ProductImage, error) {
+	query := `SELECT id, product_id, url, COALESCE(content_hash, ''), sort_order FROM product_images WHERE id = $1`
+	var img productModel.ProductImage
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, id).Scan(&img.ID, &img.ProductID, &img.URL, &img.Hash, &img.SortOrder)
+	if err != nil {
+		return nil, utils.MapDbError(err)
+	}
+
+	images := []productModel.ProductImage{img}
+	if err := conn.fillRenditions(ctx, images); err != nil {
+		return nil, err
+	}
+	return &images[0], nil
+}
+
+// insertImage menyimpan satu gambar beserta rendition-nya
+func (conn ProductRepository) insertImage(ctx context.Context, productID int64, img productModel.ProductImage) (int64, error) {
+	if err := conn.retainImageBlob(ctx, img.Hash); err != nil {
+		return 0, err
+	}
+
+	var imageID int64
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO product_images (product_id, url, content_hash, sort_order) VALUES ($1, $2, NULLIF($3, ''), $4) RETURNING id`,
+		productID, img.URL, img.Hash, img.SortOrder,
+	).Scan(&imageID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
+	}
+
+	if err := conn.replaceRenditions(ctx, imageID, img.Renditions); err != nil {
+		return 0, err
+	}
+	return imageID, nil
+}
+
+func (conn ProductRepository) replaceRenditions(ctx context.Context, imageID int64, renditions []productModel.ImageRendition) error {
+	tx := conn.db.Conn(ctx)
+
+	_, err := tx.Exec(ctx, `DELETE FROM product_image_renditions WHERE image_id = $1`, imageID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	for _, r := range renditions {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO product_image_renditions (image_id, name, url, width, height, byte_size)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			imageID, r.Name, r.URL, r.Width, r.Height, r.ByteSize,
+		)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+	}
+	return nil
+}
+
+// fillRenditions mengisi rendition untuk semua gambar dalam satu query
+func (conn ProductRepository) fillRenditions(ctx context.Context, images []productModel.ProductImage) error {
+	if len(images) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(images))
+	index := make(map[int64]int, len(images))
+	for i, img := range images {
+		ids[i] = img.ID
+		index[img.ID] = i
+	}
+
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`SELECT image_id, name, url, width, height, byte_size
+		FROM product_image_renditions
+		WHERE image_id = ANY($1::bigint[])
+		ORDER BY image_id, byte_size DESC`, ids)
+	if err != nil {
+		return utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			imageID int64
+			r       productModel.ImageRendition
+		)
+		if err := rows.Scan(&imageID, &r.Name, &r.URL, &r.Width, &r.Height, &r.ByteSize); err != nil {
+			return utils.MapDbError(err)
+		}
+		i := index[imageID]
+		images[i].Renditions = append(images[i].Renditions, r)
+	}
+	return rows.Err()
+}
+
+// retainImageBlob menambah referensi ke file gambar
+func (conn ProductRepository) retainImageBlob(ctx context.Context, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`INSERT INTO image_blobs (hash, ref_count) VALUES ($1, 1)
+		ON CONFLICT (hash) DO UPDATE SET ref_count = image_blobs.ref_count + 1, updated_at = NOW()`,
+		hash)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// releaseImageBlob mengurangi referensi ke file gambar. Baris image_blobs
+// dibiarkan walau ref_count 0, dihapus lewat PurgeImageBlob.
+func (conn ProductRepository) releaseImageBlob(ctx context.Context, hash string) error {
+	if hash == "" {
+		return nil
+	}
+
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE image_blobs SET ref_count = ref_count - 1, updated_at = NOW()
+		WHERE hash = $1 AND ref_count > 0`,
+		hash)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
 		return utils.MapDbError(err)
 	}
-	_, err = tx.Exec(ctx,
-		`DELETE FROM category_products 
-		 WHERE product_id = $1 AND category_id NOT IN (SELECT unnest($2::bigint[]))`,
-		p.ID, p.CategoryId)
+	return nil
+}
+
+// ********** Implementation List Image URLs**********
+func (conn ProductRepository) ListImageURLs(ctx context.Context) ([]string, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`SELECT url FROM product_images
+		UNION
+		SELECT url FROM product_image_renditions`)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
-		return utils.MapDbError(err)
+		return nil, utils.MapDbError(err)
 	}
+	defer rows.Close()
 
-	// Update Images
-	if len(p.Images) > 0 {
-		err = conn.UpdateImage(ctx, p.ID, p.Images, tx)
-		if err != nil {
-			logger.Error("Error: ", err.Error())
-			return utils.MapDbError(err)
+	var urls []string
+	for rows.Next() {
+		var url string
+		if err := rows.Scan(&url); err != nil {
+			return nil, utils.MapDbError(err)
 		}
+		urls = append(urls, url)
 	}
+	return urls, rows.Err()
+}
 
-	return tx.Commit(ctx)
+// ********** Implementation Purge Image Blob**********
+func (conn ProductRepository) PurgeImageBlob(ctx context.Context, hash string) (bool, error) {
+	tag, err := conn.db.Conn(ctx).Exec(ctx,
+		`DELETE FROM image_blobs WHERE hash = $1 AND ref_count = 0`, hash)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return false, utils.MapDbError(err)
+	}
+	return tag.RowsAffected() == 1, nil
 }
 
-// ********** Implementation Update Image**********
-func (conn ProductRepository) UpdateImage(ctx context.Context, productId int64, images []productModel.ProductImage, tx pgx.Tx) error {
+// ********** Implementation Find By Barcode**********
+// Cari unit berdasarkan variant_units.barcode, fallback ke variants.sku
+// (unit dengan conversion_rate terkecil). Dibuat satu query karena
+// dipanggil di setiap scan kasir.
+func (conn ProductRepository) FindByBarcode(ctx context.Context, code string) (*productModel.ScanItem, error) {
+	query := `WITH hit AS (
+			(SELECT vu.id AS unit_id, vu.variant_id, 'barcode' AS matched_by, 1 AS priority
+			FROM variant_units vu
+			WHERE vu.barcode = $1)
+			UNION ALL
+			(SELECT u.id, v.id, 'sku', 2
+			FROM variants v
+			JOIN LATERAL (
+				SELECT id FROM variant_units
+				WHERE variant_id = v.id
+				ORDER BY conversion_rate ASC, id ASC
+				LIMIT 1
+			) u ON TRUE
+			WHERE v.sku = $1)
+			ORDER BY priority
+			LIMIT 1
+		)
+		SELECT
+			p.id,
+			p.name,
+			p.status,
+			v.id,
+			COALESCE(v.sku, ''),
+			v.base_unit,
+			v.stock,
+			v.cost_price,
+			u.id,
+			u.name,
+			u.conversion_rate,
+			u.price,
+			hit.matched_by,
+			COALESCE(
+			(SELECT JSONB_AGG(
+				JSONB_BUILD_OBJECT('id', o.id, 'name', o.name, 'value', o.value)
+				ORDER BY o.id)
+			FROM variant_options o
+			WHERE o.variant_id = v.id),
+			'[]'::jsonb
+			) AS options
+		FROM hit
+		JOIN variant_units u ON u.id = hit.unit_id
+		JOIN variants v ON v.id = hit.variant_id
+		JOIN products p ON p.id = v.product_id`
 
-	oldImages, err := conn.GetImageByProductId(ctx, productId)
+	var (
+		item        productModel.ScanItem
+		optionsJSON []byte
+	)
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, code).Scan(
+		&item.ProductID,
+		&item.ProductName,
+		&item.ProductStatus,
+		&item.Variant.ID,
+		&item.Variant.SKU,
+		&item.Variant.BaseUnit,
+		&item.Variant.Stock,
+		&item.Variant.CostPrice,
+		&item.UnitID,
+		&item.UnitName,
+		&item.ConversionRate,
+		&item.Price,
+		&item.MatchedBy,
+		&optionsJSON,
+	)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
 		logger.Error("Error: ", err.Error())
-		return utils.MapDbError(err)
+		return nil, utils.MapDbError(err)
 	}
 
-	oldMap := make(map[int64]productModel.ProductImage)
-	for _, img := range oldImages {
-		oldMap[img.ID] = img
+	item.Variant.ProductID = item.ProductID
+	if err := json.Unmarshal(optionsJSON, &item.Variant.Options); err != nil {
+		logger.Error(err.Error())
+		return nil, utils.ErrInternal
 	}
-	newMap := make(map[int64]productModel.ProductImage)
-	for _, img := range images {
-		newMap[img.ID] = img
+
+	return &item, nil
+}
+
+// ********** Implementation Find Used Codes**********
+func (conn ProductRepository) FindUsedCodes(ctx context.Context, skus, barcodes []string) ([]string, []string, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`SELECT 'sku', sku FROM variants WHERE sku = ANY($1::text[])
+		UNION ALL
+		SELECT 'barcode', barcode FROM variant_units WHERE barcode = ANY($2::text[])`,
+		skus, barcodes)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, nil, utils.MapDbError(err)
 	}
+	defer rows.Close()
 
-	for _, img := range oldMap {
-		if _, ok := newMap[img.ID]; !ok {
-			_, err = tx.Exec(ctx, `DELETE FROM product_images WHERE id=$1`, img.ID)
-			if err != nil {
-				logger.Error("Error: ", err.Error())
-				return utils.MapDbError(err)
-			}
+	var usedSKUs, usedBarcodes []string
+	for rows.Next() {
+		var kind, code string
+		if err := rows.Scan(&kind, &code); err != nil {
+			return nil, nil, utils.MapDbError(err)
 		}
-	}
-	for _, img := range newMap {
-		if _, ok := oldMap[img.ID]; !ok {
-			_, err = tx.Exec(ctx, `INSERT INTO product_images (product_id, url, sort_order) VALUES ($1, $2, $3)`, productId, img.URL, img.SortOrder)
-			if err != nil {
-				logger.Error("Error: ", err.Error())
-				return utils.MapDbError(err)
-			}
+		if kind == "sku" {
+			usedSKUs = append(usedSKUs, code)
 		} else {
-			_, err = tx.Exec(ctx, `UPDATE product_images SET url=$1, sort_order=$2 WHERE id=$3`, img.URL, img.SortOrder, img.ID)
-			if err != nil {
-				logger.Error("Error: ", err.Error())
-				return utils.MapDbError(err)
+			usedBarcodes = append(usedBarcodes, code)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, utils.MapDbError(err)
+	}
+
+	return usedSKUs, usedBarcodes, nil
+}
+
+// ********** Implementation Change Status Product**********
+// Status dibaca dengan FOR UPDATE supaya dua transisi bersamaan tidak sama-sama
+// lolos validasi state machine.
+func (conn ProductRepository) ChangeStatus(ctx context.Context, id int64, transition productModel.Transition, actor productModel.Actor) (*productModel.StatusChange, error) {
+	change := &productModel.StatusChange{ProductID: id, Transition: transition, ChangedBy: actor}
+
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		err := tx.QueryRow(ctx, `SELECT status FROM products WHERE id = $1 FOR UPDATE`, id).Scan(&change.FromStatus)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return utils.ErrNotFound
 			}
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		to, ok := transition.Apply(change.FromStatus)
+		if !ok {
+			return utils.ErrInvalidTransition
+		}
+		change.ToStatus = to
+
+		_, err = tx.Exec(ctx, `UPDATE products SET status = $2, version = version + 1, updated_at = NOW() WHERE id = $1`, id, to)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
 		}
+
+		err = tx.QueryRow(ctx,
+			`INSERT INTO product_status_history (product_id, transition, from_status, to_status, changed_by, changed_by_name)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+			id, string(transition), change.FromStatus, to, actor.UserID, actor.Name,
+		).Scan(&change.ID, &change.ChangedAt)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		eventType := eventModel.ProductUpdated
+		if to == productModel.StatusArchived {
+			eventType = eventModel.ProductArchived
+		}
+
+		return outboxrepo.Append(ctx, tx, eventType, eventModel.AggregateProduct, id,
+			eventModel.ProductPayload{ID: id, Status: to})
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+
+	return change, nil
 }
 
-// ********** Implementation Get Image By Product ID**********
-func (conn ProductRepository) GetImageByProductId(ctx context.Context, productId int64) ([]productModel.ProductImage, error) {
-	query := `SELECT id, url, sort_order FROM product_images WHERE product_id = $1`
-	rows, err := conn.db.Query(ctx, query, productId)
+// ********** Implementation Status History Product**********
+func (conn ProductRepository) StatusHistory(ctx context.Context, productID int64) ([]productModel.StatusChange, error) {
+	query := `SELECT id, product_id, transition, from_status, to_status, changed_by, changed_by_name, created_at
+		FROM product_status_history
+		WHERE product_id = $1
+		ORDER BY id DESC`
+
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, productID)
 	if err != nil {
+		logger.Error("Error: ", err.Error())
 		return nil, utils.MapDbError(err)
 	}
 	defer rows.Close()
-	var images []productModel.ProductImage
+
+	history := []productModel.StatusChange{}
 	for rows.Next() {
-		var img productModel.ProductImage
-		if err := rows.Scan(&img.ID, &img.URL, &img.SortOrder); err != nil {
+		var (
+			c          productModel.StatusChange
+			transition string
+		)
+		if err := rows.Scan(&c.ID, &c.ProductID, &transition, &c.FromStatus, &c.ToStatus,
+			&c.ChangedBy.UserID, &c.ChangedBy.Name, &c.ChangedAt); err != nil {
+			logger.Error("Error: ", err.Error())
 			return nil, utils.MapDbError(err)
 		}
-		images = append(images, img)
+		c.Transition = productModel.Transition(transition)
+		history = append(history, c)
 	}
-	return images, nil
-}
 
-// ***** Implementation Get Image By ID ******
-func (conn ProductRepository) GetImageById(ctx context.Context, id int64) (string, error) {
-	query := `SELECT url FROM product_images WHERE id = $1`
-	var url string
-	err := conn.db.QueryRow(ctx, query, id).Scan(&url)
-	if err != nil {
-		return url, utils.MapDbError(err)
+	if err := rows.Err(); err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
 	}
-	return url, nil
-}
 
-// ********** Implementation Delete Product**********
-func (conn ProductRepository) Delete(ctx context.Context, id int64) error {
-	query := `UPDATE products SET status = 'archived', updated_at = NOW() WHERE id = $1`
-	_, err := conn.db.Exec(ctx, query, id)
-	if err != nil {
-		return utils.MapDbError(err)
-	}
-	return nil
+	return history, nil
 }
 
 // ===========================================
 // Category Repository
 // ===========================================
 type CategoryRepository struct {
-	db *pgx.Conn
+	db *txmanager.Manager
 }
 
-func NewCategoryRepsitory(db *pgx.Conn) *CategoryRepository {
+func NewCategoryRepsitory(db *txmanager.Manager) *CategoryRepository {
 	return &CategoryRepository{
 		db: db,
 	}
@@ -501,55 +1802,279 @@ func (conn CategoryRepository) CreateCategory(ctx context.Context, c *productMod
 	query := `INSERT INTO categories (name, parent_id) VALUES ($1, $2) RETURNING id`
 
 	var id int64
-	err := conn.db.QueryRow(ctx, query, c.Name, c.ParentID).Scan(&id)
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		err := tx.QueryRow(ctx, query, c.Name, c.ParentID).Scan(&id)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		return outboxrepo.Append(ctx, tx, eventModel.CategoryCreated, eventModel.AggregateCategory, id,
+			eventModel.CategoryPayload{ID: id, Name: c.Name, ParentID: c.ParentID})
+	})
 	if err != nil {
-		logger.Error("Error: ", err.Error())
-		return 0, utils.MapDbError(err)
+		return 0, err
 	}
 	return id, nil
 }
 
 // ********** Implementation Get Category By Id**********
 func (conn CategoryRepository) FindCategory(ctx context.Context, id int64) (*productModel.Category, error) {
-	query := `SELECT id, name, parent_id FROM categories WHERE id = $1`
+	query := `SELECT id, name, parent_id, version FROM categories WHERE id = $1`
 	var category productModel.Category
-	err := conn.db.QueryRow(ctx, query, id).Scan(&category.ID, &category.Name, &category.ParentID)
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, id).Scan(&category.ID, &category.Name, &category.ParentID, &category.Version)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
 		logger.Error("Error: ", err.Error())
 		return nil, utils.MapDbError(err)
 	}
 	return &category, nil
 }
 
+// categoryTreeLock kunci advisory untuk perubahan parent_id kategori
+const categoryTreeLock = 7301
+
 // ********** Implementation Update Category**********
+// c.Version > 0: hanya berhasil jika versi di database masih sama.
 func (conn CategoryRepository) UpdateCategory(ctx context.Context, c *productModel.Category) error {
-	query := `UPDATE categories SET name = $2, parent_id = $3, updated_at = $4 WHERE id = $1`
+	query := `UPDATE categories SET name = $2, parent_id = $3, updated_at = $4, version = version + 1
+		WHERE id = $1 AND ($5::BIGINT = 0 OR version = $5)`
 
-	_, err := conn.db.Exec(ctx, query, c.ID, c.Name, c.ParentID, time.Now())
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
 
-	if err != nil {
+		if c.ParentID != nil {
+			if err := conn.checkCycle(ctx, c.ID, *c.ParentID); err != nil {
+				return err
+			}
+		}
+
+		tag, err := tx.Exec(ctx, query, c.ID, c.Name, c.ParentID, time.Now(), c.Version)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+		if tag.RowsAffected() == 0 {
+			// tanpa versi: perilaku lama, id tidak ada diabaikan
+			if c.Version == 0 {
+				return nil
+			}
+			return versionMismatch(ctx, tx, "categories", c.ID)
+		}
+
+		return outboxrepo.Append(ctx, tx, eventModel.CategoryUpdated, eventModel.AggregateCategory, c.ID,
+			eventModel.CategoryPayload{ID: c.ID, Name: c.Name, ParentID: c.ParentID})
+	})
+}
+
+// checkCycle menolak parent baru yang ternyata kategori itu sendiri atau
+// turunannya. Re-parent diserialisasi lewat advisory lock supaya dua update
+// bersamaan (A ke bawah B, B ke bawah A) tidak lolos pengecekan.
+func (conn CategoryRepository) checkCycle(ctx context.Context, id, parentID int64) error {
+	if id == parentID {
+		return utils.ErrCategoryCycle
+	}
+
+	tx := conn.db.Conn(ctx)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryTreeLock); err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	// telusuri leluhur parent baru, siklus jika id ada di jalur itu
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM categories WHERE id = $1
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`
+
+	var cycle bool
+	if err := tx.QueryRow(ctx, query, parentID, id).Scan(&cycle); err != nil {
 		logger.Error("Error: ", err.Error())
 		return utils.MapDbError(err)
 	}
+	if cycle {
+		return utils.ErrCategoryCycle
+	}
+
 	return nil
 }
 
 // ********** Implementation Delete Category**********
-func (conn CategoryRepository) DeleteCategory(ctx context.Context, id int64) error {
-	query := `DELETE FROM categories WHERE id = $1`
+func (conn CategoryRepository) DeleteCategory(ctx context.Context, id int64, opt productModel.CategoryDelete) (*productModel.CategoryBlockers, error) {
+	var blockers *productModel.CategoryBlockers
+
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		// sub-kategori akan dipindah, serialisasi dengan re-parent lain
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, categoryTreeLock); err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		var parentID *int64
+		err := tx.QueryRow(ctx, `SELECT parent_id FROM categories WHERE id = $1 FOR UPDATE`, id).Scan(&parentID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return utils.ErrNotFound
+			}
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		used, err := conn.categoryUsage(ctx, id)
+		if err != nil {
+			return err
+		}
+		inUse := len(used.Children) > 0 || used.ProductCount > 0
+
+		// kategori tujuan product
+		var productTarget *int64
+		switch opt.Strategy {
+		case productModel.CategoryDeleteForbid:
+			if inUse {
+				blockers = used
+				return utils.ErrConflict
+			}
+
+		case productModel.CategoryDeleteReparent:
+			// kategori root tidak punya parent untuk menampung product
+			if parentID == nil && used.ProductCount > 0 {
+				blockers = &productModel.CategoryBlockers{Children: []int64{}, Products: used.Products, ProductCount: used.ProductCount}
+				return utils.ErrConflict
+			}
+			productTarget = parentID
+
+		case productModel.CategoryDeleteMove:
+			if opt.MoveProductsTo == nil || *opt.MoveProductsTo == id {
+				return utils.ErrBadRequest
+			}
+			var exists bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM categories WHERE id = $1)`, *opt.MoveProductsTo).Scan(&exists)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+			if !exists {
+				return utils.ErrBadRequest
+			}
+			productTarget = opt.MoveProductsTo
+
+		default:
+			return utils.ErrBadRequest
+		}
+
+		if len(used.Children) > 0 {
+			_, err := tx.Exec(ctx, `UPDATE categories SET parent_id = $2, version = version + 1, updated_at = NOW() WHERE parent_id = $1`, id, parentID)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+			for _, childID := range used.Children {
+				if err := conn.appendCategoryUpdated(ctx, childID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if used.ProductCount > 0 && productTarget != nil {
+			// link lama ikut terhapus lewat ON DELETE CASCADE
+			_, err := tx.Exec(ctx,
+				`INSERT INTO category_products (product_id, category_id)
+				SELECT cp.product_id, $2
+				FROM category_products cp
+				WHERE cp.category_id = $1
+				AND NOT EXISTS (
+					SELECT 1 FROM category_products t
+					WHERE t.product_id = cp.product_id AND t.category_id = $2
+				)
+				GROUP BY cp.product_id`,
+				id, *productTarget)
+			if err != nil {
+				logger.Error("Error: ", err.Error())
+				return utils.MapDbError(err)
+			}
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM categories WHERE id = $1`, id); err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		return outboxrepo.Append(ctx, tx, eventModel.CategoryDeleted, eventModel.AggregateCategory, id,
+			eventModel.CategoryPayload{ID: id})
+	})
+	if err != nil {
+		return blockers, err
+	}
+
+	return nil, nil
+}
+
+// categoryUsage sub-kategori langsung dan product yang masih memakai kategori
+func (conn CategoryRepository) categoryUsage(ctx context.Context, id int64) (*productModel.CategoryBlockers, error) {
+	tx := conn.db.Conn(ctx)
+	usage := &productModel.CategoryBlockers{Children: []int64{}, Products: []int64{}}
+
+	rows, err := tx.Query(ctx, `SELECT id FROM categories WHERE parent_id = $1 ORDER BY id`, id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	usage.Children, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	err = tx.QueryRow(ctx, `SELECT COUNT(DISTINCT product_id) FROM category_products WHERE category_id = $1`, id).
+		Scan(&usage.ProductCount)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT DISTINCT product_id FROM category_products WHERE category_id = $1 ORDER BY product_id LIMIT $2`,
+		id, productModel.CategoryBlockerLimit)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	usage.Products, err = pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
 
-	_, err := conn.db.Exec(ctx, query, id)
+	return usage, nil
+}
+
+// appendCategoryUpdated mencatat event category.updated dengan data terbaru
+func (conn CategoryRepository) appendCategoryUpdated(ctx context.Context, id int64) error {
+	tx := conn.db.Conn(ctx)
+
+	var payload eventModel.CategoryPayload
+	err := tx.QueryRow(ctx, `SELECT id, name, parent_id FROM categories WHERE id = $1`, id).
+		Scan(&payload.ID, &payload.Name, &payload.ParentID)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
 		return utils.MapDbError(err)
 	}
-	return nil
+
+	return outboxrepo.Append(ctx, tx, eventModel.CategoryUpdated, eventModel.AggregateCategory, id, payload)
 }
 
 // ********** Implementation Get list Category**********
 func (conn CategoryRepository) FindAllCategory(ctx context.Context) ([]productModel.Category, error) {
 	query := `SELECT id, name, parent_id FROM categories`
-	rows, err := conn.db.Query(ctx, query)
+	rows, err := conn.db.Conn(ctx).Query(ctx, query)
 	if err != nil {
 		logger.Error("Error: ", err.Error())
 		return nil, utils.MapDbError(err)
@@ -572,3 +2097,50 @@ func (conn CategoryRepository) FindAllCategory(ctx context.Context) ([]productMo
 	}
 	return categories, nil
 }
+
+// ********** Implementation Get Category By Parent**********
+func (conn CategoryRepository) FindCategoryByParent(ctx context.Context, id int64) ([]productModel.Category, error) {
+	query := `SELECT id, name, parent_id FROM categories WHERE parent_id = $1 ORDER BY name`
+	return conn.queryCategories(ctx, query, id)
+}
+
+// ********** Implementation Get Category Ancestors**********
+func (conn CategoryRepository) FindCategoryAncestors(ctx context.Context, id int64) ([]productModel.Category, error) {
+	query := `WITH RECURSIVE ancestors AS (
+			SELECT c.id, c.name, c.parent_id, 0 AS depth
+			FROM categories c
+			WHERE c.id = (SELECT parent_id FROM categories WHERE id = $1)
+			UNION
+			SELECT c.id, c.name, c.parent_id, a.depth + 1
+			FROM categories c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < 100
+		)
+		SELECT id, name, parent_id FROM ancestors ORDER BY depth DESC`
+	return conn.queryCategories(ctx, query, id)
+}
+
+func (conn CategoryRepository) queryCategories(ctx context.Context, query string, args ...interface{}) ([]productModel.Category, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	categories := []productModel.Category{}
+	for rows.Next() {
+		var category productModel.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.ParentID); err != nil {
+			logger.Error("Error: ", err.Error())
+			return nil, utils.MapDbError(err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return categories, nil
+}
@@ -2,50 +2,137 @@ package productrepo
 
 import (
 	"context"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
 )
 
 type ProductFilter struct {
-	Keyword    string
-	CategoryID *int64
-	Status     string // active/inactive/archived
-	Limit      int
-	Offset     int
+	Keyword            string
+	CategoryID         *int64
+	CategoryIDs        []int64             // digabung dengan CategoryID
+	CategoryMatchAll   bool                // true = harus ada di semua kategori, false = salah satu
+	IncludeDescendants bool                // kategori beserta seluruh sub-kategorinya
+	Status             string              // active/inactive/archived
+	ExcludeArchived    bool                // diabaikan jika Status diisi
+	MinPrice           *int64              // ada unit dengan harga >= MinPrice
+	MaxPrice           *int64              // ada unit dengan harga <= MaxPrice (dalam unit yang sama)
+	StockState         string              // salah satu Stock*
+	LowStockThreshold  int                 // batas atas StockLow
+	Options            map[string][]string // nama option -> nilai yang diterima, semua dalam satu varian
+	HasBarcode         *bool               // true = semua unit punya barcode, false = ada unit tanpa barcode
+	CreatedFrom        *time.Time
+	CreatedTo          *time.Time // eksklusif
+	UpdatedFrom        *time.Time
+	UpdatedTo          *time.Time // eksklusif
+	Sort               string     // salah satu Sort*, hanya untuk FindAll
+	Desc               bool
+	After              *ProductCursor // keyset, diutamakan daripada Offset
+	Limit              int
+	Offset             int
+}
+
+// Status stok product dilihat dari stok varian-variannya
+const (
+	StockIn  = "in_stock"     // ada varian dengan stok > 0
+	StockLow = "low_stock"    // ada varian dengan stok 1..LowStockThreshold
+	StockOut = "out_of_stock" // tidak ada varian dengan stok > 0
+)
+
+// Urutan list product. SortID (default) mengikuti urutan dibuat.
+const (
+	SortID        = ""
+	SortName      = "name"
+	SortCreatedAt = "created_at"
+	SortUpdatedAt = "updated_at"
+	SortPrice     = "price" // harga unit termurah
+)
+
+// ProductCursor posisi baris terakhir satu halaman. Sort & Desc ikut disimpan
+// supaya cursor tidak dipakai dengan urutan yang berbeda.
+type ProductCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"` // nilai kolom sort dalam bentuk teks postgres
+	ID    int64  `json:"id"`
 }
 
 type ProductRepoInterface interface {
 	// Create product lengkap (beserta image, variant, unit, option)
 	Create(ctx context.Context, p *productModel.Product) (int64, error)
 
-	// // Update product lengkap (deep update: images, variants, units)
+	// Update product lengkap (deep update: images, variants, options, units).
+	// Variants nil = varian tidak diubah; perubahan stok dicatat sebagai adjustment.
 	Update(ctx context.Context, p *productModel.Product) error
 
-	// // Soft delete / archive product
-	Delete(ctx context.Context, id int64) error
+	// Update gambar product saja, versi dicek & dinaikkan seperti Update.
+	// Mengembalikan gambar yang dilepas agar file-nya bisa dihapus.
+	UpdateProductImages(ctx context.Context, productID, version int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error)
+
+	// Ubah status lewat state machine (row lock), dicatat di riwayat status.
+	// ErrInvalidTransition jika tidak diizinkan dari status sekarang.
+	ChangeStatus(ctx context.Context, id int64, transition productModel.Transition, actor productModel.Actor) (*productModel.StatusChange, error)
+
+	// Riwayat perubahan status, terbaru dulu
+	StatusHistory(ctx context.Context, productID int64) ([]productModel.StatusChange, error)
 
 	// // Get product lengkap by id
 	FindByID(ctx context.Context, id int64) (*productModel.ProductDetail, error)
 
-	// List product dengan filter fleksibel
-	FindAll(ctx context.Context, filter ProductFilter) ([]productModel.Product, error)
+	// List product dengan filter fleksibel. Cursor halaman berikutnya nil
+	// jika sudah habis (atau Limit tidak diisi).
+	FindAll(ctx context.Context, filter ProductFilter) ([]productModel.Product, *ProductCursor, error)
+
+	// Jumlah product yang cocok dengan filter (tanpa Limit/Offset/After)
+	CountAll(ctx context.Context, filter ProductFilter) (int64, error)
+
+	// Pencarian full-text + fuzzy (typo) dengan skor relevansi, filter sama dengan FindAll
+	Search(ctx context.Context, query string, filter ProductFilter) ([]productModel.SearchHit, error)
+
+	// Export katalog per unit secara streaming (filter sama dengan FindAll)
+	StreamExport(ctx context.Context, filter ProductFilter, fn func(productModel.ExportRow) error) error
 
 	// Get Image By ID
-	GetImageById(ctx context.Context, id int64) (string, error)
+	GetImageById(ctx context.Context, id int64) (*productModel.ProductImage, error)
+
+	// Semua URL gambar & rendition yang masih dipakai, untuk GC file yatim
+	ListImageURLs(ctx context.Context) ([]string, error)
+
+	// Hapus catatan file gambar yang sudah tidak direferensikan product_images.
+	// true berarti file fisik boleh dihapus.
+	PurgeImageBlob(ctx context.Context, hash string) (bool, error)
+
+	// Tambah satu varian (beserta option & unit) ke product yang sudah ada
+	CreateVariant(ctx context.Context, productID int64, v *productModel.Variant) (int64, error)
+
+	// Update satu varian, option & unit di-diff berdasarkan ID
+	UpdateVariant(ctx context.Context, v *productModel.Variant) error
+
+	// Hapus varian beserta option & unit-nya
+	DeleteVariant(ctx context.Context, productID, variantID int64) error
+
+	// Get varian lengkap by id
+	FindVariantByID(ctx context.Context, productID, variantID int64) (*productModel.Variant, error)
+
+	// Scan kasir: cari unit berdasarkan barcode, fallback ke sku varian
+	FindByBarcode(ctx context.Context, code string) (*productModel.ScanItem, error)
 
-	// // Cek stok varian tertentu
-	// GetVariantStock(ctx context.Context, variantID int64) (int, error)
+	// Sku dan barcode dari daftar yang sudah dipakai (dicek dalam satu query)
+	FindUsedCodes(ctx context.Context, skus, barcodes []string) (usedSKUs, usedBarcodes []string, err error)
 
-	// // Update stok varian (misal POS)
-	// UpdateVariantStock(ctx context.Context, variantID int64, newStock int) error
+	// Stok varian dikelola lewat ledger di stockrepo
 
 }
 
 type CategoryInterface interface {
 	CreateCategory(ctx context.Context, c *productModel.Category) (int64, error)
 	UpdateCategory(ctx context.Context, c *productModel.Category) error
-	DeleteCategory(ctx context.Context, id int64) error
+	// Hapus kategori sesuai strategi. ErrConflict beserta blockers jika ditolak.
+	DeleteCategory(ctx context.Context, id int64, opt productModel.CategoryDelete) (*productModel.CategoryBlockers, error)
 	FindAllCategory(ctx context.Context) ([]productModel.Category, error)
 	FindCategory(ctx context.Context, id int64) (*productModel.Category, error)
-	// FindCategoryByParent(ctx context.Context, id int64) (*productModel.Category, error)
+	// Anak langsung dari satu kategori
+	FindCategoryByParent(ctx context.Context, id int64) ([]productModel.Category, error)
+	// Leluhur kategori dari root sampai parent langsung
+	FindCategoryAncestors(ctx context.Context, id int64) ([]productModel.Category, error)
 }
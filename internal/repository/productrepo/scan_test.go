@@ -0,0 +1,166 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanP99Target adalah target latency lookup scan di kasir (database lokal).
+const scanP99Target = 5 * time.Millisecond
+
+// testConn membuka pool ke TEST_DATABASE_URI (database yang sudah di-migrate).
+// Test di-skip jika env tidak diset.
+func testConn(tb testing.TB) *txmanager.Manager {
+	tb.Helper()
+
+	uri := os.Getenv("TEST_DATABASE_URI")
+	if uri == "" {
+		tb.Skip("TEST_DATABASE_URI not set")
+	}
+
+	logger.Initialize("test")
+
+	pool, err := pgxpool.New(context.Background(), uri)
+	require.NoError(tb, err)
+	tb.Cleanup(pool.Close)
+
+	return txmanager.New(pool)
+}
+
+// deleteProducts membersihkan product test beserta ledger stoknya. Ledger
+// append-only dan FK-nya RESTRICT, jadi trigger-nya hanya dimatikan di dalam
+// transaksi pembersihan ini lalu dinyalakan lagi sebelum commit.
+func deleteProducts(tb testing.TB, db *txmanager.Manager, ids ...int64) {
+	tb.Helper()
+	ctx := context.Background()
+
+	tx, err := db.Pool().Begin(ctx)
+	require.NoError(tb, err)
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `ALTER TABLE stock_movements DISABLE TRIGGER trg_stock_movements_append_only`)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `DELETE FROM stock_movements
+		WHERE variant_id IN (SELECT id FROM variants WHERE product_id = ANY($1))`, ids)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `ALTER TABLE stock_movements ENABLE TRIGGER trg_stock_movements_append_only`)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `DELETE FROM products WHERE id = ANY($1)`, ids)
+	require.NoError(tb, err)
+
+	require.NoError(tb, tx.Commit(ctx))
+}
+
+// seedScanProduct membuat satu product dengan satu varian dan dua unit
+func seedScanProduct(tb testing.TB, repo *ProductRepository) (sku, barcode string) {
+	tb.Helper()
+
+	suffix := time.Now().UnixNano()
+	sku = fmt.Sprintf("SCAN-SKU-%d", suffix)
+	barcode = fmt.Sprintf("899%d", suffix)
+	packBarcode := barcode + "-PACK"
+
+	id, err := repo.Create(context.Background(), &productModel.Product{
+		Name: "Scan Test",
+		Variants: []productModel.Variant{{
+			SKU:      sku,
+			BaseUnit: "pcs",
+			Stock:    10,
+			Options:  []productModel.VariantOption{{Name: "size", Value: "M"}},
+			Units: []productModel.VariantUnit{
+				{Name: "pcs", Barcode: &barcode, ConversionRate: 1, Price: 3000},
+				{Name: "pack", Barcode: &packBarcode, ConversionRate: 6, Price: 17000},
+			},
+		}},
+	})
+	require.NoError(tb, err)
+
+	tb.Cleanup(func() {
+		deleteProducts(tb, repo.db, id)
+	})
+
+	return sku, barcode
+}
+
+func TestProductRepository_FindByBarcode(t *testing.T) {
+	repo := NewProductRepository(testConn(t))
+	sku, barcode := seedScanProduct(t, repo)
+	ctx := context.Background()
+
+	item, err := repo.FindByBarcode(ctx, barcode+"-PACK")
+	require.NoError(t, err)
+	assert.Equal(t, "barcode", item.MatchedBy)
+	assert.Equal(t, "pack", item.UnitName)
+	assert.Equal(t, 6, item.ConversionRate)
+	assert.Equal(t, int64(17000), item.Price)
+	require.Len(t, item.Variant.Options, 1)
+	assert.Equal(t, "M", item.Variant.Options[0].Value)
+
+	// fallback ke sku memakai unit terkecil
+	item, err = repo.FindByBarcode(ctx, sku)
+	require.NoError(t, err)
+	assert.Equal(t, "sku", item.MatchedBy)
+	assert.Equal(t, "pcs", item.UnitName)
+
+	_, err = repo.FindByBarcode(ctx, "does-not-exist")
+	assert.ErrorIs(t, err, errorUtils.ErrNotFound)
+}
+
+func TestProductRepository_FindByBarcode_NullSKU(t *testing.T) {
+	repo := NewProductRepository(testConn(t))
+	sku, barcode := seedScanProduct(t, repo)
+	ctx := context.Background()
+
+	// varian lama / hasil import bisa tidak punya sku
+	_, err := repo.db.Pool().Exec(ctx, `UPDATE variants SET sku = NULL WHERE sku = $1`, sku)
+	require.NoError(t, err)
+
+	item, err := repo.FindByBarcode(ctx, barcode)
+	require.NoError(t, err)
+	assert.Equal(t, "", item.Variant.SKU)
+}
+
+func TestProductRepository_FindByBarcode_P99(t *testing.T) {
+	repo := NewProductRepository(testConn(t))
+	_, barcode := seedScanProduct(t, repo)
+	ctx := context.Background()
+
+	const n = 1000
+	durations := make([]time.Duration, 0, n)
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		_, err := repo.FindByBarcode(ctx, barcode)
+		durations = append(durations, time.Since(start))
+		require.NoError(t, err)
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	p99 := durations[n*99/100]
+	t.Logf("FindByBarcode p99 = %s", p99)
+	assert.LessOrEqual(t, p99, scanP99Target)
+}
+
+func BenchmarkProductRepository_FindByBarcode(b *testing.B) {
+	repo := NewProductRepository(testConn(b))
+	_, barcode := seedScanProduct(b, repo)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.FindByBarcode(ctx, barcode); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_Search(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	suffix := time.Now().UnixNano()
+	name := fmt.Sprintf("Kopi Susu Gula Aren %d", suffix)
+	sku := fmt.Sprintf("KSGA-%d", suffix)
+	barcode := fmt.Sprintf("899%d", suffix)
+
+	id, err := repo.Create(ctx, &productModel.Product{
+		Name:        name,
+		Description: "Es kopi dengan susu segar dan gula aren asli",
+		Variants: []productModel.Variant{{
+			SKU:      sku,
+			BaseUnit: "cup",
+			Options:  []productModel.VariantOption{{Name: "size", Value: "Jumbo"}},
+			Units:    []productModel.VariantUnit{{Name: "cup", Barcode: &barcode, ConversionRate: 1, Price: 18000}},
+		}},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, id)
+	})
+
+	find := func(query string) *productModel.SearchHit {
+		hits, err := repo.Search(ctx, query, ProductFilter{Limit: 50})
+		require.NoError(t, err)
+		for i := range hits {
+			if hits[i].Product.ID == id {
+				return &hits[i]
+			}
+		}
+		return nil
+	}
+
+	// nama, deskripsi, sku, option, barcode
+	for _, query := range []string{fmt.Sprint(suffix), "segar", sku, "jumbo", barcode} {
+		hit := find(query)
+		require.NotNil(t, hit, query)
+		assert.Positive(t, hit.Score, query)
+	}
+
+	// typo pada nama
+	assert.NotNil(t, find(fmt.Sprintf("Kopi Susu Gulaa Aren %d", suffix)))
+
+	hit := find("aren")
+	require.NotNil(t, hit)
+	assert.Contains(t, hit.Highlight, "<mark>Aren</mark>")
+	assert.Contains(t, hit.Snippet, "<mark>aren</mark>")
+
+	// kata kunci FindAll ikut mencari sku
+	products, _, err := repo.FindAll(ctx, ProductFilter{Keyword: sku})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, id, products[0].ID)
+}
@@ -0,0 +1,86 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_ChangeStatus(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("Lifecycle %d", time.Now().UnixNano())
+	id, err := repo.Create(ctx, &productModel.Product{Name: name, Status: productModel.StatusInactive})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Pool().Exec(context.Background(), `DELETE FROM outbox WHERE aggregate_type = 'product' AND aggregate_id = $1`, id)
+		deleteProducts(t, db, id)
+	})
+
+	actor := productModel.Actor{Name: "kasir-api"}
+
+	// inactive -> active -> archived -> inactive
+	for _, step := range []struct {
+		transition productModel.Transition
+		to         string
+	}{
+		{productModel.TransitionActivate, productModel.StatusActive},
+		{productModel.TransitionArchive, productModel.StatusArchived},
+		{productModel.TransitionRestore, productModel.StatusInactive},
+	} {
+		change, err := repo.ChangeStatus(ctx, id, step.transition, actor)
+		require.NoError(t, err, step.transition)
+		assert.Equal(t, step.to, change.ToStatus)
+		assert.False(t, change.ChangedAt.IsZero())
+	}
+
+	_, err = repo.ChangeStatus(ctx, id, productModel.TransitionDeactivate, actor)
+	assert.Equal(t, utils.ErrInvalidTransition, err)
+
+	_, err = repo.ChangeStatus(ctx, -1, productModel.TransitionActivate, actor)
+	assert.Equal(t, utils.ErrNotFound, err)
+
+	history, err := repo.StatusHistory(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, productModel.TransitionRestore, history[0].Transition)
+	assert.Equal(t, productModel.StatusArchived, history[0].FromStatus)
+	assert.Equal(t, "kasir-api", history[0].ChangedBy.Name)
+	assert.Nil(t, history[0].ChangedBy.UserID)
+}
+
+func TestProductRepository_FindAll_ExcludeArchived(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	prefix := fmt.Sprintf("Archived%d", time.Now().UnixNano())
+	active, err := repo.Create(ctx, &productModel.Product{Name: prefix + " A"})
+	require.NoError(t, err)
+	archived, err := repo.Create(ctx, &productModel.Product{Name: prefix + " B"})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, active, archived)
+	})
+
+	_, err = repo.ChangeStatus(ctx, archived, productModel.TransitionArchive, productModel.Actor{})
+	require.NoError(t, err)
+
+	products, _, err := repo.FindAll(ctx, ProductFilter{Keyword: prefix, ExcludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, active, products[0].ID)
+
+	products, _, err = repo.FindAll(ctx, ProductFilter{Keyword: prefix, Status: productModel.StatusArchived, ExcludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, archived, products[0].ID)
+}
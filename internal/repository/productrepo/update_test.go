@@ -0,0 +1,87 @@
+package productrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_Update_Variants(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &productModel.Product{
+		Name: "Deep Update",
+		Variants: []productModel.Variant{
+			{
+				BaseUnit: "pcs",
+				Stock:    5,
+				Options:  []productModel.VariantOption{{Name: "size", Value: "S"}, {Name: "color", Value: "red"}},
+				Units:    []productModel.VariantUnit{{Name: "pcs", ConversionRate: 1, Price: 1000}},
+			},
+			{BaseUnit: "pcs"},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { deleteProducts(t, db, id) })
+
+	before, err := repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, before.Variants, 2)
+	kept := before.Variants[0]
+	if kept.Stock != 5 {
+		kept = before.Variants[1]
+	}
+
+	// varian yang punya riwayat stok tidak boleh ikut terhapus
+	err = repo.Update(ctx, &productModel.Product{ID: id, Name: "Deep Update", Variants: []productModel.Variant{}})
+	assert.Equal(t, errorUtils.ErrVariantInUse, err)
+
+	// varian pertama diubah (stok, option, unit), varian kedua (belum pernah
+	// punya stok) dihapus, satu varian baru ditambahkan
+	kept.Stock = 8
+	kept.Options = []productModel.VariantOption{{ID: kept.Options[0].ID, Name: kept.Options[0].Name, Value: "M"}}
+	kept.Units = append(kept.Units, productModel.VariantUnit{Name: "box", ConversionRate: 12, Price: 11000})
+
+	err = repo.Update(ctx, &productModel.Product{
+		ID:   id,
+		Name: "Deep Update",
+		Variants: []productModel.Variant{
+			kept,
+			{BaseUnit: "kg", Stock: 2},
+		},
+	})
+	require.NoError(t, err)
+
+	after, err := repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, after.Variants, 2)
+
+	for _, v := range after.Variants {
+		if v.ID != kept.ID {
+			assert.Equal(t, "kg", v.BaseUnit)
+			continue
+		}
+		assert.Equal(t, 8, v.Stock)
+		require.Len(t, v.Options, 1)
+		assert.Equal(t, "M", v.Options[0].Value)
+		assert.Len(t, v.Units, 2)
+	}
+
+	// perubahan stok tercatat di ledger
+	level, err := stockrepo.NewStockRepository(db).GetLevel(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.True(t, level.InSync())
+
+	// nil Variants tidak menyentuh varian
+	require.NoError(t, repo.Update(ctx, &productModel.Product{ID: id, Name: "Deep Update"}))
+	after, err = repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Len(t, after.Variants, 2)
+}
@@ -0,0 +1,128 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductRepository_Update_Version(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &productModel.Product{Name: "Versioned"})
+	require.NoError(t, err)
+	t.Cleanup(func() { deleteProducts(t, db, id) })
+
+	loaded, err := repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), loaded.Version)
+
+	// dua editor membaca versi yang sama, yang kedua harus ditolak
+	require.NoError(t, repo.Update(ctx, &productModel.Product{ID: id, Name: "Editor A", Version: loaded.Version}))
+	err = repo.Update(ctx, &productModel.Product{ID: id, Name: "Editor B", Version: loaded.Version})
+	assert.Equal(t, utils.ErrPreconditionFailed, err)
+
+	after, err := repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Editor A", after.Name)
+	assert.Equal(t, int64(2), after.Version)
+
+	// perubahan varian lewat endpoint lain ikut menaikkan versi
+	_, err = repo.CreateVariant(ctx, id, &productModel.Variant{BaseUnit: "pcs"})
+	require.NoError(t, err)
+	err = repo.Update(ctx, &productModel.Product{ID: id, Name: "Editor A2", Version: after.Version})
+	assert.Equal(t, utils.ErrPreconditionFailed, err)
+
+	err = repo.Update(ctx, &productModel.Product{ID: -1, Name: "Missing", Version: 1})
+	assert.Equal(t, utils.ErrNotFound, err)
+}
+
+func TestProductRepository_UpdateProductImages_Version(t *testing.T) {
+	db := testConn(t)
+	repo := NewProductRepository(db)
+	categoryRepo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	categoryID, err := categoryRepo.CreateCategory(ctx, &productModel.Category{Name: fmt.Sprintf("Image Version %d", time.Now().UnixNano())})
+	require.NoError(t, err)
+	id, err := repo.Create(ctx, &productModel.Product{Name: "Image Versioned", Description: "Tetap", CategoryId: []*int64{&categoryID}})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		deleteProducts(t, db, id)
+		db.Pool().Exec(context.Background(), `DELETE FROM categories WHERE id = $1`, categoryID)
+	})
+
+	add := productModel.ImageChanges{Upsert: []productModel.ProductImage{{URL: "/uploads/image-version.jpg"}}}
+	removed, err := repo.UpdateProductImages(ctx, id, 1, add)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	// nama, deskripsi dan kategori tidak ikut berubah
+	after, err := repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Image Versioned", after.Name)
+	assert.Equal(t, "Tetap", after.Description)
+	assert.Len(t, after.Categories, 1)
+	assert.Len(t, after.Images, 1)
+	assert.Equal(t, int64(2), after.Version)
+
+	// ETag lama ditolak
+	_, err = repo.UpdateProductImages(ctx, id, 1, add)
+	assert.Equal(t, utils.ErrPreconditionFailed, err)
+
+	// add tidak menghapus gambar yang tidak disebut
+	second := productModel.ImageChanges{Upsert: []productModel.ProductImage{{URL: "/uploads/image-version-2.jpg"}}}
+	_, err = repo.UpdateProductImages(ctx, id, 2, second)
+	require.NoError(t, err)
+	after, err = repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, after.Images, 2)
+
+	// payload delete saja tetap menghapus barisnya
+	first := after.Images[0]
+	removed, err = repo.UpdateProductImages(ctx, id, 3, productModel.ImageChanges{Delete: []int64{first.ID}})
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, first.URL, removed[0].URL)
+	after, err = repo.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Len(t, after.Images, 1)
+
+	// gambar milik product lain ditolak
+	_, err = repo.UpdateProductImages(ctx, id, 4, productModel.ImageChanges{Delete: []int64{-1}})
+	assert.Equal(t, utils.ErrNotFound, err)
+}
+
+func TestCategoryRepository_UpdateCategory_Version(t *testing.T) {
+	db := testConn(t)
+	repo := NewCategoryRepsitory(db)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("Versioned Category %d", time.Now().UnixNano())
+	id, err := repo.CreateCategory(ctx, &productModel.Category{Name: name})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Pool().Exec(context.Background(), `DELETE FROM categories WHERE id = $1`, id) })
+
+	loaded, err := repo.FindCategory(ctx, id)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UpdateCategory(ctx, &productModel.Category{ID: id, Name: name + " A", Version: loaded.Version}))
+	err = repo.UpdateCategory(ctx, &productModel.Category{ID: id, Name: name + " B", Version: loaded.Version})
+	assert.Equal(t, utils.ErrPreconditionFailed, err)
+
+	after, err := repo.FindCategory(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, name+" A", after.Name)
+	assert.Equal(t, loaded.Version+1, after.Version)
+
+	err = repo.UpdateCategory(ctx, &productModel.Category{ID: -1, Name: name + " C", Version: 1})
+	assert.Equal(t, utils.ErrNotFound, err)
+}
@@ -0,0 +1,183 @@
+package salerepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// Sale Repository
+// ===========================================
+
+type SaleRepository struct {
+	db *txmanager.Manager
+}
+
+func NewSaleRepository(db *txmanager.Manager) *SaleRepository {
+	return &SaleRepository{
+		db: db,
+	}
+}
+
+// ********** Implementation Find Unit**********
+func (conn SaleRepository) FindUnit(ctx context.Context, unitID int64) (*saleModel.SaleItem, error) {
+	query := `SELECT vu.id, vu.variant_id, p.name, COALESCE(v.sku, ''), vu.name, vu.conversion_rate, vu.price
+		FROM variant_units vu
+		JOIN variants v ON v.id = vu.variant_id
+		JOIN products p ON p.id = v.product_id
+		WHERE vu.id = $1 AND p.status <> 'archived'`
+
+	var item saleModel.SaleItem
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, unitID).Scan(
+		&item.VariantUnitID,
+		&item.VariantID,
+		&item.ProductName,
+		&item.SKU,
+		&item.UnitName,
+		&item.ConversionRate,
+		&item.UnitPrice,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	return &item, nil
+}
+
+// ********** Implementation Create Sale**********
+func (conn SaleRepository) Create(ctx context.Context, s *saleModel.Sale) error {
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		return conn.create(ctx, s)
+	})
+}
+
+func (conn SaleRepository) create(ctx context.Context, s *saleModel.Sale) error {
+	tx := conn.db.Conn(ctx)
+
+	// nomor struk: row outlet terkunci sampai commit, jadi rollback tidak
+	// meninggalkan celah nomor
+	var (
+		outletCode string
+		receiptSeq int64
+	)
+	err := tx.QueryRow(ctx,
+		`UPDATE outlets SET last_receipt_no = last_receipt_no + 1, updated_at = NOW()
+		 WHERE id = $1 RETURNING code, last_receipt_no`,
+		s.OutletID).Scan(&outletCode, &receiptSeq)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	s.ReceiptNo = fmt.Sprintf("%s-%08d", outletCode, receiptSeq)
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO sales (outlet_id, receipt_seq, receipt_no, total, paid, change_amount)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		s.OutletID, receiptSeq, s.ReceiptNo, s.Total, s.Paid, s.Change).
+		Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	// kurangi stok per varian, diurutkan berdasarkan variant_id supaya
+	// urutan lock antar transaksi selalu sama (menghindari deadlock)
+	needed := make(map[int64]int)
+	for _, item := range s.Items {
+		needed[item.VariantID] += item.BaseQuantity
+	}
+	variantIDs := make([]int64, 0, len(needed))
+	for id := range needed {
+		variantIDs = append(variantIDs, id)
+	}
+	sort.Slice(variantIDs, func(i, j int) bool { return variantIDs[i] < variantIDs[j] })
+
+	s.OversoldVariantIDs = nil
+	for _, id := range variantIDs {
+		result, err := stockrepo.DecrementStock(ctx, tx, stockModel.Decrement{
+			VariantID:     id,
+			Quantity:      needed[id],
+			Reason:        stockModel.ReasonSale,
+			ReferenceType: "sale",
+			ReferenceID:   &s.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if result.Oversold {
+			s.OversoldVariantIDs = append(s.OversoldVariantIDs, id)
+		}
+	}
+
+	for i := range s.Items {
+		item := &s.Items[i]
+		item.SaleID = s.ID
+		err = tx.QueryRow(ctx,
+			`INSERT INTO sale_items (sale_id, variant_id, variant_unit_id, product_name, sku, unit_name,
+				quantity, conversion_rate, base_quantity, unit_price, line_total)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+			s.ID, item.VariantID, item.VariantUnitID, item.ProductName, item.SKU, item.UnitName,
+			item.Quantity, item.ConversionRate, item.BaseQuantity, item.UnitPrice, item.LineTotal).
+			Scan(&item.ID)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+	}
+
+	return nil
+}
+
+// ********** Implementation Find Sale By ID**********
+func (conn SaleRepository) FindByID(ctx context.Context, id int64) (*saleModel.Sale, error) {
+	var s saleModel.Sale
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT id, outlet_id, receipt_no, total, paid, change_amount, created_at FROM sales WHERE id = $1`, id).
+		Scan(&s.ID, &s.OutletID, &s.ReceiptNo, &s.Total, &s.Paid, &s.Change, &s.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		return nil, utils.MapDbError(err)
+	}
+
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`SELECT id, COALESCE(variant_id, 0), COALESCE(variant_unit_id, 0), product_name, COALESCE(sku, ''), unit_name,
+			quantity, conversion_rate, base_quantity, unit_price, line_total
+		 FROM sale_items WHERE sale_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := saleModel.SaleItem{SaleID: s.ID}
+		if err := rows.Scan(&item.ID, &item.VariantID, &item.VariantUnitID, &item.ProductName, &item.SKU, &item.UnitName,
+			&item.Quantity, &item.ConversionRate, &item.BaseQuantity, &item.UnitPrice, &item.LineTotal); err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		s.Items = append(s.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, utils.MapDbError(err)
+	}
+
+	return &s, nil
+}
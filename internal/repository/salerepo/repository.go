@@ -0,0 +1,19 @@
+package salerepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+)
+
+type SaleRepoInterface interface {
+	// Ambil harga & konversi unit untuk satu baris penjualan
+	FindUnit(ctx context.Context, unitID int64) (*saleModel.SaleItem, error)
+
+	// Simpan penjualan: kurangi stok, ambil nomor struk, insert sale & item
+	// dalam satu transaksi
+	Create(ctx context.Context, s *saleModel.Sale) error
+
+	// Get penjualan lengkap by id
+	FindByID(ctx context.Context, id int64) (*saleModel.Sale, error)
+}
@@ -0,0 +1,307 @@
+package stockrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/outboxrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// Stock Repository
+// ===========================================
+
+type StockRepository struct {
+	db *txmanager.Manager
+}
+
+func NewStockRepository(db *txmanager.Manager) *StockRepository {
+	return &StockRepository{
+		db: db,
+	}
+}
+
+// ApplyMovement menambah stok varian sebesar m.Quantity lalu mencatatnya di
+// ledger. Dipakai repository lain di dalam transaksi mereka sendiri.
+func ApplyMovement(ctx context.Context, tx txmanager.DBTX, m *stockModel.Movement) error {
+	err := tx.QueryRow(ctx,
+		`UPDATE variants SET stock = stock + $1, updated_at = NOW() WHERE id = $2 RETURNING stock`,
+		m.Quantity, m.VariantID).Scan(&m.BalanceAfter)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	return InsertMovement(ctx, tx, m)
+}
+
+// InsertMovement hanya menulis baris ledger (plus event stock.changed di
+// outbox); m.BalanceAfter harus sudah diisi oleh pemanggil yang sudah
+// mengubah variants.stock.
+func InsertMovement(ctx context.Context, tx txmanager.DBTX, m *stockModel.Movement) error {
+	var referenceType *string
+	if m.ReferenceType != "" {
+		referenceType = &m.ReferenceType
+	}
+
+	err := tx.QueryRow(ctx,
+		`INSERT INTO stock_movements (variant_id, quantity, balance_after, reason, reference_type, reference_id, note)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		m.VariantID, m.Quantity, m.BalanceAfter, string(m.Reason), referenceType, m.ReferenceID, m.Note).
+		Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+
+	return outboxrepo.Append(ctx, tx, eventModel.StockChanged, eventModel.AggregateVariant, m.VariantID,
+		eventModel.StockPayload{
+			VariantID:     m.VariantID,
+			MovementID:    m.ID,
+			Quantity:      m.Quantity,
+			BalanceAfter:  m.BalanceAfter,
+			Reason:        string(m.Reason),
+			ReferenceType: m.ReferenceType,
+			ReferenceID:   m.ReferenceID,
+		})
+}
+
+// lockVariant mengunci row varian dan mengembalikan stok, reservasi dan
+// stock policy product-nya
+func lockVariant(ctx context.Context, tx txmanager.DBTX, variantID int64) (int, int, stockModel.Policy, error) {
+	var (
+		stock, reserved int
+		policy          string
+	)
+	err := tx.QueryRow(ctx,
+		`SELECT v.stock, v.reserved, p.stock_policy
+		 FROM variants v
+		 JOIN products p ON p.id = v.product_id
+		 WHERE v.id = $1
+		 FOR UPDATE OF v`,
+		variantID).Scan(&stock, &reserved, &policy)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, 0, "", utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return 0, 0, "", utils.MapDbError(err)
+	}
+
+	return stock, reserved, stockModel.Policy(policy), nil
+}
+
+// DecrementStock mengunci row varian (FOR UPDATE), menerapkan stock policy
+// product, lalu mengurangi stok dan mencatat ledger. Dua kasir yang menjual
+// barang terakhir akan antri di lock ini, jadi hanya satu yang lolos.
+func DecrementStock(ctx context.Context, tx txmanager.DBTX, d stockModel.Decrement) (*stockModel.DecrementResult, error) {
+	if d.Quantity <= 0 || d.Reserved < 0 {
+		return nil, utils.ErrBadRequest
+	}
+
+	stock, reserved, policy, err := lockVariant(ctx, tx, d.VariantID)
+	if err != nil {
+		return nil, err
+	}
+
+	consumed := min(d.Reserved, reserved)
+	available := stock - (reserved - consumed)
+
+	allowed, oversold := policy.Check(available, d.Quantity)
+	if !allowed {
+		return nil, utils.ErrInsufficientStock
+	}
+
+	result := &stockModel.DecrementResult{
+		Oversold: oversold,
+		Movement: stockModel.Movement{
+			VariantID:     d.VariantID,
+			Quantity:      -d.Quantity,
+			Reason:        d.Reason,
+			ReferenceType: d.ReferenceType,
+			ReferenceID:   d.ReferenceID,
+			Note:          d.Note,
+		},
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE variants SET stock = stock - $1, reserved = reserved - $2, updated_at = NOW()
+		 WHERE id = $3 RETURNING stock`,
+		d.Quantity, consumed, d.VariantID).Scan(&result.Movement.BalanceAfter)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	if err := InsertMovement(ctx, tx, &result.Movement); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ********** Implementation Decrement Stock**********
+func (conn StockRepository) Decrement(ctx context.Context, d stockModel.Decrement) (*stockModel.DecrementResult, error) {
+	var result *stockModel.DecrementResult
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = DecrementStock(ctx, conn.db.Conn(ctx), d)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ********** Implementation Reserve Stock**********
+func (conn StockRepository) Reserve(ctx context.Context, variantID int64, qty int) (bool, error) {
+	var oversold bool
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		stock, reserved, policy, err := lockVariant(ctx, tx, variantID)
+		if err != nil {
+			return err
+		}
+
+		var allowed bool
+		allowed, oversold = policy.Check(stock-reserved, qty)
+		if !allowed {
+			return utils.ErrInsufficientStock
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE variants SET reserved = reserved + $1, updated_at = NOW() WHERE id = $2`,
+			qty, variantID)
+		if err != nil {
+			logger.Error("Error: ", err.Error())
+			return utils.MapDbError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return oversold, nil
+}
+
+// ********** Implementation Release Reservation**********
+func (conn StockRepository) Release(ctx context.Context, variantID int64, qty int) error {
+	tag, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE variants SET reserved = GREATEST(reserved - $1, 0), updated_at = NOW() WHERE id = $2`,
+		qty, variantID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// ********** Implementation Record Movement**********
+func (conn StockRepository) Record(ctx context.Context, m *stockModel.Movement) error {
+	return conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		return ApplyMovement(ctx, conn.db.Conn(ctx), m)
+	})
+}
+
+// ********** Implementation Stock Opname**********
+func (conn StockRepository) Count(ctx context.Context, variantID int64, counted int, note string) (*stockModel.Movement, error) {
+	m := &stockModel.Movement{
+		VariantID: variantID,
+		Reason:    stockModel.ReasonOpname,
+		Note:      note,
+	}
+
+	err := conn.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx := conn.db.Conn(ctx)
+
+		current, _, _, err := lockVariant(ctx, tx, variantID)
+		if err != nil {
+			return err
+		}
+
+		m.Quantity = counted - current
+		return ApplyMovement(ctx, tx, m)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// ********** Implementation Stock History**********
+func (conn StockRepository) History(ctx context.Context, filter HistoryFilter) ([]stockModel.Movement, error) {
+	// BeforeID 0 = mulai dari movement terbaru
+	query := `SELECT id, variant_id, quantity, balance_after, reason, COALESCE(reference_type, ''), reference_id,
+			COALESCE(note, ''), created_at
+		FROM stock_movements
+		WHERE variant_id = $1 AND ($2::BIGINT = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, filter.VariantID, filter.BeforeID, filter.Limit, filter.Offset)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	movements := []stockModel.Movement{}
+	for rows.Next() {
+		var (
+			m      stockModel.Movement
+			reason string
+		)
+		if err := rows.Scan(&m.ID, &m.VariantID, &m.Quantity, &m.BalanceAfter, &reason, &m.ReferenceType,
+			&m.ReferenceID, &m.Note, &m.CreatedAt); err != nil {
+			logger.Error("Error: ", err.Error())
+			return nil, utils.MapDbError(err)
+		}
+		m.Reason = stockModel.Reason(reason)
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	return movements, nil
+}
+
+// ********** Implementation Get Stock Level**********
+func (conn StockRepository) GetLevel(ctx context.Context, variantID int64) (*stockModel.Level, error) {
+	query := `SELECT v.id, v.stock, COALESCE(SUM(sm.quantity), 0)
+		FROM variants v
+		LEFT JOIN stock_movements sm ON sm.variant_id = v.id
+		WHERE v.id = $1
+		GROUP BY v.id, v.stock`
+
+	var level stockModel.Level
+	err := conn.db.Conn(ctx).QueryRow(ctx, query, variantID).Scan(&level.VariantID, &level.Stock, &level.LedgerStock)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+
+	return &level, nil
+}
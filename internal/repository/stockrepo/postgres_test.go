@@ -0,0 +1,180 @@
+package stockrepo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConn membuka pool ke TEST_DATABASE_URI (database yang sudah
+// di-migrate). Test di-skip jika env tidak diset.
+func testConn(tb testing.TB) *txmanager.Manager {
+	tb.Helper()
+
+	uri := os.Getenv("TEST_DATABASE_URI")
+	if uri == "" {
+		tb.Skip("TEST_DATABASE_URI not set")
+	}
+
+	logger.Initialize("test")
+
+	config, err := pgxpool.ParseConfig(uri)
+	require.NoError(tb, err)
+	// cukup koneksi supaya goroutine di test race benar-benar bersaing
+	config.MaxConns = 32
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	require.NoError(tb, err)
+	tb.Cleanup(pool.Close)
+
+	return txmanager.New(pool)
+}
+
+// deleteProducts membersihkan product test beserta ledger stoknya. Ledger
+// append-only dan FK-nya RESTRICT, jadi trigger-nya hanya dimatikan di dalam
+// transaksi pembersihan ini lalu dinyalakan lagi sebelum commit.
+func deleteProducts(tb testing.TB, conn *txmanager.Manager, ids ...int64) {
+	tb.Helper()
+	ctx := context.Background()
+
+	tx, err := conn.Pool().Begin(ctx)
+	require.NoError(tb, err)
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `ALTER TABLE stock_movements DISABLE TRIGGER trg_stock_movements_append_only`)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `DELETE FROM stock_movements
+		WHERE variant_id IN (SELECT id FROM variants WHERE product_id = ANY($1))`, ids)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `ALTER TABLE stock_movements ENABLE TRIGGER trg_stock_movements_append_only`)
+	require.NoError(tb, err)
+	_, err = tx.Exec(ctx, `DELETE FROM products WHERE id = ANY($1)`, ids)
+	require.NoError(tb, err)
+
+	require.NoError(tb, tx.Commit(ctx))
+}
+
+// seedVariant membuat product dengan policy tertentu dan satu varian berstok
+func seedVariant(tb testing.TB, conn *txmanager.Manager, policy stockModel.Policy, stock int) int64 {
+	tb.Helper()
+	ctx := context.Background()
+
+	var productID, variantID int64
+	err := conn.Pool().QueryRow(ctx,
+		`INSERT INTO products (name, stock_policy) VALUES ('Race Test', $1) RETURNING id`,
+		string(policy)).Scan(&productID)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { deleteProducts(tb, conn, productID) })
+
+	err = conn.Pool().QueryRow(ctx,
+		`INSERT INTO variants (product_id, base_unit, stock) VALUES ($1, 'pcs', 0) RETURNING id`,
+		productID).Scan(&variantID)
+	require.NoError(tb, err)
+
+	require.NoError(tb, NewStockRepository(conn).Record(ctx, &stockModel.Movement{
+		VariantID: variantID,
+		Quantity:  stock,
+		Reason:    stockModel.ReasonPurchaseReceipt,
+	}))
+
+	return variantID
+}
+
+// raceDecrement menjalankan n goroutine yang masing-masing mengurangi 1 stok
+// lewat pool yang sama, lalu mengembalikan jumlah yang berhasil dan ditolak.
+func raceDecrement(t *testing.T, repo *StockRepository, variantID int64, n int) (succeeded, rejected int) {
+	t.Helper()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := repo.Decrement(context.Background(), stockModel.Decrement{
+				VariantID: variantID,
+				Quantity:  1,
+				Reason:    stockModel.ReasonSale,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				succeeded++
+			case errors.Is(err, utils.ErrInsufficientStock):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	return succeeded, rejected
+}
+
+func TestStockRepository_Decrement_Race_Block(t *testing.T) {
+	conn := testConn(t)
+	variantID := seedVariant(t, conn, stockModel.PolicyBlock, 10)
+
+	succeeded, rejected := raceDecrement(t, NewStockRepository(conn), variantID, 50)
+
+	assert.Equal(t, 10, succeeded)
+	assert.Equal(t, 40, rejected)
+
+	level, err := NewStockRepository(conn).GetLevel(context.Background(), variantID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, level.Stock)
+	assert.True(t, level.InSync())
+}
+
+func TestStockRepository_Decrement_Race_AllowNegative(t *testing.T) {
+	conn := testConn(t)
+	variantID := seedVariant(t, conn, stockModel.PolicyAllowNegative, 10)
+
+	succeeded, rejected := raceDecrement(t, NewStockRepository(conn), variantID, 50)
+
+	assert.Equal(t, 50, succeeded)
+	assert.Equal(t, 0, rejected)
+
+	level, err := NewStockRepository(conn).GetLevel(context.Background(), variantID)
+	require.NoError(t, err)
+	assert.Equal(t, -40, level.Stock)
+	assert.True(t, level.InSync())
+}
+
+func TestStockRepository_Reserve(t *testing.T) {
+	conn := testConn(t)
+	repo := NewStockRepository(conn)
+	ctx := context.Background()
+	variantID := seedVariant(t, conn, stockModel.PolicyBlock, 3)
+
+	_, err := repo.Reserve(ctx, variantID, 2)
+	require.NoError(t, err)
+
+	// hanya 1 yang tersedia karena 2 sedang ditahan
+	_, err = repo.Decrement(ctx, stockModel.Decrement{VariantID: variantID, Quantity: 2, Reason: stockModel.ReasonSale})
+	assert.ErrorIs(t, err, utils.ErrInsufficientStock)
+
+	// memakai reservasi sendiri boleh
+	res, err := repo.Decrement(ctx, stockModel.Decrement{VariantID: variantID, Quantity: 2, Reason: stockModel.ReasonSale, Reserved: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Movement.BalanceAfter)
+}
@@ -0,0 +1,37 @@
+package stockrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+)
+
+type HistoryFilter struct {
+	VariantID int64
+	BeforeID  int64 // keyset: hanya movement dengan id lebih kecil
+	Limit     int
+	Offset    int
+}
+
+type StockRepoInterface interface {
+	// Catat movement dan update variants.stock dalam satu transaksi
+	Record(ctx context.Context, m *stockModel.Movement) error
+
+	// Kurangi stok dengan row lock + stock policy product
+	Decrement(ctx context.Context, d stockModel.Decrement) (*stockModel.DecrementResult, error)
+
+	// Tahan stok tanpa mengubah ledger; bool = oversold (policy warn)
+	Reserve(ctx context.Context, variantID int64, qty int) (bool, error)
+
+	// Lepas stok yang ditahan
+	Release(ctx context.Context, variantID int64, qty int) error
+
+	// Stock opname: set stok ke hasil hitung, selisihnya dicatat di ledger
+	Count(ctx context.Context, variantID int64, counted int, note string) (*stockModel.Movement, error)
+
+	// Riwayat movement satu varian, terbaru dulu
+	History(ctx context.Context, filter HistoryFilter) ([]stockModel.Movement, error)
+
+	// Stok tersimpan dibandingkan dengan jumlah ledger
+	GetLevel(ctx context.Context, variantID int64) (*stockModel.Level, error)
+}
@@ -0,0 +1,70 @@
+package txmanager
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DBTX dipenuhi oleh *pgxpool.Pool maupun pgx.Tx, jadi query repository
+// tidak perlu tahu sedang berjalan di dalam transaksi atau tidak.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// TxManager (unit of work) dipakai usecase untuk menjalankan beberapa
+// pemanggilan repository dalam satu transaksi.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txKey struct{}
+
+type Manager struct {
+	pool *pgxpool.Pool
+}
+
+func New(pool *pgxpool.Pool) *Manager {
+	return &Manager{
+		pool: pool,
+	}
+}
+
+// WithinTransaction menjalankan fn di dalam transaksi yang disimpan di ctx.
+// Jika ctx sudah membawa transaksi, fn ikut transaksi tersebut sehingga
+// pemanggilan bersarang tetap commit/rollback bersama.
+func (m *Manager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Conn mengembalikan transaksi aktif di ctx, atau pool jika tidak ada
+func (m *Manager) Conn(ctx context.Context) DBTX {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return m.pool
+}
+
+// Pool untuk kebutuhan yang tidak bisa lewat DBTX (misal ping, close)
+func (m *Manager) Pool() *pgxpool.Pool {
+	return m.pool
+}
@@ -0,0 +1,121 @@
+package userrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// User Repository
+// ===========================================
+
+type UserRepository struct {
+	db *txmanager.Manager
+}
+
+func NewUserRepository(db *txmanager.Manager) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+const userColumns = `id, username, password_hash, role, is_active, created_at, updated_at`
+
+func scanUser(row pgx.Row) (*userModel.User, error) {
+	var u userModel.User
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.IsActive, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return &u, nil
+}
+
+// ********** Implementation Create User**********
+func (conn UserRepository) Create(ctx context.Context, u *userModel.User) (int64, error) {
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO users (username, password_hash, role, is_active) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		u.Username, u.PasswordHash, u.Role, u.IsActive).
+		Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
+	}
+	return u.ID, nil
+}
+
+// ********** Implementation Find User By Username**********
+func (conn UserRepository) FindByUsername(ctx context.Context, username string) (*userModel.User, error) {
+	return scanUser(conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+}
+
+// ********** Implementation Find User By ID**********
+func (conn UserRepository) FindByID(ctx context.Context, id int64) (*userModel.User, error) {
+	return scanUser(conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+// ********** Implementation Create Refresh Token**********
+func (conn UserRepository) CreateRefreshToken(ctx context.Context, t *userModel.RefreshToken) error {
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)
+		 RETURNING id, created_at`,
+		t.UserID, t.TokenHash, t.ExpiresAt).
+		Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Find Refresh Token**********
+func (conn UserRepository) FindRefreshToken(ctx context.Context, tokenHash string) (*userModel.RefreshToken, error) {
+	var t userModel.RefreshToken
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT id, user_id, token_hash, expires_at, revoked_at, created_at
+		 FROM refresh_tokens WHERE token_hash = $1`, tokenHash).
+		Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt, &t.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return &t, nil
+}
+
+// ********** Implementation Revoke Refresh Token**********
+func (conn UserRepository) RevokeRefreshToken(ctx context.Context, id int64) (bool, error) {
+	// revoked_at IS NULL membuat dua refresh paralel dengan token yang sama
+	// hanya dimenangkan satu request
+	tag, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return false, utils.MapDbError(err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ********** Implementation Revoke User Refresh Tokens**********
+func (conn UserRepository) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
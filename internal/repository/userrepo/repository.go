@@ -0,0 +1,28 @@
+package userrepo
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+)
+
+type UserRepoInterface interface {
+	// Create user baru, password sudah dalam bentuk hash
+	Create(ctx context.Context, u *userModel.User) (int64, error)
+
+	// Get user by username / id
+	FindByUsername(ctx context.Context, username string) (*userModel.User, error)
+	FindByID(ctx context.Context, id int64) (*userModel.User, error)
+
+	// Simpan refresh token (hash) yang baru diterbitkan
+	CreateRefreshToken(ctx context.Context, t *userModel.RefreshToken) error
+
+	// Get refresh token by hash, termasuk yang sudah dicabut
+	FindRefreshToken(ctx context.Context, tokenHash string) (*userModel.RefreshToken, error)
+
+	// Cabut satu refresh token. false jika token sudah dicabut sebelumnya
+	RevokeRefreshToken(ctx context.Context, id int64) (bool, error)
+
+	// Cabut semua refresh token aktif milik user
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+}
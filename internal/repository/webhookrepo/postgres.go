@@ -0,0 +1,329 @@
+package webhookrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	utils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// ===========================================
+// Webhook Repository
+// ===========================================
+
+type WebhookRepository struct {
+	db *txmanager.Manager
+}
+
+func NewWebhookRepository(db *txmanager.Manager) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+const subscriptionColumns = `id, url, secret, event_types, is_active, created_at, updated_at`
+
+func scanSubscription(row pgx.Row) (*webhookModel.Subscription, error) {
+	var s webhookModel.Subscription
+	err := row.Scan(&s.ID, &s.URL, &s.Secret, &s.Events, &s.IsActive, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ********** Implementation Create Subscription**********
+func (conn WebhookRepository) CreateSubscription(ctx context.Context, s *webhookModel.Subscription) (int64, error) {
+	var id int64
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO webhook_subscriptions (url, secret, event_types, is_active)
+		VALUES ($1, $2, $3, $4) RETURNING id`,
+		s.URL, s.Secret, s.Events, s.IsActive).Scan(&id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return 0, utils.MapDbError(err)
+	}
+	return id, nil
+}
+
+// ********** Implementation Update Subscription**********
+func (conn WebhookRepository) UpdateSubscription(ctx context.Context, s *webhookModel.Subscription) error {
+	tag, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE webhook_subscriptions SET url = $2, event_types = $3, is_active = $4, updated_at = NOW()
+		WHERE id = $1`,
+		s.ID, s.URL, s.Events, s.IsActive)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// ********** Implementation Delete Subscription**********
+func (conn WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	tag, err := conn.db.Conn(ctx).Exec(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return utils.ErrNotFound
+	}
+	return nil
+}
+
+// ********** Implementation Find Subscription**********
+func (conn WebhookRepository) FindSubscription(ctx context.Context, id int64) (*webhookModel.Subscription, error) {
+	s, err := scanSubscription(conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT `+subscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return s, nil
+}
+
+// ********** Implementation Find All Subscription**********
+func (conn WebhookRepository) FindAllSubscriptions(ctx context.Context) ([]webhookModel.Subscription, error) {
+	return conn.findSubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM webhook_subscriptions ORDER BY id`)
+}
+
+func (conn WebhookRepository) findSubscriptions(ctx context.Context, query string, args ...any) ([]webhookModel.Subscription, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var subscriptions []webhookModel.Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	return subscriptions, rows.Err()
+}
+
+// ********** Implementation Enqueue Delivery**********
+func (conn WebhookRepository) EnqueueDeliveries(ctx context.Context, eventID int64, eventType string, payload []byte) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3 FROM webhook_subscriptions
+		WHERE is_active AND ($2 = ANY(event_types) OR '*' = ANY(event_types))
+		ON CONFLICT (subscription_id, event_id) WHERE dead_letter_id IS NULL DO NOTHING`,
+		eventID, eventType, payload)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Enqueue Replay**********
+func (conn WebhookRepository) EnqueueReplay(ctx context.Context, d *webhookModel.DeadLetter) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, dead_letter_id)
+		VALUES ($1, $2, $3, $4, $5)`,
+		d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.ID)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Claim Delivery**********
+func (conn WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhookModel.Delivery, error) {
+	rows, err := conn.db.Conn(ctx).Query(ctx,
+		`WITH claimed AS (
+			UPDATE webhook_deliveries SET next_attempt_at = NOW() + make_interval(secs => $2)
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE next_attempt_at <= NOW()
+				ORDER BY id
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, subscription_id, event_id, event_type, payload, dead_letter_id, attempts
+		)
+		SELECT c.id, c.subscription_id, s.url, s.secret, c.event_id, c.event_type, c.payload, c.dead_letter_id, c.attempts
+		FROM claimed c
+		JOIN webhook_subscriptions s ON s.id = c.subscription_id
+		ORDER BY c.id`,
+		limit, lease.Seconds())
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var deliveries []webhookModel.Delivery
+	for rows.Next() {
+		var d webhookModel.Delivery
+		err := rows.Scan(&d.ID, &d.SubscriptionID, &d.URL, &d.Secret, &d.EventID, &d.EventType,
+			&d.Payload, &d.DeadLetterID, &d.Attempts)
+		if err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ********** Implementation Reschedule Delivery**********
+func (conn WebhookRepository) RescheduleDelivery(ctx context.Context, id int64, reason string, responseStatus *int, next time.Time) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_error = $2, response_status = $3, next_attempt_at = $4
+		WHERE id = $1`,
+		id, reason, responseStatus, next)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Delete Delivery**********
+func (conn WebhookRepository) DeleteDelivery(ctx context.Context, id int64) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx, `DELETE FROM webhook_deliveries WHERE id = $1`, id)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Create Dead Letter**********
+func (conn WebhookRepository) CreateDeadLetter(ctx context.Context, d *webhookModel.DeadLetter) error {
+	err := conn.db.Conn(ctx).QueryRow(ctx,
+		`INSERT INTO webhook_dead_letters (subscription_id, event_id, event_type, payload, attempts, last_error, response_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		d.SubscriptionID, d.EventID, d.EventType, d.Payload, d.Attempts, d.LastError, d.ResponseStatus,
+	).Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+const deadLetterColumns = `id, subscription_id, event_id, event_type, payload, attempts,
+	COALESCE(last_error, ''), response_status, created_at, replayed_at`
+
+func scanDeadLetter(row pgx.Row) (*webhookModel.DeadLetter, error) {
+	var d webhookModel.DeadLetter
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Attempts,
+		&d.LastError, &d.ResponseStatus, &d.CreatedAt, &d.ReplayedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ********** Implementation Find Dead Letter**********
+func (conn WebhookRepository) FindDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error) {
+	d, err := scanDeadLetter(conn.db.Conn(ctx).QueryRow(ctx,
+		`SELECT `+deadLetterColumns+` FROM webhook_dead_letters WHERE id = $1`, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, utils.ErrNotFound
+		}
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	return d, nil
+}
+
+// ********** Implementation Find All Dead Letter**********
+func (conn WebhookRepository) FindAllDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]webhookModel.DeadLetter, error) {
+	query := `SELECT ` + deadLetterColumns + ` FROM webhook_dead_letters`
+
+	var args []interface{}
+	var conditions []string
+
+	if filter.SubscriptionID != nil {
+		conditions = append(conditions, fmt.Sprintf("subscription_id = $%d", len(args)+1))
+		args = append(args, *filter.SubscriptionID)
+	}
+
+	if !filter.IncludeReplayed {
+		conditions = append(conditions, "replayed_at IS NULL")
+	}
+
+	if filter.BeforeID > 0 {
+		conditions = append(conditions, fmt.Sprintf("id < $%d", len(args)+1))
+		args = append(args, filter.BeforeID)
+	}
+
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY id DESC"
+
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, filter.Limit)
+	}
+
+	if filter.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, filter.Offset)
+	}
+
+	rows, err := conn.db.Conn(ctx).Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return nil, utils.MapDbError(err)
+	}
+	defer rows.Close()
+
+	var deadLetters []webhookModel.DeadLetter
+	for rows.Next() {
+		d, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, utils.MapDbError(err)
+		}
+		deadLetters = append(deadLetters, *d)
+	}
+	return deadLetters, rows.Err()
+}
+
+// ********** Implementation Mark Dead Letter Replayed**********
+func (conn WebhookRepository) MarkDeadLetterReplayed(ctx context.Context, id int64, attempts int) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE webhook_dead_letters SET replayed_at = NOW(), attempts = attempts + $2 WHERE id = $1`, id, attempts)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
+
+// ********** Implementation Mark Dead Letter Failed**********
+func (conn WebhookRepository) MarkDeadLetterFailed(ctx context.Context, id int64, attempts int, reason string, responseStatus *int) error {
+	_, err := conn.db.Conn(ctx).Exec(ctx,
+		`UPDATE webhook_dead_letters SET attempts = attempts + $2, last_error = $3, response_status = $4 WHERE id = $1`,
+		id, attempts, reason, responseStatus)
+	if err != nil {
+		logger.Error("Error: ", err.Error())
+		return utils.MapDbError(err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package webhookrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+)
+
+type DeadLetterFilter struct {
+	SubscriptionID  *int64
+	IncludeReplayed bool
+	BeforeID        int64 // keyset: hanya id lebih kecil (urutan terbaru dulu)
+	Limit           int
+	Offset          int
+}
+
+type WebhookRepoInterface interface {
+	CreateSubscription(ctx context.Context, s *webhookModel.Subscription) (int64, error)
+	UpdateSubscription(ctx context.Context, s *webhookModel.Subscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+	FindSubscription(ctx context.Context, id int64) (*webhookModel.Subscription, error)
+	FindAllSubscriptions(ctx context.Context) ([]webhookModel.Subscription, error)
+
+	// ------ DELIVERY ------
+	// Antrekan event ke setiap subscription aktif yang memilih eventType
+	// (atau "*"), event yang sudah ada di antrean diabaikan
+	EnqueueDeliveries(ctx context.Context, eventID int64, eventType string, payload []byte) error
+	// Antrekan replay dead letter, ErrConflict jika sudah diantrekan
+	EnqueueReplay(ctx context.Context, d *webhookModel.DeadLetter) error
+	// Klaim pengiriman yang sudah waktunya dicoba, baru bisa diklaim lagi
+	// setelah lease habis kecuali dijadwalkan ulang lewat RescheduleDelivery
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhookModel.Delivery, error)
+	RescheduleDelivery(ctx context.Context, id int64, reason string, responseStatus *int, next time.Time) error
+	DeleteDelivery(ctx context.Context, id int64) error
+
+	// ------ DEAD LETTER ------
+
+	CreateDeadLetter(ctx context.Context, d *webhookModel.DeadLetter) error
+	FindDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error)
+	FindAllDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]webhookModel.DeadLetter, error)
+
+	// Hasil replay: berhasil ditandai replayed, gagal mencatat error terakhir.
+	// attempts adalah jumlah request HTTP pada replay tersebut.
+	MarkDeadLetterReplayed(ctx context.Context, id int64, attempts int) error
+	MarkDeadLetterFailed(ctx context.Context, id int64, attempts int, reason string, responseStatus *int) error
+}
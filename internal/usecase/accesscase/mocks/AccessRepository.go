@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type AccessRepository struct {
+	mock.Mock
+}
+
+// RolePermissions Mock
+func (_m *AccessRepository) RolePermissions(ctx context.Context) (map[string][]string, error) {
+	args := _m.Called(ctx)
+	perms, _ := args.Get(0).(map[string][]string)
+	return perms, args.Error(1)
+}
+
+// FindAPIKey Mock
+func (_m *AccessRepository) FindAPIKey(ctx context.Context, keyHash string) (*userModel.APIKey, error) {
+	args := _m.Called(ctx, keyHash)
+	k, _ := args.Get(0).(*userModel.APIKey)
+	return k, args.Error(1)
+}
+
+// CreateAPIKey Mock
+func (_m *AccessRepository) CreateAPIKey(ctx context.Context, k *userModel.APIKey) (int64, error) {
+	args := _m.Called(ctx, k)
+	return args.Get(0).(int64), args.Error(1)
+}
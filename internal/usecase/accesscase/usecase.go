@@ -0,0 +1,131 @@
+package accesscase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/accessrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+// permissionCacheTTL membatasi berapa lama perubahan di tabel
+// role_permissions baru terlihat, supaya tidak query setiap request
+const permissionCacheTTL = time.Minute
+
+type AccessService interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+	IdentifyAPIKey(ctx context.Context, key string) (*userModel.Principal, error)
+	CreateAPIKey(ctx context.Context, name, role string) (string, error)
+}
+
+type AccessUseCase struct {
+	accessRepo Repository.AccessRepoInterface
+	now        func() time.Time
+
+	mu       sync.RWMutex
+	perms    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+func NewAccessService(accessRepo Repository.AccessRepoInterface) *AccessUseCase {
+	return &AccessUseCase{
+		accessRepo: accessRepo,
+		now:        time.Now,
+	}
+}
+
+func (s *AccessUseCase) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	perms, err := s.permissions(ctx)
+	if err != nil {
+		return false, err
+	}
+	return perms[role][permission], nil
+}
+
+// IdentifyAPIKey mengembalikan ErrUnauthorized untuk key yang tidak dikenal
+// atau sudah dinonaktifkan
+func (s *AccessUseCase) IdentifyAPIKey(ctx context.Context, key string) (*userModel.Principal, error) {
+	apiKey, err := s.accessRepo.FindAPIKey(ctx, hashKey(key))
+	if err != nil {
+		if errors.Is(err, errorUtils.ErrNotFound) {
+			return nil, errorUtils.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	return &userModel.Principal{
+		Username: apiKey.Name,
+		Role:     apiKey.Role,
+		Source:   userModel.SourceAPIKey,
+	}, nil
+}
+
+// CreateAPIKey membuat key acak; hanya hash yang disimpan, key aslinya
+// dikembalikan sekali ke pemanggil
+func (s *AccessUseCase) CreateAPIKey(ctx context.Context, name, role string) (string, error) {
+	if name == "" || role == "" {
+		return "", errorUtils.ErrBadRequest
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	key := base64.RawURLEncoding.EncodeToString(b)
+
+	_, err := s.accessRepo.CreateAPIKey(ctx, &userModel.APIKey{
+		Name:     name,
+		KeyHash:  hashKey(key),
+		Role:     role,
+		IsActive: true,
+	})
+	if err != nil {
+		logger.Errorf("Create api key fail, error: %s", err)
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *AccessUseCase) permissions(ctx context.Context) (map[string]map[string]bool, error) {
+	s.mu.RLock()
+	perms, loadedAt := s.perms, s.loadedAt
+	s.mu.RUnlock()
+
+	if perms != nil && s.now().Sub(loadedAt) < permissionCacheTTL {
+		return perms, nil
+	}
+
+	rows, err := s.accessRepo.RolePermissions(ctx)
+	if err != nil {
+		logger.Errorf("Load role permissions fail, error: %s", err)
+		return nil, err
+	}
+
+	perms = make(map[string]map[string]bool, len(rows))
+	for role, codes := range rows {
+		perms[role] = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			perms[role][code] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.perms, s.loadedAt = perms, s.now()
+	s.mu.Unlock()
+
+	return perms, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package accesscase
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/accesscase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+func TestAccessUseCase_HasPermission(t *testing.T) {
+	repo := new(mocks.AccessRepository)
+	uc := NewAccessService(repo)
+
+	repo.On("RolePermissions", mock.Anything).Return(map[string][]string{
+		userModel.RoleCashier:    {userModel.PermCatalogRead},
+		userModel.RoleSupervisor: {userModel.PermCatalogRead, userModel.PermProductArchive},
+	}, nil).Once()
+
+	tests := []struct {
+		role, perm string
+		want       bool
+	}{
+		{userModel.RoleCashier, userModel.PermCatalogRead, true},
+		{userModel.RoleCashier, userModel.PermProductArchive, false},
+		{userModel.RoleSupervisor, userModel.PermProductArchive, true},
+		{userModel.RoleSupervisor, userModel.PermProductWrite, false},
+		{"unknown", userModel.PermCatalogRead, false},
+	}
+
+	for _, tt := range tests {
+		got, err := uc.HasPermission(context.Background(), tt.role, tt.perm)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %s", tt.role, tt.perm)
+	}
+
+	// permission hanya dimuat sekali selama cache masih berlaku
+	repo.AssertNumberOfCalls(t, "RolePermissions", 1)
+}
+
+func TestAccessUseCase_HasPermission_CacheExpires(t *testing.T) {
+	repo := new(mocks.AccessRepository)
+	uc := NewAccessService(repo)
+
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	repo.On("RolePermissions", mock.Anything).
+		Return(map[string][]string{userModel.RoleCashier: {}}, nil).Once()
+	repo.On("RolePermissions", mock.Anything).
+		Return(map[string][]string{userModel.RoleCashier: {userModel.PermStockWrite}}, nil).Once()
+
+	ok, err := uc.HasPermission(context.Background(), userModel.RoleCashier, userModel.PermStockWrite)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	now = now.Add(permissionCacheTTL)
+
+	ok, err = uc.HasPermission(context.Background(), userModel.RoleCashier, userModel.PermStockWrite)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestAccessUseCase_IdentifyAPIKey(t *testing.T) {
+	repo := new(mocks.AccessRepository)
+	uc := NewAccessService(repo)
+
+	repo.On("FindAPIKey", mock.Anything, hashKey("good-key")).
+		Return(&userModel.APIKey{ID: 1, Name: "pos-01", Role: userModel.RoleCashier, IsActive: true}, nil)
+	repo.On("FindAPIKey", mock.Anything, hashKey("bad-key")).
+		Return(nil, errorUtils.ErrNotFound)
+
+	p, err := uc.IdentifyAPIKey(context.Background(), "good-key")
+	require.NoError(t, err)
+	assert.Equal(t, userModel.RoleCashier, p.Role)
+	assert.Equal(t, userModel.SourceAPIKey, p.Source)
+
+	_, err = uc.IdentifyAPIKey(context.Background(), "bad-key")
+	assert.ErrorIs(t, err, errorUtils.ErrUnauthorized)
+}
@@ -0,0 +1,11 @@
+package mocks
+
+import "context"
+
+// TxManager menjalankan fn langsung tanpa transaksi database
+type TxManager struct{}
+
+// WithinTransaction TxManager Mock
+func (TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
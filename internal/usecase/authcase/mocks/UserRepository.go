@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type UserRepository struct {
+	mock.Mock
+}
+
+// Create User Mock
+func (_m *UserRepository) Create(ctx context.Context, u *userModel.User) (int64, error) {
+	args := _m.Called(ctx, u)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// FindByUsername Mock
+func (_m *UserRepository) FindByUsername(ctx context.Context, username string) (*userModel.User, error) {
+	args := _m.Called(ctx, username)
+	u, _ := args.Get(0).(*userModel.User)
+	return u, args.Error(1)
+}
+
+// FindByID Mock
+func (_m *UserRepository) FindByID(ctx context.Context, id int64) (*userModel.User, error) {
+	args := _m.Called(ctx, id)
+	u, _ := args.Get(0).(*userModel.User)
+	return u, args.Error(1)
+}
+
+// CreateRefreshToken Mock
+func (_m *UserRepository) CreateRefreshToken(ctx context.Context, t *userModel.RefreshToken) error {
+	args := _m.Called(ctx, t)
+	return args.Error(0)
+}
+
+// FindRefreshToken Mock
+func (_m *UserRepository) FindRefreshToken(ctx context.Context, tokenHash string) (*userModel.RefreshToken, error) {
+	args := _m.Called(ctx, tokenHash)
+	t, _ := args.Get(0).(*userModel.RefreshToken)
+	return t, args.Error(1)
+}
+
+// RevokeRefreshToken Mock
+func (_m *UserRepository) RevokeRefreshToken(ctx context.Context, id int64) (bool, error) {
+	args := _m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// RevokeUserRefreshTokens Mock
+func (_m *UserRepository) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	args := _m.Called(ctx, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,222 @@
+package authcase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/userrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultRole       = userModel.RoleCashier
+	minPasswordLength = 8
+)
+
+// dummyHash dipakai saat username tidak ditemukan supaya waktu respons login
+// tidak membocorkan username mana yang terdaftar
+const dummyHash = "$2a$10$iqP0EYvh9Qt878rVZrBqieJYxoqzcORJjFUAXDsvFMvnXo1qidXVO"
+
+type AuthService interface {
+	Login(ctx context.Context, username, password string) (*userModel.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*userModel.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	CreateUser(ctx context.Context, u *userModel.User, password string) (*int64, error)
+}
+
+// TokenIssuer menerbitkan access token (lihat pkgs/jwt)
+type TokenIssuer interface {
+	Issue(userID int64, username, role string) (string, time.Time, error)
+}
+
+type AuthUseCase struct {
+	userRepo   Repository.UserRepoInterface
+	txManager  txmanager.TxManager
+	tokens     TokenIssuer
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewAuthService(
+	userRepo Repository.UserRepoInterface,
+	txManager txmanager.TxManager,
+	tokens TokenIssuer,
+	refreshTTL time.Duration,
+) *AuthUseCase {
+	return &AuthUseCase{
+		userRepo:   userRepo,
+		txManager:  txManager,
+		tokens:     tokens,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+func (s *AuthUseCase) Login(ctx context.Context, username, password string) (*userModel.TokenPair, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, errorUtils.ErrNotFound) {
+			bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+			return nil, errorUtils.ErrUnauthorized
+		}
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errorUtils.ErrUnauthorized
+	}
+	if !user.IsActive {
+		return nil, errorUtils.ErrUnauthorized
+	}
+
+	return s.issue(ctx, user)
+}
+
+// Refresh menukar refresh token dengan pasangan token baru (rotasi).
+// Token lama langsung dicabut; jika token yang sudah dicabut dipakai lagi,
+// semua sesi user dicabut karena token kemungkinan besar bocor.
+func (s *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (*userModel.TokenPair, error) {
+	var (
+		pair        *userModel.TokenPair
+		reusedByUID int64
+	)
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.userRepo.FindRefreshToken(ctx, hashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, errorUtils.ErrNotFound) {
+				return errorUtils.ErrUnauthorized
+			}
+			return err
+		}
+
+		if stored.RevokedAt != nil {
+			reusedByUID = stored.UserID
+			return errReused
+		}
+		if !s.now().Before(stored.ExpiresAt) {
+			return errorUtils.ErrUnauthorized
+		}
+
+		revoked, err := s.userRepo.RevokeRefreshToken(ctx, stored.ID)
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return errorUtils.ErrUnauthorized
+		}
+
+		user, err := s.userRepo.FindByID(ctx, stored.UserID)
+		if err != nil {
+			return err
+		}
+		if !user.IsActive {
+			return errorUtils.ErrUnauthorized
+		}
+
+		pair, err = s.issue(ctx, user)
+		return err
+	})
+	// transaksi di atas sudah rollback, jadi pencabutan sesi dijalankan terpisah
+	if errors.Is(err, errReused) {
+		logger.Errorf("refresh token reused, revoking all sessions of user %d", reusedByUID)
+		if err := s.userRepo.RevokeUserRefreshTokens(ctx, reusedByUID); err != nil {
+			return nil, err
+		}
+		return nil, errorUtils.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+func (s *AuthUseCase) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.userRepo.FindRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, errorUtils.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	_, err = s.userRepo.RevokeRefreshToken(ctx, stored.ID)
+	return err
+}
+
+func (s *AuthUseCase) CreateUser(ctx context.Context, u *userModel.User, password string) (*int64, error) {
+	if u.Username == "" || len(password) < minPasswordLength {
+		return nil, errorUtils.ErrBadRequest
+	}
+	if u.Role == "" {
+		u.Role = DefaultRole
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	u.PasswordHash = string(hash)
+	u.IsActive = true
+
+	id, err := s.userRepo.Create(ctx, u)
+	if err != nil {
+		logger.Errorf("Create user fail, error: %s", err)
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+func (s *AuthUseCase) issue(ctx context.Context, user *userModel.User) (*userModel.TokenPair, error) {
+	access, accessExp, err := s.tokens.Issue(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := &userModel.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: s.now().Add(s.refreshTTL),
+	}
+	if err := s.userRepo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	return &userModel.TokenPair{
+		AccessToken:      access,
+		RefreshToken:     raw,
+		AccessExpiresAt:  accessExp,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// errReused hanya dipakai di dalam Refresh untuk membedakan reuse token
+var errReused = errors.New("refresh token reused")
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
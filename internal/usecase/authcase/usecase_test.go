@@ -0,0 +1,140 @@
+package authcase
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/userModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/authcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/jwt"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+func newTestService(t *testing.T, repo *mocks.UserRepository) (*AuthUseCase, *jwt.Manager) {
+	t.Helper()
+
+	key, err := jwt.GenerateKey()
+	require.NoError(t, err)
+	tokens, err := jwt.New(key, "test", 15*time.Minute)
+	require.NoError(t, err)
+
+	return NewAuthService(repo, mocks.TxManager{}, tokens, 24*time.Hour), tokens
+}
+
+func testUser(t *testing.T, password string) *userModel.User {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	return &userModel.User{ID: 7, Username: "kasir1", PasswordHash: string(hash), Role: "cashier", IsActive: true}
+}
+
+func TestAuthUseCase_Login_Success(t *testing.T) {
+	repo := new(mocks.UserRepository)
+	uc, tokens := newTestService(t, repo)
+
+	repo.On("FindByUsername", mock.Anything, "kasir1").Return(testUser(t, "rahasia123"), nil).Once()
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*userModel.RefreshToken")).Return(nil).Once()
+
+	pair, err := uc.Login(context.Background(), "kasir1", "rahasia123")
+	require.NoError(t, err)
+	assert.NotEmpty(t, pair.RefreshToken)
+
+	claims, err := tokens.Verify(pair.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "cashier", claims.Role)
+
+	// yang disimpan hanya hash, bukan token aslinya
+	stored := repo.Calls[1].Arguments.Get(1).(*userModel.RefreshToken)
+	assert.Equal(t, hashToken(pair.RefreshToken), stored.TokenHash)
+	assert.NotEqual(t, pair.RefreshToken, stored.TokenHash)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuthUseCase_Login_Rejected(t *testing.T) {
+	inactive := testUser(t, "rahasia123")
+	inactive.IsActive = false
+
+	tests := []struct {
+		name     string
+		user     *userModel.User
+		findErr  error
+		password string
+	}{
+		{name: "unknown user", findErr: errorUtils.ErrNotFound, password: "rahasia123"},
+		{name: "wrong password", user: testUser(t, "rahasia123"), password: "salah"},
+		{name: "inactive user", user: inactive, password: "rahasia123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.UserRepository)
+			uc, _ := newTestService(t, repo)
+
+			repo.On("FindByUsername", mock.Anything, "kasir1").Return(tt.user, tt.findErr).Once()
+
+			_, err := uc.Login(context.Background(), "kasir1", tt.password)
+			assert.ErrorIs(t, err, errorUtils.ErrUnauthorized)
+			repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAuthUseCase_Refresh_Rotates(t *testing.T) {
+	repo := new(mocks.UserRepository)
+	uc, _ := newTestService(t, repo)
+
+	stored := &userModel.RefreshToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(time.Hour)}
+	repo.On("FindRefreshToken", mock.Anything, hashToken("old-token")).Return(stored, nil).Once()
+	repo.On("RevokeRefreshToken", mock.Anything, int64(3)).Return(true, nil).Once()
+	repo.On("FindByID", mock.Anything, int64(7)).Return(testUser(t, "rahasia123"), nil).Once()
+	repo.On("CreateRefreshToken", mock.Anything, mock.AnythingOfType("*userModel.RefreshToken")).Return(nil).Once()
+
+	pair, err := uc.Refresh(context.Background(), "old-token")
+	require.NoError(t, err)
+	assert.NotEqual(t, "old-token", pair.RefreshToken)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuthUseCase_Refresh_ReuseRevokesSessions(t *testing.T) {
+	repo := new(mocks.UserRepository)
+	uc, _ := newTestService(t, repo)
+
+	revokedAt := time.Now().Add(-time.Minute)
+	stored := &userModel.RefreshToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt}
+	repo.On("FindRefreshToken", mock.Anything, hashToken("old-token")).Return(stored, nil).Once()
+	repo.On("RevokeUserRefreshTokens", mock.Anything, int64(7)).Return(nil).Once()
+
+	_, err := uc.Refresh(context.Background(), "old-token")
+	assert.ErrorIs(t, err, errorUtils.ErrUnauthorized)
+
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+}
+
+func TestAuthUseCase_Refresh_Expired(t *testing.T) {
+	repo := new(mocks.UserRepository)
+	uc, _ := newTestService(t, repo)
+
+	stored := &userModel.RefreshToken{ID: 3, UserID: 7, ExpiresAt: time.Now().Add(-time.Second)}
+	repo.On("FindRefreshToken", mock.Anything, hashToken("old-token")).Return(stored, nil).Once()
+
+	_, err := uc.Refresh(context.Background(), "old-token")
+	assert.ErrorIs(t, err, errorUtils.ErrUnauthorized)
+	repo.AssertNotCalled(t, "RevokeRefreshToken", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,263 @@
+package exportcase
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+	FormatXLSX  = "xlsx"
+)
+
+// Kolom export sama dengan kolom import, ditambah status yang diabaikan
+// saat import. File hasil export bisa langsung di-import ulang.
+const ColStatus = "status"
+
+var Columns = []string{
+	importcase.ColProductName,
+	importcase.ColDescription,
+	importcase.ColCategories,
+	importcase.ColStockPolicy,
+	ColStatus,
+	importcase.ColSKU,
+	importcase.ColBaseUnit,
+	importcase.ColCostPrice,
+	importcase.ColStock,
+	importcase.ColOptions,
+	importcase.ColUnitName,
+	importcase.ColConversionRate,
+	importcase.ColPrice,
+	importcase.ColBarcode,
+}
+
+// Writer menulis baris export ke w dalam satu format. Header ditulis saat
+// baris pertama atau Close, sehingga sebelum itu belum ada byte yang keluar.
+type Writer interface {
+	Write(row productModel.ExportRow) error
+	Close() error
+	// Started true jika sudah ada data yang ditulis ke w
+	Started() bool
+}
+
+// ContentType dan ekstensi file per format
+func ContentType(format string) (string, string, error) {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8", ".csv", nil
+	case FormatJSONL:
+		return "application/x-ndjson", ".jsonl", nil
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx", nil
+	}
+	return "", "", errorUtils.ErrBadRequest
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlWriter{enc: json.NewEncoder(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, errorUtils.ErrBadRequest
+}
+
+// record kolom satu baris dalam urutan Columns
+func record(row productModel.ExportRow) []any {
+	barcode := ""
+	if row.Barcode != nil {
+		barcode = *row.Barcode
+	}
+
+	return []any{
+		row.ProductName,
+		row.Description,
+		strings.Join(row.Categories, " | "),
+		row.StockPolicy,
+		row.Status,
+		row.SKU,
+		row.BaseUnit,
+		row.CostPrice,
+		row.Stock,
+		formatOptions(row.Options),
+		row.UnitName,
+		row.ConversionRate,
+		row.Price,
+		barcode,
+	}
+}
+
+// formatOptions []VariantOption -> "color=red; size=M"
+func formatOptions(options []productModel.VariantOption) string {
+	parts := make([]string, 0, len(options))
+	for _, o := range options {
+		parts = append(parts, o.Name+"="+o.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ********** CSV **********
+
+type csvWriter struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (c *csvWriter) start() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	return c.w.Write(Columns)
+}
+
+func (c *csvWriter) Write(row productModel.ExportRow) error {
+	if err := c.start(); err != nil {
+		return err
+	}
+
+	fields := make([]string, 0, len(Columns))
+	for _, v := range record(row) {
+		fields = append(fields, fmt.Sprint(v))
+	}
+	return c.w.Write(fields)
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.start(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Started() bool { return c.started }
+
+// ********** JSON Lines **********
+
+type jsonlRow struct {
+	ProductID      int64             `json:"product_id"`
+	ProductName    string            `json:"product_name"`
+	Description    string            `json:"description"`
+	Categories     []string          `json:"categories"`
+	StockPolicy    string            `json:"stock_policy"`
+	Status         string            `json:"status"`
+	VariantID      int64             `json:"variant_id"`
+	SKU            string            `json:"sku"`
+	BaseUnit       string            `json:"base_unit"`
+	CostPrice      int64             `json:"cost_price"`
+	Stock          int               `json:"stock"`
+	Options        map[string]string `json:"options"`
+	UnitID         int64             `json:"unit_id"`
+	UnitName       string            `json:"unit_name"`
+	ConversionRate int               `json:"conversion_rate"`
+	Price          int64             `json:"price"`
+	Barcode        *string           `json:"barcode"`
+}
+
+type jsonlWriter struct {
+	enc     *json.Encoder
+	started bool
+}
+
+func (j *jsonlWriter) Write(row productModel.ExportRow) error {
+	j.started = true
+
+	options := make(map[string]string, len(row.Options))
+	for _, o := range row.Options {
+		options[o.Name] = o.Value
+	}
+	categories := row.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+
+	return j.enc.Encode(jsonlRow{
+		ProductID:      row.ProductID,
+		ProductName:    row.ProductName,
+		Description:    row.Description,
+		Categories:     categories,
+		StockPolicy:    row.StockPolicy,
+		Status:         row.Status,
+		VariantID:      row.VariantID,
+		SKU:            row.SKU,
+		BaseUnit:       row.BaseUnit,
+		CostPrice:      row.CostPrice,
+		Stock:          row.Stock,
+		Options:        options,
+		UnitID:         row.UnitID,
+		UnitName:       row.UnitName,
+		ConversionRate: row.ConversionRate,
+		Price:          row.Price,
+		Barcode:        row.Barcode,
+	})
+}
+
+func (j *jsonlWriter) Close() error { return nil }
+
+func (j *jsonlWriter) Started() bool { return j.started }
+
+// ********** XLSX **********
+
+// xlsxWriter memakai StreamWriter excelize: baris ditampung di file
+// sementara (bukan memori) dan zip baru ditulis ke w saat Close.
+type xlsxWriter struct {
+	w       io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	line    int
+	started bool
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(file.GetSheetName(0))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]any, 0, len(Columns))
+	for _, c := range Columns {
+		header = append(header, c)
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{w: w, file: file, stream: stream, line: 1}, nil
+}
+
+func (x *xlsxWriter) Write(row productModel.ExportRow) error {
+	x.line++
+	cell, err := excelize.CoordinatesToCellName(1, x.line)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, record(row))
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	x.started = true
+	return x.file.Write(x.w)
+}
+
+func (x *xlsxWriter) Started() bool { return x.started }
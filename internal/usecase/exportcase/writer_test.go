@@ -0,0 +1,159 @@
+package exportcase
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase"
+	importMocks "github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase/mocks"
+	productMocks "github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+func exportRows() []productModel.ExportRow {
+	barcode := "899001"
+	return []productModel.ExportRow{
+		{
+			ProductID: 1, ProductName: "Cola", Description: "Fizzy, cold", Status: "active", StockPolicy: "block",
+			Categories: []string{"Drinks > Soda", "Promo"},
+			VariantID:  10, SKU: "COLA-330", BaseUnit: "pcs", Stock: 24, CostPrice: 4000,
+			Options: []productModel.VariantOption{{Name: "size", Value: "330ml"}, {Name: "pack", Value: "can"}},
+			UnitID:  100, UnitName: "pcs", ConversionRate: 1, Price: 6000, Barcode: &barcode,
+		},
+		{
+			ProductID: 1, ProductName: "Cola", Description: "Fizzy, cold", Status: "active", StockPolicy: "block",
+			Categories: []string{"Drinks > Soda", "Promo"},
+			VariantID:  10, SKU: "COLA-330", BaseUnit: "pcs", Stock: 24, CostPrice: 4000,
+			Options: []productModel.VariantOption{{Name: "size", Value: "330ml"}, {Name: "pack", Value: "can"}},
+			UnitID:  101, UnitName: "pack", ConversionRate: 6, Price: 33000,
+		},
+	}
+}
+
+func write(t *testing.T, format string, rows []productModel.ExportRow) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer, err := NewWriter(format, &buf)
+	require.NoError(t, err)
+	assert.False(t, writer.Started())
+
+	for _, row := range rows {
+		require.NoError(t, writer.Write(row))
+	}
+	require.NoError(t, writer.Close())
+	assert.True(t, writer.Started())
+
+	return buf.Bytes()
+}
+
+// importFile menjalankan import dry-run atas hasil export
+func importFile(t *testing.T, name string, content []byte) *importcase.Report {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	part, _ := mw.CreateFormFile("file", name)
+	part.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	require.NoError(t, req.ParseMultipartForm(10<<20))
+	file, fh, err := req.FormFile("file")
+	require.NoError(t, err)
+	file.Close()
+
+	parent := int64(1)
+	productRepo := new(productMocks.ProductRepository)
+	productRepo.On("FindUsedCodes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, nil)
+	categoryRepo := new(productMocks.CategoryRepository)
+	categoryRepo.On("FindAllCategory", mock.Anything).Return([]productModel.Category{
+		{ID: 1, Name: "Drinks"},
+		{ID: 2, Name: "Soda", ParentID: &parent},
+		{ID: 3, Name: "Promo"},
+	}, nil)
+
+	importer := importcase.NewProductImporter(productRepo, categoryRepo, importMocks.TxManager{}, validation.New())
+	report, err := importer.Import(context.Background(), fh, true)
+	require.NoError(t, err)
+	return report
+}
+
+func TestWriter_CSVRoundTrip(t *testing.T) {
+	content := write(t, FormatCSV, exportRows())
+
+	lines := bytes.Split(bytes.TrimSpace(content), []byte("\n"))
+	require.Len(t, lines, 3)
+	assert.Equal(t, "product_name,description,categories,stock_policy,status,sku,base_unit,cost_price,stock,options,unit_name,conversion_rate,price,barcode", string(lines[0]))
+	assert.Equal(t, `Cola,"Fizzy, cold",Drinks > Soda | Promo,block,active,COLA-330,pcs,4000,24,size=330ml; pack=can,pcs,1,6000,899001`, string(lines[1]))
+
+	report := importFile(t, "export.csv", content)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.Products)
+	assert.Equal(t, 2, report.Units)
+}
+
+func TestWriter_XLSXRoundTrip(t *testing.T) {
+	content := write(t, FormatXLSX, exportRows())
+
+	f, err := excelize.OpenReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	rows, err := f.GetRows(f.GetSheetName(0))
+	require.NoError(t, err)
+	f.Close()
+
+	require.Len(t, rows, 3)
+	assert.Equal(t, Columns, rows[0])
+	assert.Equal(t, "33000", rows[2][12])
+
+	report := importFile(t, "export.xlsx", content)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 2, report.Units)
+}
+
+func TestWriter_JSONL(t *testing.T) {
+	content := write(t, FormatJSONL, exportRows())
+
+	var rows []map[string]any
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var row map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		rows = append(rows, row)
+	}
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, "COLA-330", rows[0]["sku"])
+	assert.Equal(t, map[string]any{"size": "330ml", "pack": "can"}, rows[0]["options"])
+	assert.Equal(t, []any{"Drinks > Soda", "Promo"}, rows[0]["categories"])
+	assert.Nil(t, rows[1]["barcode"])
+}
+
+func TestWriter_EmptyCSVHasHeader(t *testing.T) {
+	content := write(t, FormatCSV, nil)
+	assert.Equal(t, "product_name,description,categories,stock_policy,status,sku,base_unit,cost_price,stock,options,unit_name,conversion_rate,price,barcode\n", string(content))
+}
+
+func TestNewWriter_UnknownFormat(t *testing.T) {
+	_, err := NewWriter("pdf", &bytes.Buffer{})
+	assert.Equal(t, errorUtils.ErrBadRequest, err)
+}
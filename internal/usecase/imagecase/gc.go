@@ -0,0 +1,161 @@
+package imagecase
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+)
+
+// ImageReferences adalah sumber URL gambar yang masih dipakai
+// (productrepo.ProductRepository)
+type ImageReferences interface {
+	ListImageURLs(ctx context.Context) ([]string, error)
+}
+
+// GarbageCollector mencari file di storage yang tidak direferensikan
+// product_images maupun rendition-nya, misalnya upload yang DB update-nya
+// gagal, ImageDelete yang gagal setelah commit, atau file ber-hash yang
+// referensi terakhirnya sudah dilepas.
+type GarbageCollector struct {
+	Storage    storage.Driver
+	References ImageReferences
+
+	// Prefix adalah folder gambar (IMAGE_PATH) yang di-scan, wajib diisi
+	// supaya GC tidak menyapu seluruh storage
+	Prefix string
+
+	// file yang lebih muda dari GracePeriod dilewati karena bisa jadi
+	// request upload-nya belum selesai commit
+	GracePeriod time.Duration
+
+	// QuarantinePrefix kosong berarti file yatim dihapus permanen,
+	// selain itu dipindahkan ke <QuarantinePrefix>/<key>
+	QuarantinePrefix string
+
+	now func() time.Time
+}
+
+type GCReport struct {
+	Scanned     int
+	Referenced  int
+	TooRecent   int
+	Orphans     []storage.Object
+	OrphanBytes int64
+	Removed     int
+	Failed      int
+}
+
+// ErrEmptyPrefix dikembalikan Run jika Prefix (IMAGE_PATH) kosong
+var ErrEmptyPrefix = errors.New("imagegc: image prefix (IMAGE_PATH) is required")
+
+// Run mencari file yatim. dryRun true hanya membuat laporan tanpa
+// menyentuh file.
+func (gc *GarbageCollector) Run(ctx context.Context, dryRun bool) (*GCReport, error) {
+	// dicocokkan per folder: "uploads" tidak ikut menyapu "uploads_old/..."
+	prefix := strings.Trim(gc.Prefix, "/")
+	if prefix == "" {
+		return nil, ErrEmptyPrefix
+	}
+	prefix += "/"
+
+	now := time.Now
+	if gc.now != nil {
+		now = gc.now
+	}
+
+	// referensi dibaca sebelum listing: file yang di-commit setelah ini
+	// masih terlindungi grace period
+	urls, err := gc.References.ListImageURLs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]struct{}, len(urls))
+	for _, url := range urls {
+		referenced[gc.Storage.Key(url)] = struct{}{}
+	}
+
+	report := &GCReport{}
+	cutoff := now().Add(-gc.GracePeriod)
+
+	err = gc.Storage.List(ctx, prefix, func(obj storage.Object) error {
+		if gc.QuarantinePrefix != "" && strings.HasPrefix(obj.Key, gc.QuarantinePrefix+"/") {
+			return nil
+		}
+
+		report.Scanned++
+		_, ok := referenced[obj.Key]
+		switch {
+		case ok:
+			report.Referenced++
+		case obj.ModTime.After(cutoff):
+			report.TooRecent++
+		default:
+			report.Orphans = append(report.Orphans, obj)
+			report.OrphanBytes += obj.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, obj := range report.Orphans {
+		if err := gc.remove(ctx, obj); err != nil {
+			logger.Errorf("imagegc: failed to remove %s: %v", obj.Key, err)
+			report.Failed++
+			continue
+		}
+		report.Removed++
+	}
+
+	return report, nil
+}
+
+// Start menjalankan Run setiap interval sampai ctx selesai
+func (gc *GarbageCollector) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := gc.Run(ctx, false)
+			if err != nil {
+				logger.Errorf("imagegc: %v", err)
+				continue
+			}
+			logger.Infof("imagegc: scanned %d files, removed %d orphans (%d bytes), %d failed",
+				report.Scanned, report.Removed, report.OrphanBytes, report.Failed)
+		}
+	}
+}
+
+func (gc *GarbageCollector) remove(ctx context.Context, obj storage.Object) error {
+	if gc.QuarantinePrefix == "" {
+		return gc.Storage.Delete(ctx, obj.Key)
+	}
+
+	src, err := gc.Storage.Get(ctx, obj.Key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst := path.Join(gc.QuarantinePrefix, obj.Key)
+	if err := gc.Storage.Put(ctx, dst, src, obj.Size, mime.TypeByExtension(path.Ext(obj.Key))); err != nil {
+		return err
+	}
+	return gc.Storage.Delete(ctx, obj.Key)
+}
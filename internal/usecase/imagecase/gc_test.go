@@ -0,0 +1,118 @@
+package imagecase
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newGCFixture menyiapkan tiga file: satu dipakai product, satu yatim lama,
+// satu yatim yang masih dalam grace period
+func newGCFixture(t *testing.T) (string, *storage.Local, *mocks.ProductRepository) {
+	t.Helper()
+
+	root := t.TempDir()
+	store := storage.NewLocal(root)
+	ctx := context.Background()
+
+	for _, key := range []string{"uploads/used.jpg", "uploads/orphan.jpg", "uploads/fresh.jpg"} {
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader([]byte("img")), 3, "image/jpeg"))
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, "uploads/used.jpg"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(root, "uploads/orphan.jpg"), old, old))
+
+	repo := new(mocks.ProductRepository)
+	repo.On("ListImageURLs", mock.Anything).Return([]string{"/uploads/used.jpg"}, nil)
+
+	return root, store, repo
+}
+
+func TestGarbageCollector_DryRun(t *testing.T) {
+	root, store, repo := newGCFixture(t)
+
+	gc := &GarbageCollector{Storage: store, References: repo, Prefix: "uploads", GracePeriod: 24 * time.Hour}
+
+	report, err := gc.Run(context.Background(), true)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, 1, report.Referenced)
+	assert.Equal(t, 1, report.TooRecent)
+	require.Len(t, report.Orphans, 1)
+	assert.Equal(t, "uploads/orphan.jpg", report.Orphans[0].Key)
+	assert.Equal(t, int64(3), report.OrphanBytes)
+	assert.Zero(t, report.Removed)
+
+	assert.FileExists(t, filepath.Join(root, "uploads/orphan.jpg"))
+}
+
+func TestGarbageCollector_Delete(t *testing.T) {
+	root, store, repo := newGCFixture(t)
+
+	gc := &GarbageCollector{Storage: store, References: repo, Prefix: "uploads", GracePeriod: 24 * time.Hour}
+
+	report, err := gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Removed)
+
+	assert.NoFileExists(t, filepath.Join(root, "uploads/orphan.jpg"))
+	assert.FileExists(t, filepath.Join(root, "uploads/used.jpg"))
+	assert.FileExists(t, filepath.Join(root, "uploads/fresh.jpg"))
+}
+
+func TestGarbageCollector_Quarantine(t *testing.T) {
+	root, store, repo := newGCFixture(t)
+
+	gc := &GarbageCollector{
+		Storage:          store,
+		References:       repo,
+		Prefix:           "uploads",
+		GracePeriod:      24 * time.Hour,
+		QuarantinePrefix: "quarantine",
+	}
+
+	report, err := gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Removed)
+
+	assert.NoFileExists(t, filepath.Join(root, "uploads/orphan.jpg"))
+	assert.FileExists(t, filepath.Join(root, "quarantine/uploads/orphan.jpg"))
+
+	// run berikutnya tidak men-scan folder karantina
+	report, err = gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Scanned)
+	assert.Empty(t, report.Orphans)
+}
+
+func TestGarbageCollector_Prefix(t *testing.T) {
+	root, store, repo := newGCFixture(t)
+
+	// folder lain dengan awalan nama yang sama tidak boleh ikut tersapu
+	sibling := "uploads_old/orphan.jpg"
+	require.NoError(t, store.Put(context.Background(), sibling, bytes.NewReader([]byte("img")), 3, "image/jpeg"))
+	old := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(root, sibling), old, old))
+
+	gc := &GarbageCollector{Storage: store, References: repo, Prefix: "uploads/", GracePeriod: 24 * time.Hour}
+	report, err := gc.Run(context.Background(), false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Scanned)
+	assert.FileExists(t, filepath.Join(root, sibling))
+
+	// prefix kosong berarti seluruh storage, ditolak
+	gc.Prefix = ""
+	_, err = gc.Run(context.Background(), false)
+	assert.ErrorIs(t, err, ErrEmptyPrefix)
+}
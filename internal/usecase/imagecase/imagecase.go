@@ -1,66 +1,134 @@
 package imagecase
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"mime/multipart"
-	"os"
 	"path"
-	"path/filepath"
-	"time"
 
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
 )
 
 type ImageService interface {
-	ImageUpload(ctx context.Context, file *multipart.FileHeader) (string, error)
-	ImageDelete(ctx context.Context, publicPath string) error
+	// ImageUpload memvalidasi gambar lalu menyimpan rendition original,
+	// medium dan thumbnail. URL hasil adalah URL original.
+	ImageUpload(ctx context.Context, file *multipart.FileHeader) (*productModel.ProductImage, error)
+
+	// ImageDelete menghapus semua rendition milik gambar. Hanya untuk
+	// gambar tanpa Hash, file ber-hash bisa dipakai bersama dan dihapus
+	// oleh GarbageCollector.
+	ImageDelete(ctx context.Context, img productModel.ProductImage) error
 }
 
+// ImageUploadService menyimpan gambar lewat storage driver (disk lokal atau
+// MinIO/S3) di bawah folder PublicPath
 type ImageUploadService struct {
-	StoragePath string
-	PublicPath  string
+	Storage    storage.Driver
+	PublicPath string
+	Limits     Limits // zero value memakai DefaultLimits
 }
 
-func (s *ImageUploadService) ImageUpload(ctx context.Context, file *multipart.FileHeader) (string, error) {
+func (s *ImageUploadService) ImageUpload(ctx context.Context, file *multipart.FileHeader) (*productModel.ProductImage, error) {
+	limits := s.limits()
+	if file.Size > limits.MaxBytes {
+		return nil, errorUtils.ErrBadRequest
+	}
 
 	src, err := file.Open()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer src.Close()
 
-	if err := os.MkdirAll(s.StoragePath, 0755); err != nil {
-		return "", err
+	data, err := io.ReadAll(io.LimitReader(src, limits.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, errorUtils.ErrBadRequest
 	}
 
-	extension := filepath.Ext(file.Filename)
-	newFileName := fmt.Sprintf("%d%s", time.Now().UnixNano(), extension)
-
-	storagePath := fmt.Sprintf("%s/%s", s.StoragePath, s.PublicPath)
-	dstPath := filepath.Join(storagePath, newFileName)
-	dst, err := os.Create(dstPath)
+	// tipe file ditentukan dari isi, bukan dari nama/ekstensi
+	renditions, err := process(data, limits)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, src); err != nil {
-		return "", err
-	}
+	// nama file = hash isi file, upload gambar yang sama tidak membuat file baru
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	base := path.Join(s.PublicPath, hash)
+
+	image := &productModel.ProductImage{Hash: hash}
+	var written []string
+	for _, r := range renditions {
+		key := base + r.suffix + r.ext
 
-	publicPath := path.Join(s.PublicPath, newFileName)
-	return publicPath, nil
+		// file yang sudah ada tetap ditulis ulang (isinya sama) supaya waktu
+		// modifikasinya baru dan tidak dianggap yatim oleh GarbageCollector
+		exists, err := s.Storage.Exists(ctx, key)
+		if err == nil {
+			err = s.Storage.Put(ctx, key, bytes.NewReader(r.data), int64(len(r.data)), r.contentType)
+			if !exists {
+				written = append(written, key)
+			}
+		}
+		if err != nil {
+			// hanya hapus file yang baru ditulis, file lama masih dipakai gambar lain
+			for _, key := range written {
+				s.Storage.Delete(ctx, key)
+			}
+			return nil, err
+		}
+
+		image.Renditions = append(image.Renditions, productModel.ImageRendition{
+			Name:     r.name,
+			URL:      s.Storage.URL(key),
+			Width:    r.width,
+			Height:   r.height,
+			ByteSize: int64(len(r.data)),
+		})
+		if r.name == productModel.RenditionOriginal {
+			image.URL = s.Storage.URL(key)
+		}
+	}
 
+	return image, nil
 }
 
-func (s *ImageUploadService) ImageDelete(ctx context.Context, publicPath string) error {
-	err := os.Remove(fmt.Sprintf("%s/%s", s.StoragePath, publicPath))
-	if err != nil {
+func (s *ImageUploadService) ImageDelete(ctx context.Context, img productModel.ProductImage) error {
+	// gambar lama (sebelum ada rendition) hanya punya URL
+	if len(img.Renditions) == 0 {
+		img.Renditions = []productModel.ImageRendition{{URL: img.URL}}
+	}
+
+	if err := s.deleteRenditions(ctx, img.Renditions); err != nil {
 		logger.Errorf("Failed to delete image: %v", err)
 		return err
 	}
 
 	return nil
 }
+
+func (s *ImageUploadService) deleteRenditions(ctx context.Context, renditions []productModel.ImageRendition) error {
+	var firstErr error
+	for _, r := range renditions {
+		if err := s.Storage.Delete(ctx, s.Storage.Key(r.URL)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *ImageUploadService) limits() Limits {
+	if s.Limits == (Limits{}) {
+		return DefaultLimits
+	}
+	return s.Limits
+}
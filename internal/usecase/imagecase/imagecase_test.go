@@ -3,40 +3,186 @@ package imagecase
 import (
 	"bytes"
 	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
 	"mime/multipart"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestImageUploadService_Upload(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	service := &ImageUploadService{
-		StoragePath: tmpDir,
-	}
+// fileHeader membuat multipart file seperti yang diterima handler
+func fileHeader(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
 
-	// fake multipart file
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
-	part, _ := writer.CreateFormFile("image", "test.jpg")
-	part.Write([]byte("fake image content"))
+	part, _ := writer.CreateFormFile("image", name)
+	part.Write(content)
 	writer.Close()
 
 	req := httptest.NewRequest("POST", "/", body)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	req.ParseMultipartForm(10 << 20)
+	require.NoError(t, req.ParseMultipartForm(10<<20))
 
 	file, header, err := req.FormFile("image")
 	require.NoError(t, err)
-	defer file.Close()
+	file.Close()
+
+	return header
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	return buf.Bytes()
+}
+
+func findRendition(t *testing.T, img *productModel.ProductImage, name string) productModel.ImageRendition {
+	for _, r := range img.Renditions {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("rendition %s not found", name)
+	return productModel.ImageRendition{}
+}
+
+func TestImageUploadService_Upload(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	service := &ImageUploadService{
+		Storage:    storage.NewLocal(tmpDir),
+		PublicPath: "uploads",
+	}
+
+	// nama file .png tapi isinya JPEG: tipe diambil dari isi
+	content := encodeJPEG(t, testImage(1600, 1000))
+	img, err := service.ImageUpload(context.Background(), fileHeader(t, "photo.png", content))
+	require.NoError(t, err)
+	require.Len(t, img.Renditions, 3)
+
+	original := findRendition(t, img, productModel.RenditionOriginal)
+	assert.Equal(t, img.URL, original.URL)
+	assert.Equal(t, ".jpg", filepath.Ext(original.URL))
+	assert.Equal(t, 1600, original.Width)
+	assert.Equal(t, 1000, original.Height)
+	assert.Equal(t, int64(len(content)), original.ByteSize)
+
+	medium := findRendition(t, img, productModel.RenditionMedium)
+	assert.Equal(t, 800, medium.Width)
+	assert.Equal(t, 500, medium.Height)
+
+	thumbnail := findRendition(t, img, productModel.RenditionThumbnail)
+	assert.Equal(t, 200, thumbnail.Width)
+	assert.Equal(t, 125, thumbnail.Height)
+
+	for _, r := range img.Renditions {
+		assert.FileExists(t, filepath.Join(tmpDir, r.URL))
+		assert.Positive(t, r.ByteSize)
+	}
+
+	require.NoError(t, service.ImageDelete(context.Background(), *img))
+	for _, r := range img.Renditions {
+		assert.NoFileExists(t, filepath.Join(tmpDir, r.URL))
+	}
+}
+
+func TestImageUploadService_UploadSmallPNG(t *testing.T) {
+	service := &ImageUploadService{
+		Storage:    storage.NewLocal(t.TempDir()),
+		PublicPath: "uploads",
+	}
+
+	// lebih kecil dari medium: tidak diperbesar, tetap PNG
+	img, err := service.ImageUpload(context.Background(), fileHeader(t, "small.png", encodePNG(t, testImage(300, 600))))
+	require.NoError(t, err)
+
+	medium := findRendition(t, img, productModel.RenditionMedium)
+	assert.Equal(t, 300, medium.Width)
+	assert.Equal(t, 600, medium.Height)
+	assert.Equal(t, ".png", filepath.Ext(medium.URL))
+
+	thumbnail := findRendition(t, img, productModel.RenditionThumbnail)
+	assert.Equal(t, 100, thumbnail.Width)
+	assert.Equal(t, 200, thumbnail.Height)
+}
+
+func TestImageUploadService_UploadRejected(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	service := &ImageUploadService{
+		Storage:    storage.NewLocal(tmpDir),
+		PublicPath: "uploads",
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr error
+	}{
+		{"not an image", []byte("fake image content"), errorUtils.ErrUnsupportedMediaType},
+		{"gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), errorUtils.ErrUnsupportedMediaType},
+		{"too small", encodePNG(t, testImage(16, 16)), errorUtils.ErrBadRequest},
+		{"too large", encodePNG(t, testImage(9000, 10)), errorUtils.ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ImageUpload(context.Background(), fileHeader(t, "image.jpg", tt.content))
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+
+	// tidak ada file yang tersisa
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "uploads", "*"))
+	assert.Empty(t, matches)
+}
 
-	path, err := service.ImageUpload(context.Background(), header)
+func TestImageUploadService_UploadDeduplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	service := &ImageUploadService{
+		Storage:    storage.NewLocal(tmpDir),
+		PublicPath: "uploads",
+	}
+
+	content := encodePNG(t, testImage(400, 400))
+
+	first, err := service.ImageUpload(context.Background(), fileHeader(t, "a.png", content))
+	require.NoError(t, err)
+	second, err := service.ImageUpload(context.Background(), fileHeader(t, "b.png", content))
 	require.NoError(t, err)
 
-	// assertions
-	assert.FileExists(t, path)
+	assert.Len(t, first.Hash, 64)
+	assert.Equal(t, first.Hash, second.Hash)
+	assert.Equal(t, first.Renditions, second.Renditions)
+
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "uploads", "*"))
+	assert.Len(t, matches, 3)
 }
@@ -0,0 +1,143 @@
+package imagecase
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/gabriel-vasile/mimetype"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Limits membatasi ukuran file dan dimensi gambar yang diterima
+type Limits struct {
+	MaxBytes  int64
+	MinWidth  int
+	MinHeight int
+	MaxWidth  int
+	MaxHeight int
+}
+
+var DefaultLimits = Limits{
+	MaxBytes:  10 << 20,
+	MinWidth:  64,
+	MinHeight: 64,
+	MaxWidth:  8000,
+	MaxHeight: 8000,
+}
+
+// sisi terpanjang untuk rendition yang diperkecil
+var resizedRenditions = []struct {
+	name    string
+	maxSide int
+}{
+	{productModel.RenditionMedium, 800},
+	{productModel.RenditionThumbnail, 200},
+}
+
+const jpegQuality = 82
+
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+type rendition struct {
+	name        string
+	suffix      string
+	ext         string
+	contentType string
+	width       int
+	height      int
+	data        []byte
+}
+
+// process mendeteksi tipe dari isi file, memeriksa dimensi, lalu membuat
+// rendition medium & thumbnail. Original disimpan apa adanya.
+func process(data []byte, limits Limits) ([]rendition, error) {
+	mtype := mimetype.Detect(data)
+	ext, ok := allowedTypes[mtype.String()]
+	if !ok {
+		return nil, errorUtils.ErrUnsupportedMediaType
+	}
+
+	// cek dimensi dari header dulu sebelum decode penuh (decompression bomb)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errorUtils.ErrUnsupportedMediaType
+	}
+	if cfg.Width < limits.MinWidth || cfg.Height < limits.MinHeight ||
+		cfg.Width > limits.MaxWidth || cfg.Height > limits.MaxHeight {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errorUtils.ErrUnsupportedMediaType
+	}
+
+	renditions := []rendition{{
+		name:        productModel.RenditionOriginal,
+		ext:         ext,
+		contentType: mtype.String(),
+		width:       cfg.Width,
+		height:      cfg.Height,
+		data:        data,
+	}}
+
+	// PNG tetap PNG supaya transparansi tidak hilang, selain itu JPEG
+	encodePNG := mtype.Is("image/png")
+
+	for _, spec := range resizedRenditions {
+		resized := resize(src, spec.maxSide)
+
+		var buf bytes.Buffer
+		r := rendition{
+			name:   spec.name,
+			suffix: "_" + spec.name,
+			width:  resized.Bounds().Dx(),
+			height: resized.Bounds().Dy(),
+		}
+		if encodePNG {
+			err = png.Encode(&buf, resized)
+			r.ext, r.contentType = ".png", "image/png"
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality})
+			r.ext, r.contentType = ".jpg", "image/jpeg"
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.data = buf.Bytes()
+
+		renditions = append(renditions, r)
+	}
+
+	return renditions, nil
+}
+
+// resize memperkecil gambar supaya sisi terpanjang <= maxSide dengan rasio
+// tetap. Gambar yang lebih kecil tidak diperbesar.
+func resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w > maxSide || h > maxSide {
+		if w >= h {
+			h = max(1, h*maxSide/w)
+			w = maxSide
+		} else {
+			w = max(1, w*maxSide/h)
+			h = maxSide
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
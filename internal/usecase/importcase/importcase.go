@@ -0,0 +1,568 @@
+package importcase
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+const (
+	DefaultBatchSize = 50
+	DefaultMaxRows   = 5000
+
+	// pemisah path kategori ("Drinks > Soda") dan antar kategori
+	categoryPathSep = ">"
+	categoryListSep = "|"
+	// pemisah antar option ("color=red; size=M")
+	optionSep = ";"
+)
+
+// Kolom file import, satu baris = satu unit
+const (
+	ColProductName    = "product_name"
+	ColDescription    = "description"
+	ColCategories     = "categories"
+	ColStockPolicy    = "stock_policy"
+	ColSKU            = "sku"
+	ColBaseUnit       = "base_unit"
+	ColCostPrice      = "cost_price"
+	ColStock          = "stock"
+	ColOptions        = "options"
+	ColUnitName       = "unit_name"
+	ColConversionRate = "conversion_rate"
+	ColPrice          = "price"
+	ColBarcode        = "barcode"
+)
+
+var requiredColumns = []string{ColProductName, ColSKU, ColBaseUnit, ColUnitName, ColPrice}
+
+type ImportService interface {
+	Import(ctx context.Context, file *multipart.FileHeader, dryRun bool) (*Report, error)
+}
+
+// Row satu baris file yang sudah di-parse, divalidasi lewat validation.Validation
+type Row struct {
+	Line           int    `json:"-"`
+	ProductName    string `json:"product_name" validate:"required"`
+	Description    string `json:"description"`
+	Categories     string `json:"categories"`
+	StockPolicy    string `json:"stock_policy" validate:"omitempty,oneof=block allow_negative warn"`
+	SKU            string `json:"sku" validate:"required"`
+	BaseUnit       string `json:"base_unit" validate:"required"`
+	CostPrice      int64  `json:"cost_price" validate:"gte=0"`
+	Stock          int    `json:"stock" validate:"gte=0"`
+	Options        string `json:"options"`
+	UnitName       string `json:"unit_name" validate:"required"`
+	ConversionRate int    `json:"conversion_rate" validate:"gt=0"`
+	Price          int64  `json:"price" validate:"gte=0"`
+	Barcode        string `json:"barcode"`
+}
+
+type RowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// Report hasil import. Partial true berarti sebuah batch gagal disimpan
+// setelah CommittedBatches batch sebelumnya (product di Created) tersimpan;
+// product setelah batch yang gagal tidak disimpan.
+type Report struct {
+	DryRun           bool       `json:"dry_run"`
+	Rows             int        `json:"rows"`
+	Products         int        `json:"products"`
+	Variants         int        `json:"variants"`
+	Units            int        `json:"units"`
+	Created          []int64    `json:"created"`
+	CommittedBatches int        `json:"committed_batches"`
+	Partial          bool       `json:"partial"`
+	Errors           []RowError `json:"errors"`
+}
+
+func (r *Report) addError(line int, format string, args ...any) {
+	r.Errors = append(r.Errors, RowError{Row: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// HasErrors true jika ada baris yang gagal divalidasi atau disimpan
+func (r *Report) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// importProduct product hasil pengelompokan baris beserta baris pertamanya
+type importProduct struct {
+	line    int
+	product productModel.Product
+}
+
+type ProductImporter struct {
+	productRepo  Repository.ProductRepoInterface
+	categoryRepo Repository.CategoryInterface
+	txManager    txmanager.TxManager
+	validator    validation.Validation
+
+	BatchSize int // jumlah product per transaksi
+	MaxRows   int
+}
+
+func NewProductImporter(
+	productRepo Repository.ProductRepoInterface,
+	categoryRepo Repository.CategoryInterface,
+	txManager txmanager.TxManager,
+	validator validation.Validation,
+) *ProductImporter {
+	return &ProductImporter{
+		productRepo:  productRepo,
+		categoryRepo: categoryRepo,
+		txManager:    txManager,
+		validator:    validator,
+		BatchSize:    DefaultBatchSize,
+		MaxRows:      DefaultMaxRows,
+	}
+}
+
+// Import memvalidasi seluruh file lalu, jika bukan dry-run dan tidak ada
+// error, menyimpan product per batch. Error per baris dikembalikan lewat
+// Report, bukan error.
+func (s *ProductImporter) Import(ctx context.Context, file *multipart.FileHeader, dryRun bool) (*Report, error) {
+	src, err := file.Open()
+	if err != nil {
+		logger.Errorf("failed to open import file, error: %s", err)
+		return nil, errorUtils.ErrBadRequest
+	}
+	defer src.Close()
+
+	format, err := detectFormat(file.Filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	header, records, err := readRecords(format, src)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > s.MaxRows {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	report := &Report{DryRun: dryRun, Rows: len(records), Created: []int64{}, Errors: []RowError{}}
+
+	columns, ok := s.mapColumns(header, report)
+	if !ok {
+		return report, nil
+	}
+
+	var rows []Row
+	for _, rec := range records {
+		row, ok := s.parseRow(rec, columns, report)
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+
+	products, err := s.groupRows(ctx, rows, report)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkExisting(ctx, products, report); err != nil {
+		return nil, err
+	}
+
+	report.Products = len(products)
+	for _, p := range products {
+		report.Variants += len(p.product.Variants)
+		for _, v := range p.product.Variants {
+			report.Units += len(v.Units)
+		}
+	}
+
+	if dryRun || report.HasErrors() {
+		return report, nil
+	}
+
+	s.commit(ctx, products, report)
+	return report, nil
+}
+
+// mapColumns memetakan nama kolom header ke index
+func (s *ProductImporter) mapColumns(header []string, report *Report) (map[string]int, bool) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			columns[name] = i
+		}
+	}
+
+	for _, col := range requiredColumns {
+		if _, ok := columns[col]; !ok {
+			report.addError(1, "missing column %s", col)
+		}
+	}
+
+	return columns, !report.HasErrors()
+}
+
+func (s *ProductImporter) parseRow(rec record, columns map[string]int, report *Report) (Row, bool) {
+	get := func(col string) string {
+		i, ok := columns[col]
+		if !ok || i >= len(rec.fields) {
+			return ""
+		}
+		return strings.TrimSpace(rec.fields[i])
+	}
+
+	row := Row{
+		Line:        rec.line,
+		ProductName: get(ColProductName),
+		Description: get(ColDescription),
+		Categories:  get(ColCategories),
+		StockPolicy: get(ColStockPolicy),
+		SKU:         get(ColSKU),
+		BaseUnit:    get(ColBaseUnit),
+		Options:     get(ColOptions),
+		UnitName:    get(ColUnitName),
+		Barcode:     get(ColBarcode),
+	}
+
+	ok := true
+	parseInt := func(col string, def int64) int64 {
+		value := get(col)
+		if value == "" {
+			return def
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			report.addError(rec.line, "%s must be a whole number", col)
+			ok = false
+		}
+		return n
+	}
+
+	row.CostPrice = parseInt(ColCostPrice, 0)
+	row.Stock = int(parseInt(ColStock, 0))
+	row.ConversionRate = int(parseInt(ColConversionRate, 1))
+	row.Price = parseInt(ColPrice, 0)
+	if get(ColPrice) == "" {
+		report.addError(rec.line, "%s is a required field", ColPrice)
+		ok = false
+	}
+	if !ok {
+		return row, false
+	}
+
+	if err := s.validator.ValidateStruct(row); err != nil {
+		report.addError(rec.line, "%s", err.Error())
+		return row, false
+	}
+
+	return row, true
+}
+
+// groupRows menyusun baris menjadi product -> variant (per sku) -> unit
+func (s *ProductImporter) groupRows(ctx context.Context, rows []Row, report *Report) ([]importProduct, error) {
+	categories, err := s.loadCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		products     []importProduct
+		productIndex = map[string]int{}
+		firstRow     = map[string]Row{} // baris pertama per product & per variant
+		skuOwner     = map[string]string{}
+		barcodeLine  = map[string]int{}
+	)
+
+	for _, row := range rows {
+		productKey := strings.ToLower(row.ProductName)
+
+		pi, exists := productIndex[productKey]
+		if !exists {
+			categoryIDs, err := categories.resolve(row.Categories)
+			if err != nil {
+				report.addError(row.Line, "%s", err.Error())
+				continue
+			}
+
+			products = append(products, importProduct{
+				line: row.Line,
+				product: productModel.Product{
+					Name:        row.ProductName,
+					Description: row.Description,
+					StockPolicy: row.StockPolicy,
+					CategoryId:  categoryIDs,
+				},
+			})
+			pi = len(products) - 1
+			productIndex[productKey] = pi
+			firstRow["p:"+productKey] = row
+		} else if first := firstRow["p:"+productKey]; !sameProduct(first, row) {
+			report.addError(row.Line, "description, categories and stock_policy must match row %d", first.Line)
+			continue
+		}
+		p := &products[pi].product
+
+		// satu sku hanya boleh dipakai satu product
+		skuKey := strings.ToLower(row.SKU)
+		if owner, ok := skuOwner[skuKey]; ok && owner != productKey {
+			report.addError(row.Line, "sku %s is already used by another product in this file", row.SKU)
+			continue
+		}
+
+		variantIdx := -1
+		for i := range p.Variants {
+			if strings.EqualFold(p.Variants[i].SKU, row.SKU) {
+				variantIdx = i
+				break
+			}
+		}
+
+		if variantIdx < 0 {
+			options, err := parseOptions(row.Options)
+			if err != nil {
+				report.addError(row.Line, "%s", err.Error())
+				continue
+			}
+
+			p.Variants = append(p.Variants, productModel.Variant{
+				SKU:       row.SKU,
+				BaseUnit:  row.BaseUnit,
+				CostPrice: row.CostPrice,
+				Stock:     row.Stock,
+				Options:   options,
+			})
+			variantIdx = len(p.Variants) - 1
+			skuOwner[skuKey] = productKey
+			firstRow["v:"+skuKey] = row
+		} else if first := firstRow["v:"+skuKey]; !sameVariant(first, row) {
+			report.addError(row.Line, "base_unit, cost_price, stock and options of sku %s must match row %d", row.SKU, first.Line)
+			continue
+		}
+		v := &p.Variants[variantIdx]
+
+		duplicateUnit := false
+		for _, u := range v.Units {
+			if strings.EqualFold(u.Name, row.UnitName) {
+				duplicateUnit = true
+				break
+			}
+		}
+		if duplicateUnit {
+			report.addError(row.Line, "unit %s is listed twice for sku %s", row.UnitName, row.SKU)
+			continue
+		}
+
+		unit := productModel.VariantUnit{
+			Name:           row.UnitName,
+			ConversionRate: row.ConversionRate,
+			Price:          row.Price,
+		}
+		if row.Barcode != "" {
+			if line, ok := barcodeLine[row.Barcode]; ok {
+				report.addError(row.Line, "barcode %s is already used in row %d", row.Barcode, line)
+				continue
+			}
+			barcodeLine[row.Barcode] = row.Line
+
+			barcode := row.Barcode
+			unit.Barcode = &barcode
+		}
+		v.Units = append(v.Units, unit)
+	}
+
+	return products, nil
+}
+
+// checkExisting menolak sku dan barcode yang sudah ada di database. Semua
+// kode dicek dalam satu query; sku dicocokkan dengan sku dan barcode dengan
+// barcode, sama seperti constraint unique di tabelnya.
+func (s *ProductImporter) checkExisting(ctx context.Context, products []importProduct, report *Report) error {
+	var skus, barcodes []string
+	for _, p := range products {
+		for _, v := range p.product.Variants {
+			skus = append(skus, v.SKU)
+			for _, u := range v.Units {
+				if u.Barcode != nil {
+					barcodes = append(barcodes, *u.Barcode)
+				}
+			}
+		}
+	}
+	if len(skus) == 0 {
+		return nil
+	}
+
+	usedSKUs, usedBarcodes, err := s.productRepo.FindUsedCodes(ctx, skus, barcodes)
+	if err != nil {
+		logger.Errorf("Import check existing codes fail, error: %s", err)
+		return err
+	}
+
+	skuUsed := make(map[string]bool, len(usedSKUs))
+	for _, sku := range usedSKUs {
+		skuUsed[sku] = true
+	}
+	barcodeUsed := make(map[string]bool, len(usedBarcodes))
+	for _, barcode := range usedBarcodes {
+		barcodeUsed[barcode] = true
+	}
+
+	for _, p := range products {
+		for _, v := range p.product.Variants {
+			if skuUsed[v.SKU] {
+				report.addError(p.line, "sku %s already exists", v.SKU)
+			}
+			for _, u := range v.Units {
+				if u.Barcode != nil && barcodeUsed[*u.Barcode] {
+					report.addError(p.line, "barcode %s already exists", *u.Barcode)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// commit menyimpan product per batch, satu transaksi per batch. Batch yang
+// gagal di-rollback dan proses berhenti; batch sebelumnya tetap tersimpan dan
+// dicatat di report sebagai Partial.
+func (s *ProductImporter) commit(ctx context.Context, products []importProduct, report *Report) {
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	for start := 0; start < len(products); start += batchSize {
+		end := min(start+batchSize, len(products))
+
+		var (
+			ids    []int64
+			failed importProduct
+		)
+		err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			for _, p := range products[start:end] {
+				id, err := s.productRepo.Create(ctx, &p.product)
+				if err != nil {
+					failed = p
+					return err
+				}
+				ids = append(ids, id)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Errorf("Import batch fail, error: %s", err)
+			report.addError(failed.line, "failed to save product %s: %s", failed.product.Name, err.Error())
+			report.Partial = report.CommittedBatches > 0
+			return
+		}
+
+		report.Created = append(report.Created, ids...)
+		report.CommittedBatches++
+	}
+}
+
+func sameProduct(a, b Row) bool {
+	return (b.Description == "" || b.Description == a.Description) &&
+		(b.Categories == "" || b.Categories == a.Categories) &&
+		(b.StockPolicy == "" || b.StockPolicy == a.StockPolicy)
+}
+
+func sameVariant(a, b Row) bool {
+	return strings.EqualFold(a.BaseUnit, b.BaseUnit) &&
+		a.CostPrice == b.CostPrice &&
+		a.Stock == b.Stock &&
+		(b.Options == "" || b.Options == a.Options)
+}
+
+// parseOptions "color=red; size=M" -> []VariantOption
+func parseOptions(value string) ([]productModel.VariantOption, error) {
+	options := []productModel.VariantOption{}
+	if value == "" {
+		return options, nil
+	}
+
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, optionSep) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, val, ok := strings.Cut(part, "=")
+		name, val = strings.TrimSpace(name), strings.TrimSpace(val)
+		if !ok || name == "" || val == "" {
+			return nil, fmt.Errorf("option %q must be written as name=value", part)
+		}
+		if seen[strings.ToLower(name)] {
+			return nil, fmt.Errorf("option %s is listed twice", name)
+		}
+		seen[strings.ToLower(name)] = true
+
+		options = append(options, productModel.VariantOption{Name: name, Value: val})
+	}
+
+	return options, nil
+}
+
+// categoryIndex mencari id kategori berdasarkan path nama
+type categoryIndex map[int64]map[string]int64 // parent id (0 = root) -> nama -> id
+
+func (s *ProductImporter) loadCategories(ctx context.Context) (categoryIndex, error) {
+	categories, err := s.categoryRepo.FindAllCategory(ctx)
+	if err != nil {
+		logger.Errorf("Import load categories fail, error: %s", err)
+		return nil, err
+	}
+
+	index := categoryIndex{}
+	for _, c := range categories {
+		var parent int64
+		if c.ParentID != nil {
+			parent = *c.ParentID
+		}
+		if index[parent] == nil {
+			index[parent] = map[string]int64{}
+		}
+		index[parent][strings.ToLower(strings.TrimSpace(c.Name))] = c.ID
+	}
+
+	return index, nil
+}
+
+// resolve "Drinks > Soda | Promo" -> id kategori Soda (anak Drinks) dan Promo
+func (idx categoryIndex) resolve(value string) ([]*int64, error) {
+	var ids []*int64
+	if value == "" {
+		return ids, nil
+	}
+
+	for _, path := range strings.Split(value, categoryListSep) {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		var id int64
+		for _, name := range strings.Split(path, categoryPathSep) {
+			child, ok := idx[id][strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("category %q not found", path)
+			}
+			id = child
+		}
+
+		ids = append(ids, &id)
+	}
+
+	return ids, nil
+}
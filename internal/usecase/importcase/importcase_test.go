@@ -0,0 +1,276 @@
+package importcase
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/importcase/mocks"
+	productMocks "github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/validation"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+const header = "product_name,description,categories,sku,base_unit,cost_price,stock,options,unit_name,conversion_rate,price,barcode\n"
+
+// fileHeader membuat multipart file seperti yang diterima handler
+func fileHeader(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	part, _ := writer.CreateFormFile("file", name)
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	require.NoError(t, req.ParseMultipartForm(10<<20))
+
+	file, fh, err := req.FormFile("file")
+	require.NoError(t, err)
+	file.Close()
+
+	return fh
+}
+
+func ptr(v int64) *int64 { return &v }
+
+func newImporter() (*ProductImporter, *productMocks.ProductRepository, *productMocks.CategoryRepository) {
+	productRepo := new(productMocks.ProductRepository)
+	categoryRepo := new(productMocks.CategoryRepository)
+
+	categoryRepo.On("FindAllCategory", mock.Anything).Return([]productModel.Category{
+		{ID: 1, Name: "Drinks"},
+		{ID: 2, Name: "Soda", ParentID: ptr(1)},
+		{ID: 3, Name: "Snacks"},
+		{ID: 4, Name: "Soda", ParentID: ptr(3)},
+	}, nil)
+
+	// sku & barcode belum ada di database
+	productRepo.On("FindUsedCodes", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, nil)
+
+	return NewProductImporter(productRepo, categoryRepo, mocks.TxManager{}, validation.New()), productRepo, categoryRepo
+}
+
+func TestProductImporter_DryRun(t *testing.T) {
+	importer, productRepo, _ := newImporter()
+
+	csv := header +
+		"Cola,Fizzy,Drinks > Soda,COLA-330,pcs,4000,24,size=330ml,pcs,1,6000,899001\n" +
+		"Cola,,,COLA-330,pcs,4000,24,,pack,6,33000,899002\n" +
+		"Cola,,,COLA-1L,pcs,9000,0,size=1L,pcs,,12000,\n" +
+		"\n" +
+		"Chips,,Snacks | Snacks > Soda,CHIP-01,pcs,2000,10,,pcs,1,3500,\n"
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), true)
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Errors)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, 2, report.Products)
+	assert.Equal(t, 3, report.Variants)
+	assert.Equal(t, 4, report.Units)
+	assert.Empty(t, report.Created)
+
+	productRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductImporter_Commit(t *testing.T) {
+	importer, productRepo, _ := newImporter()
+	importer.BatchSize = 1
+
+	csv := header +
+		"Cola,Fizzy,Drinks > Soda,COLA-330,pcs,4000,24,size=330ml; pack=can,pcs,1,6000,899001\n" +
+		"Cola,,,COLA-330,pcs,4000,24,,pack,6,33000,899002\n" +
+		"Chips,,Snacks,CHIP-01,pcs,2000,10,,pcs,1,3500,\n"
+
+	var created []productModel.Product
+	productRepo.On("Create", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			created = append(created, *args.Get(1).(*productModel.Product))
+		}).
+		Return(int64(10), nil).Once()
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(int64(11), nil).Once()
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), false)
+	require.NoError(t, err)
+
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, []int64{10, 11}, report.Created)
+
+	require.Len(t, created, 1)
+	cola := created[0]
+	assert.Equal(t, "Cola", cola.Name)
+	assert.Equal(t, "Fizzy", cola.Description)
+	require.Len(t, cola.CategoryId, 1)
+	assert.Equal(t, int64(2), *cola.CategoryId[0])
+
+	require.Len(t, cola.Variants, 1)
+	variant := cola.Variants[0]
+	assert.Equal(t, "COLA-330", variant.SKU)
+	assert.Equal(t, 24, variant.Stock)
+	assert.Equal(t, []productModel.VariantOption{
+		{Name: "size", Value: "330ml"},
+		{Name: "pack", Value: "can"},
+	}, variant.Options)
+
+	require.Len(t, variant.Units, 2)
+	assert.Equal(t, 6, variant.Units[1].ConversionRate)
+	assert.Equal(t, int64(33000), variant.Units[1].Price)
+	assert.Equal(t, "899002", *variant.Units[1].Barcode)
+
+	productRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestProductImporter_CommitBatchFailure(t *testing.T) {
+	importer, productRepo, _ := newImporter()
+	importer.BatchSize = 1
+
+	csv := header +
+		"Cola,,,COLA-330,pcs,0,0,,pcs,1,6000,\n" +
+		"Chips,,,CHIP-01,pcs,0,0,,pcs,1,3500,\n" +
+		"Tea,,,TEA-01,pcs,0,0,,pcs,1,3000,\n"
+
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(int64(10), nil).Once()
+	productRepo.On("Create", mock.Anything, mock.Anything).Return(int64(0), errorUtils.ErrConflict).Once()
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), false)
+	require.NoError(t, err)
+
+	// batch pertama tersimpan, berhenti di batch yang gagal
+	assert.Equal(t, []int64{10}, report.Created)
+	assert.Equal(t, 1, report.CommittedBatches)
+	assert.True(t, report.Partial)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Row)
+	productRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestProductImporter_RowErrors(t *testing.T) {
+	importer, productRepo, _ := newImporter()
+
+	csv := header +
+		"Cola,,Drinks > Soda,COLA-330,pcs,4000,24,,pcs,1,6000,899001\n" + // 2 ok
+		",,,X-1,pcs,0,0,,pcs,1,1000,\n" + // 3 tanpa nama
+		"Tea,,Drinks > Juice,TEA-1,pcs,0,0,,pcs,1,1000,\n" + // 4 kategori tidak ada
+		"Milk,,,MILK-1,pcs,abc,0,,pcs,1,1000,\n" + // 5 angka tidak valid
+		"Cola,,,COLA-330,box,4000,24,,pack,6,33000,\n" + // 6 base_unit beda
+		"Juice,,,JUICE-1,pcs,0,0,sweet,pcs,1,1000,\n" + // 7 option tidak valid
+		"Water,,,WATER-1,pcs,0,0,,pcs,1,1000,899001\n" + // 8 barcode duplikat
+		"Cola,,,COLA-330,pcs,4000,24,,pcs,1,6000,\n" + // 9 unit duplikat
+		"Soda,,,COLA-330,pcs,0,0,,pcs,1,1000,\n" + // 10 sku milik product lain
+		"Syrup,,,SYRUP-1,pcs,0,0,,pcs,1,,\n" // 11 tanpa harga
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), false)
+	require.NoError(t, err)
+
+	var rows []int
+	for _, e := range report.Errors {
+		rows = append(rows, e.Row)
+	}
+	assert.ElementsMatch(t, []int{3, 4, 5, 6, 7, 8, 9, 10, 11}, rows)
+	assert.Empty(t, report.Created)
+
+	productRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductImporter_ExistingSKU(t *testing.T) {
+	productRepo := new(productMocks.ProductRepository)
+	categoryRepo := new(productMocks.CategoryRepository)
+	categoryRepo.On("FindAllCategory", mock.Anything).Return([]productModel.Category{}, nil)
+
+	// COLA-330 juga dipakai sebagai barcode unit lain, tetap terdeteksi
+	// sebagai sku yang sudah ada
+	productRepo.On("FindUsedCodes", mock.Anything, []string{"COLA-330", "CHIP-01"}, []string{"899001", "COLA-330"}).
+		Return([]string{"COLA-330"}, []string{"899001", "COLA-330"}, nil).Once()
+
+	importer := NewProductImporter(productRepo, categoryRepo, mocks.TxManager{}, validation.New())
+
+	csv := header +
+		"Cola,,,COLA-330,pcs,0,0,,pcs,1,6000,899001\n" +
+		"Chips,,,CHIP-01,pcs,0,0,,pcs,1,3500,COLA-330\n"
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), true)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 3)
+	assert.Contains(t, report.Errors[0].Message, "sku COLA-330 already exists")
+	assert.Contains(t, report.Errors[1].Message, "barcode 899001 already exists")
+	assert.Contains(t, report.Errors[2].Message, "barcode COLA-330 already exists")
+	productRepo.AssertExpectations(t)
+}
+
+func TestProductImporter_MissingColumn(t *testing.T) {
+	importer, _, _ := newImporter()
+
+	csv := "product_name,sku,unit_name\nCola,COLA-330,pcs\n"
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), true)
+	require.NoError(t, err)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 1, report.Errors[0].Row)
+	assert.Equal(t, "missing column base_unit", report.Errors[0].Message)
+}
+
+func TestProductImporter_SemicolonCSV(t *testing.T) {
+	importer, _, _ := newImporter()
+
+	csv := "\xef\xbb\xbfProduct_Name;SKU;Base_Unit;Unit_Name;Price\nCola;COLA-330;pcs;pcs;6000\n"
+
+	report, err := importer.Import(context.Background(), fileHeader(t, "products.csv", []byte(csv)), true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 1, report.Products)
+}
+
+func TestProductImporter_XLSX(t *testing.T) {
+	importer, _, _ := newImporter()
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	rows := [][]any{
+		{"product_name", "categories", "sku", "base_unit", "options", "unit_name", "conversion_rate", "price", "barcode"},
+		{"Cola", "Drinks > Soda", "COLA-330", "pcs", "size=330ml", "pcs", 1, 6000, "899001"},
+		{"Cola", "", "COLA-330", "pcs", "", "pack", 6, 33000, "899002"},
+		{},
+		{"Chips", "Snacks", "CHIP-01", "pcs", "", "pcs", 1, 3500, ""},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, f.SetSheetRow(sheet, cell, &row))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	// nama file tanpa ekstensi: format dikenali dari isi
+	report, err := importer.Import(context.Background(), fileHeader(t, "upload", buf.Bytes()), true)
+	require.NoError(t, err)
+	assert.Empty(t, report.Errors)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 2, report.Products)
+	assert.Equal(t, 3, report.Units)
+}
+
+func TestProductImporter_UnsupportedFile(t *testing.T) {
+	importer, _, _ := newImporter()
+
+	_, err := importer.Import(context.Background(), fileHeader(t, "image", []byte("\x89PNG\r\n\x1a\n\x00\x00")), true)
+	assert.Equal(t, errorUtils.ErrUnsupportedMediaType, err)
+}
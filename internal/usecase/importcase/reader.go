@@ -0,0 +1,163 @@
+package importcase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// record satu baris mentah dari file beserta nomor barisnya (header = 1)
+type record struct {
+	line   int
+	fields []string
+}
+
+// detectFormat menentukan format dari ekstensi, fallback ke isi file
+func detectFormat(filename string, file io.ReadSeeker) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	switch {
+	case mtype.Is("application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"):
+		return FormatXLSX, nil
+	case mtype.Is("text/csv"), mtype.Is("text/plain"):
+		return FormatCSV, nil
+	}
+	return "", errorUtils.ErrUnsupportedMediaType
+}
+
+// readRecords membaca header dan semua baris data, baris kosong dilewati
+func readRecords(format string, file io.Reader) ([]string, []record, error) {
+	var (
+		rows []record
+		err  error
+	)
+
+	switch format {
+	case FormatCSV:
+		rows, err = readCSV(file)
+	case FormatXLSX:
+		rows, err = readXLSX(file)
+	default:
+		return nil, nil, errorUtils.ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, nil, errorUtils.ErrBadRequest
+	}
+
+	return rows[0].fields, rows[1:], nil
+}
+
+func readCSV(file io.Reader) ([]record, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	// BOM dari Excel "CSV UTF-8"
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Excel dengan locale Indonesia menyimpan CSV dengan pemisah titik koma
+	firstLine, _, _ := bytes.Cut(content, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	var rows []record
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			logger.Errorf("failed to read csv, error: %s", err)
+			return nil, errorUtils.ErrBadRequest
+		}
+
+		line, _ := reader.FieldPos(0)
+		if isBlank(fields) {
+			continue
+		}
+		rows = append(rows, record{line: line, fields: fields})
+	}
+
+	return rows, nil
+}
+
+func readXLSX(file io.Reader) ([]record, error) {
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		logger.Errorf("failed to open xlsx, error: %s", err)
+		return nil, errorUtils.ErrBadRequest
+	}
+	defer f.Close()
+
+	// hanya sheet pertama yang dibaca
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	it, err := f.Rows(sheets[0])
+	if err != nil {
+		logger.Errorf("failed to read xlsx rows, error: %s", err)
+		return nil, errorUtils.ErrBadRequest
+	}
+	defer it.Close()
+
+	var rows []record
+	for line := 1; it.Next(); line++ {
+		fields, err := it.Columns()
+		if err != nil {
+			logger.Errorf("failed to read xlsx row, error: %s", err)
+			return nil, errorUtils.ErrBadRequest
+		}
+		if isBlank(fields) {
+			continue
+		}
+		rows = append(rows, record{line: line, fields: fields})
+	}
+
+	return rows, it.Error()
+}
+
+func isBlank(fields []string) bool {
+	for _, f := range fields {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+	return true
+}
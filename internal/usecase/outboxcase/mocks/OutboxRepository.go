@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/stretchr/testify/mock"
+)
+
+type OutboxRepository struct {
+	mock.Mock
+}
+
+// ClaimPending Mock
+func (_m *OutboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]eventModel.Event, error) {
+	args := _m.Called(ctx, limit, lease)
+	events, _ := args.Get(0).([]eventModel.Event)
+	return events, args.Error(1)
+}
+
+// MarkPublished Mock
+func (_m *OutboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	args := _m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MarkFailed Mock
+func (_m *OutboxRepository) MarkFailed(ctx context.Context, id int64, reason string, nextAttempt time.Time) error {
+	args := _m.Called(ctx, id, reason, nextAttempt)
+	return args.Error(0)
+}
+
+// ReleaseClaims Mock
+func (_m *OutboxRepository) ReleaseClaims(ctx context.Context, ids []int64) error {
+	args := _m.Called(ctx, ids)
+	return args.Error(0)
+}
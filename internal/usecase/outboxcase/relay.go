@@ -0,0 +1,129 @@
+package outboxcase
+
+import (
+	"context"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/outboxrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+)
+
+// Sink adalah tujuan event (webhook HTTP, file, ...). Publish yang sukses
+// berarti event boleh ditandai terkirim.
+type Sink interface {
+	Publish(ctx context.Context, event eventModel.Event) error
+}
+
+const (
+	defaultBatchSize = 100
+	baseBackoff      = time.Second
+	maxBackoff       = 10 * time.Minute
+	// event yang diklaim tidak diambil relay lain selama claimLease
+	claimLease = 5 * time.Minute
+)
+
+// Relay memindahkan event dari tabel outbox ke sink. Event diklaim dengan
+// lease lalu dikirim tanpa transaksi atau row lock yang terbuka. Pengiriman
+// at-least-once: jika proses mati setelah Publish sebelum event ditandai,
+// event dikirim ulang setelah lease habis. Konsumen harus dedup berdasarkan
+// ID event.
+type Relay struct {
+	outboxRepo Repository.OutboxRepoInterface
+	sink       Sink
+	batchSize  int
+	now        func() time.Time
+}
+
+func NewRelay(outboxRepo Repository.OutboxRepoInterface, sink Sink) *Relay {
+	return &Relay{
+		outboxRepo: outboxRepo,
+		sink:       sink,
+		batchSize:  defaultBatchSize,
+		now:        time.Now,
+	}
+}
+
+// RelayOnce mengirim satu batch event dan mengembalikan jumlah event yang
+// diproses (terkirim maupun gagal)
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.outboxRepo.ClaimPending(ctx, r.batchSize, claimLease)
+	if err != nil {
+		logger.Errorf("RelayOnce fail, error: %s", err)
+		return 0, err
+	}
+
+	// sisa event dilepas ke relay berikutnya saat lease hampir habis, supaya
+	// tidak dikirim bersamaan dengan relay lain yang mengklaimnya lagi.
+	// Klaimnya langsung dilepas agar tidak menunggu sisa lease.
+	deadline := r.now().Add(claimLease / 2)
+
+	var processed int
+	for _, event := range events {
+		if r.now().After(deadline) {
+			break
+		}
+		processed++
+
+		if err := r.sink.Publish(ctx, event); err != nil {
+			logger.Errorf("outbox: publish event %d (%s) failed: %v", event.ID, event.Type, err)
+
+			next := r.now().Add(backoff(event.Attempts + 1))
+			if err := r.outboxRepo.MarkFailed(ctx, event.ID, err.Error(), next); err != nil {
+				logger.Errorf("RelayOnce fail, error: %s", err)
+				return processed, err
+			}
+			continue
+		}
+
+		if err := r.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+			logger.Errorf("RelayOnce fail, error: %s", err)
+			return processed, err
+		}
+	}
+
+	if processed < len(events) {
+		ids := make([]int64, 0, len(events)-processed)
+		for _, event := range events[processed:] {
+			ids = append(ids, event.ID)
+		}
+		if err := r.outboxRepo.ReleaseClaims(ctx, ids); err != nil {
+			logger.Errorf("RelayOnce fail, error: %s", err)
+			return processed, err
+		}
+	}
+
+	return processed, nil
+}
+
+// Run memanggil RelayOnce setiap interval sampai ctx selesai. Batch yang
+// penuh langsung dilanjutkan tanpa menunggu interval.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.RelayOnce(ctx)
+		if err == nil && n == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// backoff eksponensial 1s, 2s, 4s, ... maksimal 10 menit
+func backoff(attempt int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
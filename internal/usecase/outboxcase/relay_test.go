@@ -0,0 +1,171 @@
+package outboxcase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/outboxcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+// sinkFunc mengubah fungsi biasa menjadi Sink
+type sinkFunc func(ctx context.Context, event eventModel.Event) error
+
+func (f sinkFunc) Publish(ctx context.Context, event eventModel.Event) error {
+	return f(ctx, event)
+}
+
+func testEvent(t *testing.T, id int64, eventType string, attempts int) eventModel.Event {
+	e, err := eventModel.New(eventType, eventModel.AggregateProduct, 7, eventModel.ProductPayload{ID: 7, Status: "active"})
+	require.NoError(t, err)
+	e.ID = id
+	e.Attempts = attempts
+	return e
+}
+
+func TestRelay_RelayOnce(t *testing.T) {
+	repo := new(mocks.OutboxRepository)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	events := []eventModel.Event{
+		testEvent(t, 1, eventModel.ProductCreated, 0),
+		testEvent(t, 2, eventModel.ProductArchived, 3),
+	}
+
+	sink := sinkFunc(func(ctx context.Context, event eventModel.Event) error {
+		if event.ID == 2 {
+			return errors.New("storefront down")
+		}
+		return nil
+	})
+
+	repo.On("ClaimPending", mock.Anything, defaultBatchSize, claimLease).Return(events, nil).Once()
+	repo.On("MarkPublished", mock.Anything, int64(1)).Return(nil).Once()
+	// percobaan ke-4: 1s * 2^3
+	repo.On("MarkFailed", mock.Anything, int64(2), "storefront down", now.Add(8*time.Second)).Return(nil).Once()
+
+	relay := NewRelay(repo, sink)
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	repo.AssertExpectations(t)
+}
+
+func TestRelay_RelayOnce_RepositoryError(t *testing.T) {
+	repo := new(mocks.OutboxRepository)
+
+	repo.On("ClaimPending", mock.Anything, defaultBatchSize, claimLease).Return(nil, errors.New("db down")).Once()
+
+	relay := NewRelay(repo, sinkFunc(func(context.Context, eventModel.Event) error {
+		t.Fatal("sink must not be called")
+		return nil
+	}))
+
+	_, err := relay.RelayOnce(context.Background())
+	assert.Error(t, err)
+}
+
+func TestRelay_RelayOnce_LeaseExpiring(t *testing.T) {
+	repo := new(mocks.OutboxRepository)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	events := []eventModel.Event{
+		testEvent(t, 1, eventModel.ProductCreated, 0),
+		testEvent(t, 2, eventModel.ProductUpdated, 0),
+	}
+
+	// publish pertama menghabiskan separuh lease, event kedua tidak dikirim
+	// dan klaimnya dilepas
+	sink := sinkFunc(func(ctx context.Context, event eventModel.Event) error {
+		now = now.Add(claimLease)
+		return nil
+	})
+
+	repo.On("ClaimPending", mock.Anything, defaultBatchSize, claimLease).Return(events, nil).Once()
+	repo.On("MarkPublished", mock.Anything, int64(1)).Return(nil).Once()
+	repo.On("ReleaseClaims", mock.Anything, []int64{2}).Return(nil).Once()
+
+	relay := NewRelay(repo, sink)
+	relay.now = func() time.Time { return now }
+
+	n, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	repo.AssertExpectations(t)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 16*time.Second, backoff(5))
+	assert.Equal(t, maxBackoff, backoff(30))
+}
+
+func TestHTTPSink_Publish(t *testing.T) {
+	var got eventModel.Message
+	status := http.StatusAccepted
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "42", r.Header.Get("X-Event-ID"))
+		assert.Equal(t, eventModel.ProductCreated, r.Header.Get("X-Event-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL)
+
+	require.NoError(t, sink.Publish(context.Background(), testEvent(t, 42, eventModel.ProductCreated, 0)))
+	assert.Equal(t, int64(42), got.ID)
+	assert.Equal(t, eventModel.AggregateProduct, got.AggregateType)
+	assert.JSONEq(t, `{"id":7,"status":"active"}`, string(got.Payload))
+
+	// non 2xx dianggap gagal supaya relay mencoba lagi
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Publish(context.Background(), testEvent(t, 42, eventModel.ProductCreated, 0)))
+}
+
+func TestFileSink_Publish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	sink, err := NewFileSink(path)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Publish(context.Background(), testEvent(t, 1, eventModel.ProductCreated, 0)))
+	require.NoError(t, sink.Publish(context.Background(), testEvent(t, 2, eventModel.ProductUpdated, 0)))
+	require.NoError(t, sink.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var types []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var msg eventModel.Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &msg))
+		types = append(types, msg.Type)
+	}
+	assert.Equal(t, []string{eventModel.ProductCreated, eventModel.ProductUpdated}, types)
+}
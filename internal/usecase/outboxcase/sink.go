@@ -0,0 +1,91 @@
+package outboxcase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+)
+
+// HTTPSink mengirim setiap event sebagai POST JSON ke satu URL. Status 2xx
+// dianggap terkirim, selain itu dicoba lagi oleh Relay.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPSink(url string) *HTTPSink {
+	return &HTTPSink{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, event eventModel.Event) error {
+	body, err := json.Marshal(event.Message())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", strconv.FormatInt(event.ID, 10))
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s responded %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// FileSink menulis setiap event sebagai satu baris JSON (JSON Lines),
+// dipakai untuk test dan debugging
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file}, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, event eventModel.Event) error {
+	line, err := json.Marshal(event.Message())
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
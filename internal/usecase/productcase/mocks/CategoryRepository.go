@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type CategoryRepository struct {
+	mock.Mock
+}
+
+// CreateCategory Mock
+func (_m *CategoryRepository) CreateCategory(ctx context.Context, c *productModel.Category) (int64, error) {
+	args := _m.Called(ctx, c)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// UpdateCategory Mock
+func (_m *CategoryRepository) UpdateCategory(ctx context.Context, c *productModel.Category) error {
+	args := _m.Called(ctx, c)
+	return args.Error(0)
+}
+
+// DeleteCategory Mock
+func (_m *CategoryRepository) DeleteCategory(ctx context.Context, id int64, opt productModel.CategoryDelete) (*productModel.CategoryBlockers, error) {
+	args := _m.Called(ctx, id, opt)
+	blockers, _ := args.Get(0).(*productModel.CategoryBlockers)
+	return blockers, args.Error(1)
+}
+
+// FindAllCategory Mock
+func (_m *CategoryRepository) FindAllCategory(ctx context.Context) ([]productModel.Category, error) {
+	args := _m.Called(ctx)
+	categories, _ := args.Get(0).([]productModel.Category)
+	return categories, args.Error(1)
+}
+
+// FindCategory Mock
+func (_m *CategoryRepository) FindCategory(ctx context.Context, id int64) (*productModel.Category, error) {
+	args := _m.Called(ctx, id)
+	category, _ := args.Get(0).(*productModel.Category)
+	return category, args.Error(1)
+}
+
+// FindCategoryByParent Mock
+func (_m *CategoryRepository) FindCategoryByParent(ctx context.Context, id int64) ([]productModel.Category, error) {
+	args := _m.Called(ctx, id)
+	categories, _ := args.Get(0).([]productModel.Category)
+	return categories, args.Error(1)
+}
+
+// FindCategoryAncestors Mock
+func (_m *CategoryRepository) FindCategoryAncestors(ctx context.Context, id int64) ([]productModel.Category, error) {
+	args := _m.Called(ctx, id)
+	categories, _ := args.Get(0).([]productModel.Category)
+	return categories, args.Error(1)
+}
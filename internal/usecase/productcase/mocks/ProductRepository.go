@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -16,3 +17,124 @@ func (_m *ProductRepository) Create(ctx context.Context, p *productModel.Product
 	args := _m.Called(ctx, p)
 	return args.Get(0).(int64), args.Error(1)
 }
+
+// Update Product Mock
+func (_m *ProductRepository) Update(ctx context.Context, p *productModel.Product) error {
+	args := _m.Called(ctx, p)
+	return args.Error(0)
+}
+
+// UpdateProductImages Mock
+func (_m *ProductRepository) UpdateProductImages(ctx context.Context, productID, version int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error) {
+	args := _m.Called(ctx, productID, version, changes)
+	removed, _ := args.Get(0).([]productModel.ProductImage)
+	return removed, args.Error(1)
+}
+
+// ChangeStatus Product Mock
+func (_m *ProductRepository) ChangeStatus(ctx context.Context, id int64, transition productModel.Transition, actor productModel.Actor) (*productModel.StatusChange, error) {
+	args := _m.Called(ctx, id, transition, actor)
+	change, _ := args.Get(0).(*productModel.StatusChange)
+	return change, args.Error(1)
+}
+
+// StatusHistory Product Mock
+func (_m *ProductRepository) StatusHistory(ctx context.Context, productID int64) ([]productModel.StatusChange, error) {
+	args := _m.Called(ctx, productID)
+	history, _ := args.Get(0).([]productModel.StatusChange)
+	return history, args.Error(1)
+}
+
+// FindByID Product Mock
+func (_m *ProductRepository) FindByID(ctx context.Context, id int64) (*productModel.ProductDetail, error) {
+	args := _m.Called(ctx, id)
+	p, _ := args.Get(0).(*productModel.ProductDetail)
+	return p, args.Error(1)
+}
+
+// FindAll Product Mock
+func (_m *ProductRepository) FindAll(ctx context.Context, filter Repository.ProductFilter) ([]productModel.Product, *Repository.ProductCursor, error) {
+	args := _m.Called(ctx, filter)
+	products, _ := args.Get(0).([]productModel.Product)
+	next, _ := args.Get(1).(*Repository.ProductCursor)
+	return products, next, args.Error(2)
+}
+
+// CountAll Product Mock
+func (_m *ProductRepository) CountAll(ctx context.Context, filter Repository.ProductFilter) (int64, error) {
+	args := _m.Called(ctx, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Search Product Mock
+func (_m *ProductRepository) Search(ctx context.Context, query string, filter Repository.ProductFilter) ([]productModel.SearchHit, error) {
+	args := _m.Called(ctx, query, filter)
+	hits, _ := args.Get(0).([]productModel.SearchHit)
+	return hits, args.Error(1)
+}
+
+// StreamExport Mock
+func (_m *ProductRepository) StreamExport(ctx context.Context, filter Repository.ProductFilter, fn func(productModel.ExportRow) error) error {
+	args := _m.Called(ctx, filter, fn)
+	return args.Error(0)
+}
+
+// GetImageById Mock
+func (_m *ProductRepository) GetImageById(ctx context.Context, id int64) (*productModel.ProductImage, error) {
+	args := _m.Called(ctx, id)
+	image, _ := args.Get(0).(*productModel.ProductImage)
+	return image, args.Error(1)
+}
+
+// ListImageURLs Mock
+func (_m *ProductRepository) ListImageURLs(ctx context.Context) ([]string, error) {
+	args := _m.Called(ctx)
+	urls, _ := args.Get(0).([]string)
+	return urls, args.Error(1)
+}
+
+// PurgeImageBlob Mock
+func (_m *ProductRepository) PurgeImageBlob(ctx context.Context, hash string) (bool, error) {
+	args := _m.Called(ctx, hash)
+	return args.Bool(0), args.Error(1)
+}
+
+// CreateVariant Mock
+func (_m *ProductRepository) CreateVariant(ctx context.Context, productID int64, v *productModel.Variant) (int64, error) {
+	args := _m.Called(ctx, productID, v)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// UpdateVariant Mock
+func (_m *ProductRepository) UpdateVariant(ctx context.Context, v *productModel.Variant) error {
+	args := _m.Called(ctx, v)
+	return args.Error(0)
+}
+
+// DeleteVariant Mock
+func (_m *ProductRepository) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	args := _m.Called(ctx, productID, variantID)
+	return args.Error(0)
+}
+
+// FindVariantByID Mock
+func (_m *ProductRepository) FindVariantByID(ctx context.Context, productID, variantID int64) (*productModel.Variant, error) {
+	args := _m.Called(ctx, productID, variantID)
+	v, _ := args.Get(0).(*productModel.Variant)
+	return v, args.Error(1)
+}
+
+// FindByBarcode Mock
+func (_m *ProductRepository) FindByBarcode(ctx context.Context, code string) (*productModel.ScanItem, error) {
+	args := _m.Called(ctx, code)
+	item, _ := args.Get(0).(*productModel.ScanItem)
+	return item, args.Error(1)
+}
+
+// FindUsedCodes Mock
+func (_m *ProductRepository) FindUsedCodes(ctx context.Context, skus, barcodes []string) ([]string, []string, error) {
+	args := _m.Called(ctx, skus, barcodes)
+	usedSKUs, _ := args.Get(0).([]string)
+	usedBarcodes, _ := args.Get(1).([]string)
+	return usedSKUs, usedBarcodes, args.Error(2)
+}
@@ -2,34 +2,49 @@ package productcase
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
 	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
 	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
 )
 
 type ProductService interface {
 	// ------ PRODUCT ------
 	CreateProduct(ctx context.Context, p *productModel.Product) (*int64, error)
 	UpdateProduct(ctx context.Context, p *productModel.Product) error
-	DeleteProduct(ctx context.Context, id int64) error
+	UpdateProductImages(ctx context.Context, productID, version int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error)
+	DeleteProduct(ctx context.Context, id int64, actor productModel.Actor) error
+	ChangeProductStatus(ctx context.Context, id int64, transition productModel.Transition, actor productModel.Actor) (*productModel.StatusChange, error)
+	GetStatusHistory(ctx context.Context, id int64) ([]productModel.StatusChange, error)
 	GetProductByID(ctx context.Context, id int64) (*productModel.ProductDetail, error)
-	ListProducts(ctx context.Context, filter ProductFilter) ([]productModel.Product, error)
-	GetProductImage(ctx context.Context, id int64) (string, error)
+	ListProducts(ctx context.Context, filter ProductFilter) (*ProductPage, error)
+	SearchProducts(ctx context.Context, query string, filter ProductFilter) (*SearchPage, error)
+	ExportProducts(ctx context.Context, filter ProductFilter, fn func(productModel.ExportRow) error) error
+	GetProductImage(ctx context.Context, id int64) (*productModel.ProductImage, error)
+	ReleaseImage(ctx context.Context, img productModel.ProductImage) (bool, error)
 
 	// ------ CATEGORY ------
 	CreateCategory(ctx context.Context, c *productModel.Category) (*int64, error)
 	UpdateCategory(ctx context.Context, c *productModel.Category) error
-	DeleteCategory(ctx context.Context, id int64) error
+	DeleteCategory(ctx context.Context, id int64, opt productModel.CategoryDelete) (*productModel.CategoryBlockers, error)
 	ListCategories(ctx context.Context) ([]productModel.Category, error)
 	GetCategory(ctx context.Context, id int64) (*productModel.Category, error)
-	// GetCategoryByParentId(ctx context.Context, id int64) ([]productModel.Category, error)
+	GetCategoryTree(ctx context.Context) ([]*productModel.CategoryNode, error)
+	GetCategoryChildren(ctx context.Context, id int64) ([]productModel.Category, error)
+	GetCategoryAncestors(ctx context.Context, id int64) ([]productModel.Category, error)
 
-	// // ------ VARIANT ------
-	// AddVariant(ctx context.Context, v *productModel.Variant) (int64, error)
-	// UpdateVariant(ctx context.Context, v *productModel.Variant) error
-	// DeleteVariant(ctx context.Context, id int64) error
-	// GetVariantByID(ctx context.Context, id int64) (*productModel.Variant, error)
+	// ------ VARIANT ------
+	AddVariant(ctx context.Context, productID int64, v *productModel.Variant) (*int64, error)
+	UpdateVariant(ctx context.Context, v *productModel.Variant) error
+	DeleteVariant(ctx context.Context, productID, variantID int64) error
+	GetVariantByID(ctx context.Context, productID, variantID int64) (*productModel.Variant, error)
+
+	// ------ SCAN ------
+	ScanBarcode(ctx context.Context, code string) (*productModel.ScanItem, error)
 
 	// // ------ VARIANT OPTION ------
 	// AddVariantOption(ctx context.Context, variantID int64, opt *productModel.VariantOption) error
@@ -43,11 +58,44 @@ type ProductService interface {
 }
 
 type ProductFilter struct {
-	Search     string
-	CategoryID *int64
-	Status     *string // active/inactive/archived
+	Search             string
+	CategoryID         *int64
+	CategoryIDs        []int64
+	CategoryMatch      string  // any/all, kosong = any
+	IncludeDescendants bool    // ikutkan product dari sub-kategori
+	Status             *string // active/inactive/archived
+	MinPrice           *int64
+	MaxPrice           *int64
+	StockState         string // in_stock/low_stock/out_of_stock
+	LowStockThreshold  int    // kosong = DefaultLowStockThreshold
+	Options            map[string][]string
+	HasBarcode         *bool
+	CreatedFrom        *time.Time
+	CreatedTo          *time.Time
+	UpdatedFrom        *time.Time
+	UpdatedTo          *time.Time
+	Sort               string // id/name/created_at/updated_at/price, kosong = id (urutan dibuat)
+	Direction          string // asc/desc, kosong = default per sort
+	Cursor             *Repository.ProductCursor
+	WithTotal          bool
+	Limit              int
+	Offset             int
+}
+
+// ProductPage satu halaman ListProducts. Next nil berarti halaman terakhir.
+type ProductPage struct {
+	Products []productModel.Product
+	Limit    int
+	Next     *Repository.ProductCursor
+	Total    *int64 // hanya jika WithTotal
+}
+
+// SearchPage satu halaman hasil pencarian. Urutan relevansi tidak stabil
+// untuk keyset, jadi halaman berikutnya memakai offset.
+type SearchPage struct {
+	Hits       []productModel.SearchHit
 	Limit      int
-	Offset     int
+	NextOffset *int
 }
 
 type ProductUseCase struct {
@@ -67,6 +115,12 @@ func NewProductService(productRepo Repository.ProductRepoInterface, categoryRepo
 // ----------------------------------------------------------------------
 
 func (s *ProductUseCase) CreateProduct(ctx context.Context, p *productModel.Product) (*int64, error) {
+	// product baru tidak boleh langsung archived
+	switch p.Status {
+	case "", productModel.StatusActive, productModel.StatusInactive:
+	default:
+		return nil, errorUtils.ErrBadRequest
+	}
 
 	id, err := s.productRepo.Create(ctx, p)
 	if err != nil {
@@ -82,40 +136,266 @@ func (s *ProductUseCase) UpdateProduct(ctx context.Context, p *productModel.Prod
 	return s.productRepo.Update(ctx, p)
 }
 
-func (s *ProductUseCase) DeleteProduct(ctx context.Context, id int64) error {
-	return s.productRepo.Delete(ctx, id)
+func (s *ProductUseCase) UpdateProductImages(ctx context.Context, productID, version int64, changes productModel.ImageChanges) ([]productModel.ProductImage, error) {
+	return s.productRepo.UpdateProductImages(ctx, productID, version, changes)
+}
+
+// DeleteProduct = archive (soft delete), lewat state machine yang sama
+func (s *ProductUseCase) DeleteProduct(ctx context.Context, id int64, actor productModel.Actor) error {
+	_, err := s.ChangeProductStatus(ctx, id, productModel.TransitionArchive, actor)
+	return err
+}
+
+// ChangeProductStatus menjalankan satu transisi lifecycle (activate,
+// deactivate, archive, restore) dan mencatat siapa yang melakukannya.
+func (s *ProductUseCase) ChangeProductStatus(ctx context.Context, id int64, transition productModel.Transition, actor productModel.Actor) (*productModel.StatusChange, error) {
+	if !transition.Valid() {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	change, err := s.productRepo.ChangeStatus(ctx, id, transition, actor)
+	if err != nil {
+		logger.Errorf("ChangeProductStatus %d %s fail, error: %s", id, transition, err)
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (s *ProductUseCase) GetStatusHistory(ctx context.Context, id int64) ([]productModel.StatusChange, error) {
+	return s.productRepo.StatusHistory(ctx, id)
 }
 
 func (s *ProductUseCase) GetProductByID(ctx context.Context, id int64) (*productModel.ProductDetail, error) {
 	return s.productRepo.FindByID(ctx, id)
 }
 
-func (s *ProductUseCase) ListProducts(ctx context.Context, filter ProductFilter) ([]productModel.Product, error) {
-	// Map struct usecase filter -> repo filter
+// DefaultLowStockThreshold batas stok menipis jika tidak diisi
+const DefaultLowStockThreshold = 5
+
+// Map struct usecase filter -> repo filter. Kombinasi yang tidak masuk akal
+// (rentang terbalik, nilai tidak dikenal) menghasilkan ErrBadRequest.
+func (filter ProductFilter) repoFilter() (Repository.ProductFilter, error) {
 	repoFilter := Repository.ProductFilter{
-		Keyword:    filter.Search,
-		CategoryID: filter.CategoryID,
-		Limit:      filter.Limit,
-		Offset:     filter.Offset,
+		Keyword:            filter.Search,
+		CategoryID:         filter.CategoryID,
+		CategoryIDs:        filter.CategoryIDs,
+		IncludeDescendants: filter.IncludeDescendants,
+		MinPrice:           filter.MinPrice,
+		MaxPrice:           filter.MaxPrice,
+		StockState:         filter.StockState,
+		LowStockThreshold:  filter.LowStockThreshold,
+		HasBarcode:         filter.HasBarcode,
+		CreatedFrom:        filter.CreatedFrom,
+		CreatedTo:          filter.CreatedTo,
+		UpdatedFrom:        filter.UpdatedFrom,
+		UpdatedTo:          filter.UpdatedTo,
+		Limit:              filter.Limit,
+		Offset:             filter.Offset,
 	}
 
 	if filter.Status != nil {
 		repoFilter.Status = *filter.Status
 	}
 
-	products, err := s.productRepo.FindAll(ctx, repoFilter)
+	switch strings.ToLower(filter.CategoryMatch) {
+	case "", "any":
+	case "all":
+		repoFilter.CategoryMatchAll = true
+	default:
+		return repoFilter, errorUtils.ErrBadRequest
+	}
+
+	if (filter.MinPrice != nil && *filter.MinPrice < 0) || (filter.MaxPrice != nil && *filter.MaxPrice < 0) {
+		return repoFilter, errorUtils.ErrBadRequest
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return repoFilter, errorUtils.ErrBadRequest
+	}
+
+	switch filter.StockState {
+	case "", Repository.StockIn, Repository.StockOut:
+	case Repository.StockLow:
+		if repoFilter.LowStockThreshold <= 0 {
+			repoFilter.LowStockThreshold = DefaultLowStockThreshold
+		}
+	default:
+		return repoFilter, errorUtils.ErrBadRequest
+	}
+
+	if len(filter.Options) > 0 {
+		repoFilter.Options = map[string][]string{}
+		for name, values := range filter.Options {
+			name = strings.TrimSpace(name)
+			if name == "" || len(values) == 0 {
+				return repoFilter, errorUtils.ErrBadRequest
+			}
+			repoFilter.Options[name] = values
+		}
+	}
+
+	if (filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo)) ||
+		(filter.UpdatedFrom != nil && filter.UpdatedTo != nil && !filter.UpdatedFrom.Before(*filter.UpdatedTo)) {
+		return repoFilter, errorUtils.ErrBadRequest
+	}
+
+	return repoFilter, nil
+}
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 200
+)
+
+// ListProducts dengan keyset cursor. Cursor hanya sah untuk sort & arah yang
+// sama dengan saat cursor dibuat.
+func (s *ProductUseCase) ListProducts(ctx context.Context, filter ProductFilter) (*ProductPage, error) {
+	repoFilter, err := filter.repoFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	// archived hanya tampil jika diminta lewat status=archived
+	repoFilter.ExcludeArchived = filter.Status == nil
+
+	switch filter.Sort {
+	case "id":
+		// alias untuk urutan default
+		repoFilter.Sort = Repository.SortID
+	case Repository.SortID, Repository.SortName, Repository.SortCreatedAt,
+		Repository.SortUpdatedAt, Repository.SortPrice:
+		repoFilter.Sort = filter.Sort
+	default:
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	switch strings.ToLower(filter.Direction) {
+	case "":
+		// nama & harga wajar dari kecil, selain itu terbaru dulu
+		repoFilter.Desc = repoFilter.Sort != Repository.SortName && repoFilter.Sort != Repository.SortPrice
+	case "asc":
+		repoFilter.Desc = false
+	case "desc":
+		repoFilter.Desc = true
+	default:
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	if filter.Cursor != nil {
+		if filter.Cursor.Sort != repoFilter.Sort || filter.Cursor.Desc != repoFilter.Desc {
+			return nil, errorUtils.ErrBadRequest
+		}
+		repoFilter.After = filter.Cursor
+	}
+
+	if repoFilter.Limit <= 0 {
+		repoFilter.Limit = DefaultListLimit
+	}
+	if repoFilter.Limit > MaxListLimit {
+		repoFilter.Limit = MaxListLimit
+	}
+
+	products, next, err := s.productRepo.FindAll(ctx, repoFilter)
 	if err != nil {
 		logger.Errorf("ListProducts fail, error: %s", err)
 		return nil, err
 	}
 
-	return products, nil
+	page := &ProductPage{Products: products, Limit: repoFilter.Limit, Next: next}
+
+	if filter.WithTotal {
+		total, err := s.productRepo.CountAll(ctx, repoFilter)
+		if err != nil {
+			logger.Errorf("ListProducts count fail, error: %s", err)
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
 }
 
-func (s *ProductUseCase) GetProductImage(ctx context.Context, id int64) (string, error) {
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchProducts mencari product berdasarkan relevansi. filter.Search
+// diabaikan, kata kunci diambil dari query.
+func (s *ProductUseCase) SearchProducts(ctx context.Context, query string, filter ProductFilter) (*SearchPage, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultSearchLimit
+	}
+	if filter.Limit > MaxSearchLimit {
+		filter.Limit = MaxSearchLimit
+	}
+
+	// satu baris lebih untuk tahu masih ada halaman berikutnya
+	repoFilter, err := filter.repoFilter()
+	if err != nil {
+		return nil, err
+	}
+	repoFilter.Limit = filter.Limit + 1
+
+	hits, err := s.productRepo.Search(ctx, query, repoFilter)
+	if err != nil {
+		logger.Errorf("SearchProducts fail, error: %s", err)
+		return nil, err
+	}
+
+	page := &SearchPage{Hits: hits, Limit: filter.Limit}
+	if len(hits) > filter.Limit {
+		page.Hits = hits[:filter.Limit]
+		next := filter.Offset + filter.Limit
+		page.NextOffset = &next
+	}
+
+	return page, nil
+}
+
+// ExportProducts mengalirkan katalog per unit ke fn tanpa memuat semuanya ke memori
+func (s *ProductUseCase) ExportProducts(ctx context.Context, filter ProductFilter, fn func(productModel.ExportRow) error) error {
+	repoFilter, err := filter.repoFilter()
+	if err != nil {
+		return err
+	}
+
+	err = s.productRepo.StreamExport(ctx, repoFilter, fn)
+	if err != nil {
+		logger.Errorf("ExportProducts fail, error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ProductUseCase) GetProductImage(ctx context.Context, id int64) (*productModel.ProductImage, error) {
 	return s.productRepo.GetImageById(ctx, id)
 }
 
+// ReleaseImage dipanggil setelah gambar dilepas dari product. Hasil true
+// berarti file fisik boleh langsung dihapus pemanggil: gambar lama tanpa
+// hash, atau file ber-hash yang referensi terakhirnya baru saja dilepas
+// (baris image_blobs-nya terhapus).
+func (s *ProductUseCase) ReleaseImage(ctx context.Context, img productModel.ProductImage) (bool, error) {
+	// gambar lama (sebelum dedup) selalu punya file sendiri
+	if img.Hash == "" {
+		return true, nil
+	}
+
+	unused, err := s.productRepo.PurgeImageBlob(ctx, img.Hash)
+	if err != nil {
+		logger.Errorf("ReleaseImage fail, error: %s", err)
+		return false, err
+	}
+	return unused, nil
+}
+
 // ----------------------------------------------------------------------
 // Category Product
 // ----------------------------------------------------------------------
@@ -140,14 +420,31 @@ func (s *ProductUseCase) UpdateCategory(ctx context.Context, c *productModel.Cat
 	return nil
 }
 
-func (s *ProductUseCase) DeleteCategory(ctx context.Context, id int64) error {
-	err := s.categoryRepo.DeleteCategory(ctx, id)
+// DeleteCategory menghapus kategori sesuai strategi, default forbid_if_used.
+// Jika ditolak, blockers berisi sub-kategori & product yang masih memakai.
+func (s *ProductUseCase) DeleteCategory(ctx context.Context, id int64, opt productModel.CategoryDelete) (*productModel.CategoryBlockers, error) {
+	if opt.Strategy == "" {
+		opt.Strategy = productModel.CategoryDeleteForbid
+	}
+
+	switch opt.Strategy {
+	case productModel.CategoryDeleteForbid, productModel.CategoryDeleteReparent:
+		opt.MoveProductsTo = nil
+	case productModel.CategoryDeleteMove:
+		if opt.MoveProductsTo == nil || *opt.MoveProductsTo == id {
+			return nil, errorUtils.ErrBadRequest
+		}
+	default:
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	blockers, err := s.categoryRepo.DeleteCategory(ctx, id, opt)
 	if err != nil {
 		logger.Errorf("Delete fail, error: %s", err)
-		return err
+		return blockers, err
 	}
 
-	return nil
+	return nil, nil
 }
 
 func (s *ProductUseCase) ListCategories(ctx context.Context) ([]productModel.Category, error) {
@@ -170,23 +467,133 @@ func (s *ProductUseCase) GetCategory(ctx context.Context, id int64) (*productMod
 	return categories, nil
 }
 
-// // ----------------------------------------------------------------------
-// // PARTIAL EDIT: ADD VARIANT
-// // ----------------------------------------------------------------------
+// GetCategoryTree menyusun seluruh kategori menjadi pohon, anak diurutkan
+// berdasarkan nama
+func (s *ProductUseCase) GetCategoryTree(ctx context.Context) ([]*productModel.CategoryNode, error) {
+	categories, err := s.categoryRepo.FindAllCategory(ctx)
+	if err != nil {
+		logger.Errorf("failed to Get Category Tree, error: %s", err)
+		return nil, err
+	}
 
-// func (s *ProductUseCase) AddVariant(ctx context.Context, productID int64, v productModel.Variant) error {
-// 	// 1. Load produk lengkap
-// 	p, err := s.productRepo.FindByID(ctx, productID)
-// 	if err != nil {
-// 		return err
-// 	}
+	return BuildCategoryTree(categories), nil
+}
 
-// 	// 2. Tambah variant
-// 	p.Variants = append(p.Variants, v)
+func (s *ProductUseCase) GetCategoryChildren(ctx context.Context, id int64) ([]productModel.Category, error) {
+	if _, err := s.categoryRepo.FindCategory(ctx, id); err != nil {
+		return nil, err
+	}
 
-// 	// 3. Simpan full product kembali
-// 	return s.productRepo.Update(ctx, p)
-// }
+	children, err := s.categoryRepo.FindCategoryByParent(ctx, id)
+	if err != nil {
+		logger.Errorf("fail Get Category Children, error: %s", err)
+		return nil, err
+	}
+
+	return children, nil
+}
+
+func (s *ProductUseCase) GetCategoryAncestors(ctx context.Context, id int64) ([]productModel.Category, error) {
+	if _, err := s.categoryRepo.FindCategory(ctx, id); err != nil {
+		return nil, err
+	}
+
+	ancestors, err := s.categoryRepo.FindCategoryAncestors(ctx, id)
+	if err != nil {
+		logger.Errorf("fail Get Category Ancestors, error: %s", err)
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+// BuildCategoryTree mengubah daftar kategori datar menjadi pohon. Kategori
+// yang tidak terhubung ke root (data siklik) tidak ikut.
+func BuildCategoryTree(categories []productModel.Category) []*productModel.CategoryNode {
+	nodes := make(map[int64]*productModel.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &productModel.CategoryNode{ID: c.ID, Name: c.Name, ParentID: c.ParentID, Children: []*productModel.CategoryNode{}}
+	}
+
+	roots := []*productModel.CategoryNode{}
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	var sortNodes func([]*productModel.CategoryNode)
+	sortNodes = func(list []*productModel.CategoryNode) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		for _, n := range list {
+			sortNodes(n.Children)
+		}
+	}
+	sortNodes(roots)
+
+	return roots
+}
+
+// ----------------------------------------------------------------------
+// VARIANT
+// ----------------------------------------------------------------------
+
+func (s *ProductUseCase) AddVariant(ctx context.Context, productID int64, v *productModel.Variant) (*int64, error) {
+	id, err := s.productRepo.CreateVariant(ctx, productID, v)
+	if err != nil {
+		logger.Errorf("AddVariant fail, error: %s", err)
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+func (s *ProductUseCase) UpdateVariant(ctx context.Context, v *productModel.Variant) error {
+	err := s.productRepo.UpdateVariant(ctx, v)
+	if err != nil {
+		logger.Errorf("UpdateVariant fail, error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ProductUseCase) DeleteVariant(ctx context.Context, productID, variantID int64) error {
+	err := s.productRepo.DeleteVariant(ctx, productID, variantID)
+	if err != nil {
+		logger.Errorf("DeleteVariant fail, error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *ProductUseCase) GetVariantByID(ctx context.Context, productID, variantID int64) (*productModel.Variant, error) {
+	return s.productRepo.FindVariantByID(ctx, productID, variantID)
+}
+
+// ----------------------------------------------------------------------
+// SCAN
+// ----------------------------------------------------------------------
+
+func (s *ProductUseCase) ScanBarcode(ctx context.Context, code string) (*productModel.ScanItem, error) {
+	item, err := s.productRepo.FindByBarcode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// produk yang sudah diarsipkan tidak boleh dijual lagi
+	if item.ProductStatus == "archived" {
+		return nil, errorUtils.ErrNotFound
+	}
+
+	return item, nil
+}
 
 // // ----------------------------------------------------------------------
 // // PARTIAL EDIT: ADD VARIANT OPTION
@@ -2,15 +2,25 @@ package productcase
 
 import (
 	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/productModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/productrepo"
 	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/productcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
 func TestProductUseCase_CreateProduct_Success(t *testing.T) {
 	repo := new(mocks.ProductRepository)
 
@@ -57,3 +67,458 @@ func TestProductUseCase_CreateProduct_Success(t *testing.T) {
 // 	// repo tidak boleh dipanggil
 // 	repo.AssertNotCalled(t, "Create")
 // }
+
+func TestProductUseCase_AddVariant_Success(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+
+	uc := &ProductUseCase{
+		productRepo: repo,
+	}
+
+	variant := &productModel.Variant{
+		SKU:      "TEH-001",
+		BaseUnit: "pcs",
+		Units: []productModel.VariantUnit{
+			{Name: "pcs", ConversionRate: 1, Price: 3000},
+		},
+	}
+
+	repo.
+		On("CreateVariant", mock.Anything, int64(7), variant).
+		Return(int64(3), nil).
+		Once()
+
+	id, err := uc.AddVariant(context.Background(), 7, variant)
+
+	require.NoError(t, err)
+	require.NotNil(t, id)
+	assert.Equal(t, int64(3), *id)
+
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_UpdateVariant_NotFound(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+
+	uc := &ProductUseCase{
+		productRepo: repo,
+	}
+
+	variant := &productModel.Variant{ID: 99, ProductID: 7, SKU: "TEH-001", BaseUnit: "pcs"}
+
+	repo.
+		On("UpdateVariant", mock.Anything, variant).
+		Return(errorUtils.ErrNotFound).
+		Once()
+
+	err := uc.UpdateVariant(context.Background(), variant)
+
+	assert.ErrorIs(t, err, errorUtils.ErrNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_ScanBarcode(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+
+	uc := &ProductUseCase{
+		productRepo: repo,
+	}
+
+	active := &productModel.ScanItem{ProductID: 1, ProductStatus: "active", UnitName: "pcs", Price: 3000}
+	archived := &productModel.ScanItem{ProductID: 2, ProductStatus: "archived"}
+
+	repo.On("FindByBarcode", mock.Anything, "8991").Return(active, nil).Once()
+	repo.On("FindByBarcode", mock.Anything, "8992").Return(archived, nil).Once()
+
+	item, err := uc.ScanBarcode(context.Background(), "8991")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3000), item.Price)
+
+	_, err = uc.ScanBarcode(context.Background(), "8992")
+	assert.ErrorIs(t, err, errorUtils.ErrNotFound)
+
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_ReleaseImage(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+
+	uc := &ProductUseCase{
+		productRepo: repo,
+	}
+
+	repo.On("PurgeImageBlob", mock.Anything, "shared").Return(false, nil).Once()
+	repo.On("PurgeImageBlob", mock.Anything, "last").Return(true, nil).Once()
+
+	// masih dipakai product lain
+	unused, err := uc.ReleaseImage(context.Background(), productModel.ProductImage{Hash: "shared"})
+	require.NoError(t, err)
+	assert.False(t, unused)
+
+	// referensi terakhir: baris blob dibersihkan, file boleh dihapus
+	unused, err = uc.ReleaseImage(context.Background(), productModel.ProductImage{Hash: "last"})
+	require.NoError(t, err)
+	assert.True(t, unused)
+
+	// gambar lama tanpa hash tidak dibagi
+	unused, err = uc.ReleaseImage(context.Background(), productModel.ProductImage{URL: "/uploads/1.jpg"})
+	require.NoError(t, err)
+	assert.True(t, unused)
+
+	repo.AssertExpectations(t)
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	drinks, soda := int64(1), int64(2)
+	categories := []productModel.Category{
+		{ID: 3, Name: "Zero", ParentID: &soda},
+		{ID: 2, Name: "Soda", ParentID: &drinks},
+		{ID: 4, Name: "Juice", ParentID: &drinks},
+		{ID: 1, Name: "Drinks"},
+		{ID: 5, Name: "Bakery"},
+	}
+
+	tree := BuildCategoryTree(categories)
+
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Bakery", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+
+	require.Len(t, tree[1].Children, 2)
+	assert.Equal(t, "Juice", tree[1].Children[0].Name)
+	assert.Equal(t, "Soda", tree[1].Children[1].Name)
+	require.Len(t, tree[1].Children[1].Children, 1)
+	assert.Equal(t, int64(3), tree[1].Children[1].Children[0].ID)
+}
+
+func TestProductUseCase_GetCategoryChildren(t *testing.T) {
+	categoryRepo := new(mocks.CategoryRepository)
+	uc := &ProductUseCase{categoryRepo: categoryRepo}
+
+	parent := int64(1)
+	categoryRepo.On("FindCategory", mock.Anything, int64(1)).Return(&productModel.Category{ID: 1, Name: "Drinks"}, nil)
+	categoryRepo.On("FindCategoryByParent", mock.Anything, int64(1)).
+		Return([]productModel.Category{{ID: 2, Name: "Soda", ParentID: &parent}}, nil)
+	categoryRepo.On("FindCategory", mock.Anything, int64(9)).Return(nil, errorUtils.ErrNotFound)
+
+	children, err := uc.GetCategoryChildren(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Len(t, children, 1)
+
+	_, err = uc.GetCategoryChildren(context.Background(), 9)
+	assert.Equal(t, errorUtils.ErrNotFound, err)
+	categoryRepo.AssertNotCalled(t, "FindCategoryByParent", mock.Anything, int64(9))
+}
+
+func TestProductUseCase_ListProducts_IncludeDescendants(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	categoryID := int64(1)
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return *f.CategoryID == categoryID && f.IncludeDescendants
+	})).Return([]productModel.Product{}, nil, nil).Once()
+
+	_, err := uc.ListProducts(context.Background(), ProductFilter{CategoryID: &categoryID, IncludeDescendants: true})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_DeleteCategory(t *testing.T) {
+	target := int64(7)
+
+	tests := []struct {
+		name    string
+		opt     productModel.CategoryDelete
+		repoOpt *productModel.CategoryDelete // nil = repo tidak dipanggil
+		wantErr error
+	}{
+		{
+			name:    "default forbid",
+			opt:     productModel.CategoryDelete{},
+			repoOpt: &productModel.CategoryDelete{Strategy: productModel.CategoryDeleteForbid},
+		},
+		{
+			name:    "reparent ignores target",
+			opt:     productModel.CategoryDelete{Strategy: productModel.CategoryDeleteReparent, MoveProductsTo: &target},
+			repoOpt: &productModel.CategoryDelete{Strategy: productModel.CategoryDeleteReparent},
+		},
+		{
+			name:    "move products",
+			opt:     productModel.CategoryDelete{Strategy: productModel.CategoryDeleteMove, MoveProductsTo: &target},
+			repoOpt: &productModel.CategoryDelete{Strategy: productModel.CategoryDeleteMove, MoveProductsTo: &target},
+		},
+		{
+			name:    "move without target",
+			opt:     productModel.CategoryDelete{Strategy: productModel.CategoryDeleteMove},
+			wantErr: errorUtils.ErrBadRequest,
+		},
+		{
+			name:    "unknown strategy",
+			opt:     productModel.CategoryDelete{Strategy: "cascade"},
+			wantErr: errorUtils.ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			categoryRepo := new(mocks.CategoryRepository)
+			uc := &ProductUseCase{categoryRepo: categoryRepo}
+
+			if tt.repoOpt != nil {
+				categoryRepo.On("DeleteCategory", mock.Anything, int64(1), *tt.repoOpt).Return(nil, nil).Once()
+			}
+
+			_, err := uc.DeleteCategory(context.Background(), 1, tt.opt)
+			assert.Equal(t, tt.wantErr, err)
+			categoryRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductUseCase_DeleteCategory_Blocked(t *testing.T) {
+	categoryRepo := new(mocks.CategoryRepository)
+	uc := &ProductUseCase{categoryRepo: categoryRepo}
+
+	blockers := &productModel.CategoryBlockers{Children: []int64{2}, Products: []int64{10, 11}, ProductCount: 2}
+	categoryRepo.On("DeleteCategory", mock.Anything, int64(1), mock.Anything).Return(blockers, errorUtils.ErrConflict)
+
+	got, err := uc.DeleteCategory(context.Background(), 1, productModel.CategoryDelete{})
+	assert.Equal(t, errorUtils.ErrConflict, err)
+	assert.Equal(t, blockers, got)
+}
+
+func TestProductUseCase_SearchProducts(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	_, err := uc.SearchProducts(context.Background(), "   ", ProductFilter{})
+	assert.Equal(t, errorUtils.ErrBadRequest, err)
+
+	hits := []productModel.SearchHit{{Product: productModel.Product{ID: 1, Name: "Cola"}, Score: 0.8}}
+	repo.On("Search", mock.Anything, "cola", mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return f.Limit == MaxSearchLimit+1
+	})).Return(hits, nil).Once()
+
+	got, err := uc.SearchProducts(context.Background(), " cola ", ProductFilter{Limit: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, hits, got.Hits)
+	assert.Equal(t, MaxSearchLimit, got.Limit)
+	assert.Nil(t, got.NextOffset)
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_SearchProducts_NextOffset(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	hits := []productModel.SearchHit{{Product: productModel.Product{ID: 1}}, {Product: productModel.Product{ID: 2}}}
+	repo.On("Search", mock.Anything, "cola", mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return f.Limit == 2 && f.Offset == 4
+	})).Return(hits, nil).Once()
+
+	got, err := uc.SearchProducts(context.Background(), "cola", ProductFilter{Limit: 1, Offset: 4})
+	require.NoError(t, err)
+	assert.Equal(t, hits[:1], got.Hits)
+	require.NotNil(t, got.NextOffset)
+	assert.Equal(t, 5, *got.NextOffset)
+}
+
+func TestProductUseCase_ListProducts_Sort(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   ProductFilter
+		wantDesc bool
+		wantErr  error
+	}{
+		{name: "default terbaru dulu", filter: ProductFilter{}, wantDesc: true},
+		{name: "nama default asc", filter: ProductFilter{Sort: "name"}, wantDesc: false},
+		{name: "harga desc", filter: ProductFilter{Sort: "price", Direction: "DESC"}, wantDesc: true},
+		{name: "updated asc", filter: ProductFilter{Sort: "updated_at", Direction: "asc"}, wantDesc: false},
+		{name: "sort tidak dikenal", filter: ProductFilter{Sort: "stock"}, wantErr: errorUtils.ErrBadRequest},
+		{name: "arah tidak dikenal", filter: ProductFilter{Direction: "up"}, wantErr: errorUtils.ErrBadRequest},
+		{
+			name:    "cursor beda sort",
+			filter:  ProductFilter{Sort: "name", Cursor: &Repository.ProductCursor{Sort: "price", ID: 3}},
+			wantErr: errorUtils.ErrBadRequest,
+		},
+		{
+			name:    "cursor beda arah",
+			filter:  ProductFilter{Sort: "name", Cursor: &Repository.ProductCursor{Sort: "name", Desc: true, ID: 3}},
+			wantErr: errorUtils.ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mocks.ProductRepository)
+			uc := &ProductUseCase{productRepo: repo}
+
+			repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+				return f.Sort == tt.filter.Sort && f.Desc == tt.wantDesc && f.Limit == DefaultListLimit
+			})).Return([]productModel.Product{}, nil, nil).Maybe()
+
+			_, err := uc.ListProducts(context.Background(), tt.filter)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				repo.AssertNotCalled(t, "FindAll", mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestProductUseCase_ListProducts_SortIDAlias(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return f.Sort == Repository.SortID && f.Desc
+	})).Return([]productModel.Product{}, nil, nil).Once()
+
+	_, err := uc.ListProducts(context.Background(), ProductFilter{Sort: "id"})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_ListProducts_CursorAndTotal(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	cursor := &Repository.ProductCursor{Sort: "name", Value: "Cola", ID: 4}
+	next := &Repository.ProductCursor{Sort: "name", Value: "Fanta", ID: 9}
+	products := []productModel.Product{{ID: 9, Name: "Fanta"}}
+
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return f.After == cursor && f.Limit == MaxListLimit
+	})).Return(products, next, nil).Once()
+	repo.On("CountAll", mock.Anything, mock.Anything).Return(int64(120), nil).Once()
+
+	page, err := uc.ListProducts(context.Background(), ProductFilter{Sort: "name", Cursor: cursor, WithTotal: true, Limit: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, products, page.Products)
+	assert.Equal(t, next, page.Next)
+	assert.Equal(t, MaxListLimit, page.Limit)
+	require.NotNil(t, page.Total)
+	assert.Equal(t, int64(120), *page.Total)
+	repo.AssertExpectations(t)
+}
+
+func TestProductFilter_RepoFilter(t *testing.T) {
+	price := func(v int64) *int64 { return &v }
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		filter  ProductFilter
+		check   func(t *testing.T, f Repository.ProductFilter)
+		wantErr bool
+	}{
+		{
+			name:   "kategori all",
+			filter: ProductFilter{CategoryIDs: []int64{1, 2}, CategoryMatch: "ALL"},
+			check: func(t *testing.T, f Repository.ProductFilter) {
+				assert.True(t, f.CategoryMatchAll)
+				assert.Equal(t, []int64{1, 2}, f.CategoryIDs)
+			},
+		},
+		{name: "kategori match tidak dikenal", filter: ProductFilter{CategoryMatch: "some"}, wantErr: true},
+		{
+			name:   "rentang harga",
+			filter: ProductFilter{MinPrice: price(1000), MaxPrice: price(5000)},
+			check: func(t *testing.T, f Repository.ProductFilter) {
+				assert.Equal(t, int64(1000), *f.MinPrice)
+				assert.Equal(t, int64(5000), *f.MaxPrice)
+			},
+		},
+		{name: "harga terbalik", filter: ProductFilter{MinPrice: price(5000), MaxPrice: price(1000)}, wantErr: true},
+		{name: "harga negatif", filter: ProductFilter{MinPrice: price(-1)}, wantErr: true},
+		{
+			name:   "stok menipis default threshold",
+			filter: ProductFilter{StockState: Repository.StockLow},
+			check: func(t *testing.T, f Repository.ProductFilter) {
+				assert.Equal(t, DefaultLowStockThreshold, f.LowStockThreshold)
+			},
+		},
+		{name: "status stok tidak dikenal", filter: ProductFilter{StockState: "plenty"}, wantErr: true},
+		{
+			name:   "option",
+			filter: ProductFilter{Options: map[string][]string{" color ": {"red"}, "size": {"M", "L"}}},
+			check: func(t *testing.T, f Repository.ProductFilter) {
+				assert.Equal(t, map[string][]string{"color": {"red"}, "size": {"M", "L"}}, f.Options)
+			},
+		},
+		{name: "option tanpa nama", filter: ProductFilter{Options: map[string][]string{"": {"red"}}}, wantErr: true},
+		{name: "tanggal dibuat terbalik", filter: ProductFilter{CreatedFrom: day(10), CreatedTo: day(5)}, wantErr: true},
+		{name: "tanggal diubah kosong", filter: ProductFilter{UpdatedFrom: day(5), UpdatedTo: day(5)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.filter.repoFilter()
+			if tt.wantErr {
+				assert.Equal(t, errorUtils.ErrBadRequest, err)
+				return
+			}
+			require.NoError(t, err)
+			tt.check(t, f)
+		})
+	}
+}
+
+func TestProductUseCase_ChangeProductStatus(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	userID := int64(3)
+	actor := productModel.Actor{UserID: &userID, Name: "admin"}
+	change := &productModel.StatusChange{ProductID: 1, FromStatus: "active", ToStatus: "archived"}
+
+	_, err := uc.ChangeProductStatus(context.Background(), 1, "publish", actor)
+	assert.Equal(t, errorUtils.ErrBadRequest, err)
+
+	repo.On("ChangeStatus", mock.Anything, int64(1), productModel.TransitionArchive, actor).Return(change, nil).Once()
+	require.NoError(t, uc.DeleteProduct(context.Background(), 1, actor))
+
+	repo.On("ChangeStatus", mock.Anything, int64(1), productModel.TransitionActivate, actor).
+		Return(nil, errorUtils.ErrInvalidTransition).Once()
+	_, err = uc.ChangeProductStatus(context.Background(), 1, productModel.TransitionActivate, actor)
+	assert.Equal(t, errorUtils.ErrInvalidTransition, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestProductUseCase_CreateProduct_Status(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	_, err := uc.CreateProduct(context.Background(), &productModel.Product{Name: "Kopi", Status: productModel.StatusArchived})
+	assert.Equal(t, errorUtils.ErrBadRequest, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestProductUseCase_ListProducts_ExcludeArchived(t *testing.T) {
+	repo := new(mocks.ProductRepository)
+	uc := &ProductUseCase{productRepo: repo}
+
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return f.ExcludeArchived && f.Status == ""
+	})).Return([]productModel.Product{}, nil, nil).Once()
+	_, err := uc.ListProducts(context.Background(), ProductFilter{})
+	require.NoError(t, err)
+
+	archived := productModel.StatusArchived
+	repo.On("FindAll", mock.Anything, mock.MatchedBy(func(f Repository.ProductFilter) bool {
+		return !f.ExcludeArchived && f.Status == archived
+	})).Return([]productModel.Product{}, nil, nil).Once()
+	_, err = uc.ListProducts(context.Background(), ProductFilter{Status: &archived})
+	require.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
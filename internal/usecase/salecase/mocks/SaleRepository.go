@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type SaleRepository struct {
+	mock.Mock
+}
+
+// FindUnit Mock
+func (_m *SaleRepository) FindUnit(ctx context.Context, unitID int64) (*saleModel.SaleItem, error) {
+	args := _m.Called(ctx, unitID)
+	item, _ := args.Get(0).(*saleModel.SaleItem)
+	return item, args.Error(1)
+}
+
+// Create Sale Mock
+func (_m *SaleRepository) Create(ctx context.Context, s *saleModel.Sale) error {
+	args := _m.Called(ctx, s)
+	return args.Error(0)
+}
+
+// FindByID Sale Mock
+func (_m *SaleRepository) FindByID(ctx context.Context, id int64) (*saleModel.Sale, error) {
+	args := _m.Called(ctx, id)
+	s, _ := args.Get(0).(*saleModel.Sale)
+	return s, args.Error(1)
+}
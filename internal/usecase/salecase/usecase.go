@@ -0,0 +1,86 @@
+package salecase
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/salerepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+type SaleService interface {
+	Checkout(ctx context.Context, s *saleModel.Sale) (*saleModel.Sale, error)
+	GetSale(ctx context.Context, id int64) (*saleModel.Sale, error)
+}
+
+type SaleUseCase struct {
+	saleRepo  Repository.SaleRepoInterface
+	txManager txmanager.TxManager
+}
+
+func NewSaleService(saleRepo Repository.SaleRepoInterface, txManager txmanager.TxManager) *SaleUseCase {
+	return &SaleUseCase{
+		saleRepo:  saleRepo,
+		txManager: txManager,
+	}
+}
+
+// Checkout menghitung harga setiap baris dari variant_units (bukan dari
+// client), mengonversi qty ke satuan dasar, lalu menyimpan penjualan.
+// Pembacaan harga dan penyimpanan berjalan dalam satu transaksi.
+func (s *SaleUseCase) Checkout(ctx context.Context, sale *saleModel.Sale) (*saleModel.Sale, error) {
+	if len(sale.Items) == 0 {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.checkout(ctx, sale)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sale, nil
+}
+
+func (s *SaleUseCase) checkout(ctx context.Context, sale *saleModel.Sale) error {
+
+	var total int64
+	for i, line := range sale.Items {
+		if line.Quantity <= 0 {
+			return errorUtils.ErrBadRequest
+		}
+
+		unit, err := s.saleRepo.FindUnit(ctx, line.VariantUnitID)
+		if err != nil {
+			logger.Errorf("Checkout fail, unit %d: %s", line.VariantUnitID, err)
+			return err
+		}
+
+		unit.Quantity = line.Quantity
+		unit.BaseQuantity = line.Quantity * unit.ConversionRate
+		unit.LineTotal = int64(line.Quantity) * unit.UnitPrice
+		sale.Items[i] = *unit
+
+		total += unit.LineTotal
+	}
+
+	sale.Total = total
+	if sale.Paid < sale.Total {
+		return errorUtils.ErrBadRequest
+	}
+	sale.Change = sale.Paid - sale.Total
+
+	if err := s.saleRepo.Create(ctx, sale); err != nil {
+		logger.Errorf("Checkout fail, error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SaleUseCase) GetSale(ctx context.Context, id int64) (*saleModel.Sale, error) {
+	return s.saleRepo.FindByID(ctx, id)
+}
@@ -0,0 +1,76 @@
+package salecase
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/saleModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/salecase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+func TestSaleUseCase_Checkout_Success(t *testing.T) {
+	repo := new(mocks.SaleRepository)
+
+	uc := NewSaleService(repo, mocks.TxManager{})
+
+	repo.On("FindUnit", mock.Anything, int64(10)).
+		Return(&saleModel.SaleItem{VariantUnitID: 10, VariantID: 1, UnitName: "pcs", ConversionRate: 1, UnitPrice: 3000}, nil).
+		Once()
+	repo.On("FindUnit", mock.Anything, int64(11)).
+		Return(&saleModel.SaleItem{VariantUnitID: 11, VariantID: 1, UnitName: "pack", ConversionRate: 6, UnitPrice: 17000}, nil).
+		Once()
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*saleModel.Sale")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*saleModel.Sale).ReceiptNo = "MAIN-00000001"
+		}).
+		Return(nil).
+		Once()
+
+	sale, err := uc.Checkout(context.Background(), &saleModel.Sale{
+		OutletID: 1,
+		Paid:     50000,
+		Items: []saleModel.SaleItem{
+			{VariantUnitID: 10, Quantity: 2},
+			{VariantUnitID: 11, Quantity: 1},
+		},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(23000), sale.Total)
+	assert.Equal(t, int64(27000), sale.Change)
+	assert.Equal(t, 2, sale.Items[0].BaseQuantity)
+	assert.Equal(t, 6, sale.Items[1].BaseQuantity)
+	assert.Equal(t, "MAIN-00000001", sale.ReceiptNo)
+
+	repo.AssertExpectations(t)
+}
+
+func TestSaleUseCase_Checkout_Underpaid(t *testing.T) {
+	repo := new(mocks.SaleRepository)
+
+	uc := NewSaleService(repo, mocks.TxManager{})
+
+	repo.On("FindUnit", mock.Anything, int64(10)).
+		Return(&saleModel.SaleItem{VariantUnitID: 10, VariantID: 1, ConversionRate: 1, UnitPrice: 3000}, nil).
+		Once()
+
+	_, err := uc.Checkout(context.Background(), &saleModel.Sale{
+		OutletID: 1,
+		Paid:     1000,
+		Items:    []saleModel.SaleItem{{VariantUnitID: 10, Quantity: 1}},
+	})
+
+	assert.ErrorIs(t, err, errorUtils.ErrBadRequest)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
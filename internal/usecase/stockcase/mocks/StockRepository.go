@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	mock "github.com/stretchr/testify/mock"
+)
+
+type StockRepository struct {
+	mock.Mock
+}
+
+// Record Movement Mock
+func (_m *StockRepository) Record(ctx context.Context, m *stockModel.Movement) error {
+	args := _m.Called(ctx, m)
+	return args.Error(0)
+}
+
+// Count (opname) Mock
+func (_m *StockRepository) Count(ctx context.Context, variantID int64, counted int, note string) (*stockModel.Movement, error) {
+	args := _m.Called(ctx, variantID, counted, note)
+	m, _ := args.Get(0).(*stockModel.Movement)
+	return m, args.Error(1)
+}
+
+// History Mock
+func (_m *StockRepository) History(ctx context.Context, filter Repository.HistoryFilter) ([]stockModel.Movement, error) {
+	args := _m.Called(ctx, filter)
+	movements, _ := args.Get(0).([]stockModel.Movement)
+	return movements, args.Error(1)
+}
+
+// GetLevel Mock
+func (_m *StockRepository) GetLevel(ctx context.Context, variantID int64) (*stockModel.Level, error) {
+	args := _m.Called(ctx, variantID)
+	level, _ := args.Get(0).(*stockModel.Level)
+	return level, args.Error(1)
+}
+
+// Decrement Mock
+func (_m *StockRepository) Decrement(ctx context.Context, d stockModel.Decrement) (*stockModel.DecrementResult, error) {
+	args := _m.Called(ctx, d)
+	res, _ := args.Get(0).(*stockModel.DecrementResult)
+	return res, args.Error(1)
+}
+
+// Reserve Mock
+func (_m *StockRepository) Reserve(ctx context.Context, variantID int64, qty int) (bool, error) {
+	args := _m.Called(ctx, variantID, qty)
+	return args.Bool(0), args.Error(1)
+}
+
+// Release Mock
+func (_m *StockRepository) Release(ctx context.Context, variantID int64, qty int) error {
+	args := _m.Called(ctx, variantID, qty)
+	return args.Error(0)
+}
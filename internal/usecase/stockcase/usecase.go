@@ -0,0 +1,155 @@
+package stockcase
+
+import (
+	"context"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+type StockService interface {
+	RecordMovement(ctx context.Context, m *stockModel.Movement) error
+	StockOpname(ctx context.Context, variantID int64, counted int, note string) (*stockModel.Movement, error)
+	GetStockHistory(ctx context.Context, filter Repository.HistoryFilter) (*HistoryPage, error)
+	GetStockLevel(ctx context.Context, variantID int64) (*stockModel.Level, error)
+
+	// ------ DECREMENT / RESERVE ------
+	Decrement(ctx context.Context, d stockModel.Decrement) (*stockModel.DecrementResult, error)
+	Reserve(ctx context.Context, variantID int64, qty int) (bool, error)
+	Release(ctx context.Context, variantID int64, qty int) error
+}
+
+const defaultHistoryLimit = 50
+
+// HistoryPage satu halaman riwayat stok, HasMore berarti masih ada movement
+// yang lebih lama dari item terakhir.
+type HistoryPage struct {
+	Movements []stockModel.Movement
+	Limit     int
+	HasMore   bool
+}
+
+type StockUseCase struct {
+	stockRepo Repository.StockRepoInterface
+}
+
+func NewStockService(stockRepo Repository.StockRepoInterface) *StockUseCase {
+	return &StockUseCase{
+		stockRepo: stockRepo,
+	}
+}
+
+// RecordMovement mencatat movement manual. Penjualan hanya lewat checkout
+// dan opname lewat StockOpname, jadi keduanya ditolak di sini.
+func (s *StockUseCase) RecordMovement(ctx context.Context, m *stockModel.Movement) error {
+	switch m.Reason {
+	case stockModel.ReasonReturn, stockModel.ReasonPurchaseReceipt:
+		if m.Quantity <= 0 {
+			return errorUtils.ErrBadRequest
+		}
+	case stockModel.ReasonAdjustment, stockModel.ReasonTransfer:
+		if m.Quantity == 0 {
+			return errorUtils.ErrBadRequest
+		}
+	default:
+		return errorUtils.ErrBadRequest
+	}
+
+	if err := s.stockRepo.Record(ctx, m); err != nil {
+		logger.Errorf("RecordMovement fail, error: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *StockUseCase) StockOpname(ctx context.Context, variantID int64, counted int, note string) (*stockModel.Movement, error) {
+	if counted < 0 {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	m, err := s.stockRepo.Count(ctx, variantID, counted, note)
+	if err != nil {
+		logger.Errorf("StockOpname fail, error: %s", err)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (s *StockUseCase) GetStockHistory(ctx context.Context, filter Repository.HistoryFilter) (*HistoryPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+
+	movements, err := s.stockRepo.History(ctx, filter)
+	if err != nil {
+		logger.Errorf("GetStockHistory fail, error: %s", err)
+		return nil, err
+	}
+
+	page := &HistoryPage{Movements: movements, Limit: limit}
+	if len(movements) > limit {
+		page.Movements = movements[:limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+func (s *StockUseCase) GetStockLevel(ctx context.Context, variantID int64) (*stockModel.Level, error) {
+	return s.stockRepo.GetLevel(ctx, variantID)
+}
+
+// Decrement mengurangi stok sesuai stock policy product. Stok tidak cukup
+// dengan policy block menghasilkan ErrInsufficientStock.
+func (s *StockUseCase) Decrement(ctx context.Context, d stockModel.Decrement) (*stockModel.DecrementResult, error) {
+	if d.Quantity <= 0 {
+		return nil, errorUtils.ErrBadRequest
+	}
+	if d.Reason == "" {
+		d.Reason = stockModel.ReasonSale
+	}
+	if !d.Reason.Valid() {
+		return nil, errorUtils.ErrBadRequest
+	}
+
+	result, err := s.stockRepo.Decrement(ctx, d)
+	if err != nil {
+		logger.Errorf("Decrement fail, error: %s", err)
+		return nil, err
+	}
+
+	if result.Oversold {
+		logger.Warnf("variant %d oversold by policy warn, stock now %d", d.VariantID, result.Movement.BalanceAfter)
+	}
+
+	return result, nil
+}
+
+func (s *StockUseCase) Reserve(ctx context.Context, variantID int64, qty int) (bool, error) {
+	if qty <= 0 {
+		return false, errorUtils.ErrBadRequest
+	}
+
+	oversold, err := s.stockRepo.Reserve(ctx, variantID, qty)
+	if err != nil {
+		logger.Errorf("Reserve fail, error: %s", err)
+		return false, err
+	}
+
+	return oversold, nil
+}
+
+func (s *StockUseCase) Release(ctx context.Context, variantID int64, qty int) error {
+	if qty <= 0 {
+		return errorUtils.ErrBadRequest
+	}
+
+	return s.stockRepo.Release(ctx, variantID, qty)
+}
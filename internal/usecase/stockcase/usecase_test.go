@@ -0,0 +1,103 @@
+package stockcase
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/stockModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/stockrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/stockcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+func TestStockUseCase_RecordMovement(t *testing.T) {
+	repo := new(mocks.StockRepository)
+
+	uc := NewStockService(repo)
+
+	receipt := &stockModel.Movement{VariantID: 1, Quantity: 24, Reason: stockModel.ReasonPurchaseReceipt}
+	repo.On("Record", mock.Anything, receipt).Return(nil).Once()
+
+	require.NoError(t, uc.RecordMovement(context.Background(), receipt))
+
+	invalid := []*stockModel.Movement{
+		{VariantID: 1, Quantity: -1, Reason: stockModel.ReasonSale},
+		{VariantID: 1, Quantity: -5, Reason: stockModel.ReasonPurchaseReceipt},
+		{VariantID: 1, Quantity: 0, Reason: stockModel.ReasonAdjustment},
+		{VariantID: 1, Quantity: 3, Reason: stockModel.ReasonOpname},
+		{VariantID: 1, Quantity: 3, Reason: "lost"},
+	}
+	for _, m := range invalid {
+		assert.ErrorIs(t, uc.RecordMovement(context.Background(), m), errorUtils.ErrBadRequest, m.Reason)
+	}
+
+	repo.AssertExpectations(t)
+}
+
+func TestStockUseCase_GetStockHistory_DefaultLimit(t *testing.T) {
+	repo := new(mocks.StockRepository)
+
+	uc := NewStockService(repo)
+
+	history := []stockModel.Movement{{ID: 2, VariantID: 1, Quantity: -2, BalanceAfter: 3, Reason: stockModel.ReasonSale}}
+	repo.On("History", mock.Anything, Repository.HistoryFilter{VariantID: 1, Limit: defaultHistoryLimit + 1}).Return(history, nil).Once()
+
+	res, err := uc.GetStockHistory(context.Background(), Repository.HistoryFilter{VariantID: 1})
+
+	require.NoError(t, err)
+	assert.Equal(t, history, res.Movements)
+	assert.Equal(t, defaultHistoryLimit, res.Limit)
+	assert.False(t, res.HasMore)
+
+	repo.AssertExpectations(t)
+}
+
+func TestStockUseCase_GetStockHistory_HasMore(t *testing.T) {
+	repo := new(mocks.StockRepository)
+
+	uc := NewStockService(repo)
+
+	history := []stockModel.Movement{{ID: 9}, {ID: 8}, {ID: 7}}
+	repo.On("History", mock.Anything, Repository.HistoryFilter{VariantID: 1, BeforeID: 10, Limit: 3}).Return(history, nil).Once()
+
+	res, err := uc.GetStockHistory(context.Background(), Repository.HistoryFilter{VariantID: 1, BeforeID: 10, Limit: 2})
+
+	require.NoError(t, err)
+	assert.Equal(t, history[:2], res.Movements)
+	assert.True(t, res.HasMore)
+}
+
+func TestStockUseCase_Decrement(t *testing.T) {
+	repo := new(mocks.StockRepository)
+
+	uc := NewStockService(repo)
+
+	repo.On("Decrement", mock.Anything, stockModel.Decrement{VariantID: 1, Quantity: 2, Reason: stockModel.ReasonSale}).
+		Return(&stockModel.DecrementResult{Movement: stockModel.Movement{BalanceAfter: -1}, Oversold: true}, nil).
+		Once()
+	repo.On("Decrement", mock.Anything, stockModel.Decrement{VariantID: 2, Quantity: 1, Reason: stockModel.ReasonSale}).
+		Return(nil, errorUtils.ErrInsufficientStock).
+		Once()
+
+	res, err := uc.Decrement(context.Background(), stockModel.Decrement{VariantID: 1, Quantity: 2})
+	require.NoError(t, err)
+	assert.True(t, res.Oversold)
+
+	_, err = uc.Decrement(context.Background(), stockModel.Decrement{VariantID: 2, Quantity: 1})
+	assert.ErrorIs(t, err, errorUtils.ErrInsufficientStock)
+
+	_, err = uc.Decrement(context.Background(), stockModel.Decrement{VariantID: 3, Quantity: 0})
+	assert.ErrorIs(t, err, errorUtils.ErrBadRequest)
+
+	repo.AssertExpectations(t)
+}
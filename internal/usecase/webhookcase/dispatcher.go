@@ -0,0 +1,246 @@
+package webhookcase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/repository/txmanager"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	"github.com/sethvargo/go-retry"
+)
+
+// Header yang dikirim ke endpoint webhook
+const (
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderEventType = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	// 1 percobaan + 5 retry sebelum masuk dead letter
+	defaultMaxAttempts = 6
+	defaultBaseDelay   = time.Second
+	maxDelay           = time.Minute
+	defaultBatchSize   = 100
+	// satu batch dikirim paralel dan tiap request dibatasi timeout client,
+	// jadi batch selesai jauh sebelum lease habis
+	claimLease = time.Minute
+)
+
+// Dispatcher dipakai sebagai outboxcase.Sink. Publish hanya mengantrekan
+// event ke setiap subscription yang memilihnya; pengiriman dilakukan
+// DeliverOnce / Run, satu percobaan per pengiriman tanpa transaksi yang
+// terbuka. Retry dijadwalkan lewat next_attempt_at dengan exponential
+// backoff dan pengiriman yang tetap gagal masuk dead letter.
+type Dispatcher struct {
+	webhookRepo Repository.WebhookRepoInterface
+	txManager   txmanager.TxManager
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	batchSize   int
+	now         func() time.Time
+}
+
+func NewDispatcher(webhookRepo Repository.WebhookRepoInterface, txManager txmanager.TxManager) *Dispatcher {
+	return &Dispatcher{
+		webhookRepo: webhookRepo,
+		txManager:   txManager,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		batchSize:   defaultBatchSize,
+		now:         time.Now,
+	}
+}
+
+// Sign menghitung signature HMAC-SHA256 dari "<timestamp>.<body>". Penerima
+// menghitung ulang dengan secret subscription dan membandingkannya dengan
+// header X-Webhook-Signature ("v1=<hex>").
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish mengantrekan event untuk setiap subscription aktif yang
+// memilihnya. Tidak ada request HTTP di sini, jadi relay outbox tidak
+// tertahan oleh endpoint yang lambat.
+func (d *Dispatcher) Publish(ctx context.Context, event eventModel.Event) error {
+	body, err := json.Marshal(event.Message())
+	if err != nil {
+		return err
+	}
+
+	return d.webhookRepo.EnqueueDeliveries(ctx, event.ID, event.Type, body)
+}
+
+// DeliverOnce mengirim satu batch antrean dan mengembalikan jumlah pengiriman
+// yang diproses (terkirim maupun gagal)
+func (d *Dispatcher) DeliverOnce(ctx context.Context) (int, error) {
+	deliveries, err := d.webhookRepo.ClaimDeliveries(ctx, d.batchSize, claimLease)
+	if err != nil {
+		logger.Errorf("DeliverOnce fail, error: %s", err)
+		return 0, err
+	}
+
+	results := make([]attempt, len(deliveries))
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	for i, delivery := range deliveries {
+		if err := d.record(ctx, delivery, results[i]); err != nil {
+			logger.Errorf("DeliverOnce fail, error: %s", err)
+			return len(deliveries), err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// Run memanggil DeliverOnce setiap interval sampai ctx selesai. Batch yang
+// penuh langsung dilanjutkan tanpa menunggu interval.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := d.DeliverOnce(ctx)
+		if err == nil && n == d.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type attempt struct {
+	status    *int
+	err       error
+	retryable bool
+}
+
+// attempt mengirim satu request. Error jaringan, 408, 429 dan 5xx boleh
+// dicoba lagi; 4xx lain langsung masuk dead letter.
+func (d *Dispatcher) attempt(ctx context.Context, delivery webhookModel.Delivery) attempt {
+	status, err := d.send(ctx, delivery)
+
+	res := attempt{err: err}
+	if status != 0 {
+		res.status = &status
+	}
+	switch {
+	case status == 0, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests, status >= 500:
+		res.retryable = true
+	}
+	return res
+}
+
+// record mencatat hasil percobaan: terkirim atau dead letter menghapus row
+// antrean, gagal sementara dijadwalkan ulang
+func (d *Dispatcher) record(ctx context.Context, delivery webhookModel.Delivery, res attempt) error {
+	attempts := delivery.Attempts + 1
+
+	if res.err == nil {
+		return d.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if delivery.DeadLetterID != nil {
+				if err := d.webhookRepo.MarkDeadLetterReplayed(ctx, *delivery.DeadLetterID, attempts); err != nil {
+					return err
+				}
+			}
+			return d.webhookRepo.DeleteDelivery(ctx, delivery.ID)
+		})
+	}
+
+	logger.Errorf("webhook: event %d to subscription %d failed (attempt %d): %v",
+		delivery.EventID, delivery.SubscriptionID, attempts, res.err)
+
+	if res.retryable && attempts < d.maxAttempts {
+		next := d.now().Add(d.backoff(attempts))
+		return d.webhookRepo.RescheduleDelivery(ctx, delivery.ID, res.err.Error(), res.status, next)
+	}
+
+	return d.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if delivery.DeadLetterID != nil {
+			err = d.webhookRepo.MarkDeadLetterFailed(ctx, *delivery.DeadLetterID, attempts, res.err.Error(), res.status)
+		} else {
+			err = d.webhookRepo.CreateDeadLetter(ctx, &webhookModel.DeadLetter{
+				SubscriptionID: delivery.SubscriptionID,
+				EventID:        delivery.EventID,
+				EventType:      delivery.EventType,
+				Payload:        delivery.Payload,
+				Attempts:       attempts,
+				LastError:      res.err.Error(),
+				ResponseStatus: res.status,
+			})
+		}
+		if err != nil {
+			return err
+		}
+		return d.webhookRepo.DeleteDelivery(ctx, delivery.ID)
+	})
+}
+
+// backoff eksponensial dari baseDelay, maksimal satu menit
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	b := retry.WithCappedDuration(maxDelay, retry.NewExponential(d.baseDelay))
+
+	var delay time.Duration
+	for i := 0; i < attempt; i++ {
+		delay, _ = b.Next()
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery webhookModel.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEventID, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "v1="+Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook %s responded %s", delivery.URL, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,109 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookRepository struct {
+	mock.Mock
+}
+
+// CreateSubscription Mock
+func (_m *WebhookRepository) CreateSubscription(ctx context.Context, s *webhookModel.Subscription) (int64, error) {
+	args := _m.Called(ctx, s)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// UpdateSubscription Mock
+func (_m *WebhookRepository) UpdateSubscription(ctx context.Context, s *webhookModel.Subscription) error {
+	args := _m.Called(ctx, s)
+	return args.Error(0)
+}
+
+// DeleteSubscription Mock
+func (_m *WebhookRepository) DeleteSubscription(ctx context.Context, id int64) error {
+	args := _m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// FindSubscription Mock
+func (_m *WebhookRepository) FindSubscription(ctx context.Context, id int64) (*webhookModel.Subscription, error) {
+	args := _m.Called(ctx, id)
+	s, _ := args.Get(0).(*webhookModel.Subscription)
+	return s, args.Error(1)
+}
+
+// FindAllSubscriptions Mock
+func (_m *WebhookRepository) FindAllSubscriptions(ctx context.Context) ([]webhookModel.Subscription, error) {
+	args := _m.Called(ctx)
+	subs, _ := args.Get(0).([]webhookModel.Subscription)
+	return subs, args.Error(1)
+}
+
+// EnqueueDeliveries Mock
+func (_m *WebhookRepository) EnqueueDeliveries(ctx context.Context, eventID int64, eventType string, payload []byte) error {
+	args := _m.Called(ctx, eventID, eventType, payload)
+	return args.Error(0)
+}
+
+// EnqueueReplay Mock
+func (_m *WebhookRepository) EnqueueReplay(ctx context.Context, d *webhookModel.DeadLetter) error {
+	args := _m.Called(ctx, d)
+	return args.Error(0)
+}
+
+// ClaimDeliveries Mock
+func (_m *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhookModel.Delivery, error) {
+	args := _m.Called(ctx, limit, lease)
+	deliveries, _ := args.Get(0).([]webhookModel.Delivery)
+	return deliveries, args.Error(1)
+}
+
+// RescheduleDelivery Mock
+func (_m *WebhookRepository) RescheduleDelivery(ctx context.Context, id int64, reason string, responseStatus *int, next time.Time) error {
+	args := _m.Called(ctx, id, reason, responseStatus, next)
+	return args.Error(0)
+}
+
+// DeleteDelivery Mock
+func (_m *WebhookRepository) DeleteDelivery(ctx context.Context, id int64) error {
+	args := _m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// CreateDeadLetter Mock
+func (_m *WebhookRepository) CreateDeadLetter(ctx context.Context, d *webhookModel.DeadLetter) error {
+	args := _m.Called(ctx, d)
+	return args.Error(0)
+}
+
+// FindDeadLetter Mock
+func (_m *WebhookRepository) FindDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error) {
+	args := _m.Called(ctx, id)
+	d, _ := args.Get(0).(*webhookModel.DeadLetter)
+	return d, args.Error(1)
+}
+
+// FindAllDeadLetters Mock
+func (_m *WebhookRepository) FindAllDeadLetters(ctx context.Context, filter Repository.DeadLetterFilter) ([]webhookModel.DeadLetter, error) {
+	args := _m.Called(ctx, filter)
+	deadLetters, _ := args.Get(0).([]webhookModel.DeadLetter)
+	return deadLetters, args.Error(1)
+}
+
+// MarkDeadLetterReplayed Mock
+func (_m *WebhookRepository) MarkDeadLetterReplayed(ctx context.Context, id int64, attempts int) error {
+	args := _m.Called(ctx, id, attempts)
+	return args.Error(0)
+}
+
+// MarkDeadLetterFailed Mock
+func (_m *WebhookRepository) MarkDeadLetterFailed(ctx context.Context, id int64, attempts int, reason string, responseStatus *int) error {
+	args := _m.Called(ctx, id, attempts, reason, responseStatus)
+	return args.Error(0)
+}
@@ -0,0 +1,176 @@
+package webhookcase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/url"
+	"slices"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+type WebhookService interface {
+	// ------ SUBSCRIPTION ------
+	// CreateSubscription mengisi s.ID dan s.Secret
+	CreateSubscription(ctx context.Context, s *webhookModel.Subscription) error
+	UpdateSubscription(ctx context.Context, s *webhookModel.Subscription) error
+	DeleteSubscription(ctx context.Context, id int64) error
+	GetSubscription(ctx context.Context, id int64) (*webhookModel.Subscription, error)
+	ListSubscriptions(ctx context.Context) ([]webhookModel.Subscription, error)
+
+	// ------ DEAD LETTER ------
+	ListDeadLetters(ctx context.Context, filter Repository.DeadLetterFilter) (*DeadLetterPage, error)
+	GetDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error)
+	ReplayDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error)
+}
+
+type WebhookUseCase struct {
+	webhookRepo Repository.WebhookRepoInterface
+}
+
+func NewWebhookService(webhookRepo Repository.WebhookRepoInterface) *WebhookUseCase {
+	return &WebhookUseCase{
+		webhookRepo: webhookRepo,
+	}
+}
+
+// ----------------------------------------------------------------------
+// Subscription
+// ----------------------------------------------------------------------
+
+func (s *WebhookUseCase) CreateSubscription(ctx context.Context, sub *webhookModel.Subscription) error {
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+	sub.Secret = secret
+
+	id, err := s.webhookRepo.CreateSubscription(ctx, sub)
+	if err != nil {
+		logger.Errorf("CreateSubscription fail, error: %s", err)
+		return err
+	}
+	sub.ID = id
+
+	return nil
+}
+
+func (s *WebhookUseCase) UpdateSubscription(ctx context.Context, sub *webhookModel.Subscription) error {
+	if err := validateSubscription(sub); err != nil {
+		return err
+	}
+
+	return s.webhookRepo.UpdateSubscription(ctx, sub)
+}
+
+func (s *WebhookUseCase) DeleteSubscription(ctx context.Context, id int64) error {
+	return s.webhookRepo.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookUseCase) GetSubscription(ctx context.Context, id int64) (*webhookModel.Subscription, error) {
+	return s.webhookRepo.FindSubscription(ctx, id)
+}
+
+func (s *WebhookUseCase) ListSubscriptions(ctx context.Context) ([]webhookModel.Subscription, error) {
+	return s.webhookRepo.FindAllSubscriptions(ctx)
+}
+
+// validateSubscription hanya menerima URL http(s) dan event yang dikenal
+func validateSubscription(sub *webhookModel.Subscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errorUtils.ErrBadRequest
+	}
+
+	if len(sub.Events) == 0 {
+		return errorUtils.ErrBadRequest
+	}
+	for _, e := range sub.Events {
+		if e != webhookModel.AllEvents && !slices.Contains(eventModel.Types, e) {
+			return errorUtils.ErrBadRequest
+		}
+	}
+
+	return nil
+}
+
+func newSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// ----------------------------------------------------------------------
+// Dead Letter
+// ----------------------------------------------------------------------
+
+const defaultDeadLetterLimit = 50
+
+// DeadLetterPage satu halaman dead letter, HasMore berarti masih ada yang
+// lebih lama dari item terakhir.
+type DeadLetterPage struct {
+	DeadLetters []webhookModel.DeadLetter
+	Limit       int
+	HasMore     bool
+}
+
+func (s *WebhookUseCase) ListDeadLetters(ctx context.Context, filter Repository.DeadLetterFilter) (*DeadLetterPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultDeadLetterLimit
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+
+	deadLetters, err := s.webhookRepo.FindAllDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DeadLetterPage{DeadLetters: deadLetters, Limit: limit}
+	if len(deadLetters) > limit {
+		page.DeadLetters = deadLetters[:limit]
+		page.HasMore = true
+	}
+
+	return page, nil
+}
+
+func (s *WebhookUseCase) GetDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error) {
+	return s.webhookRepo.FindDeadLetter(ctx, id)
+}
+
+// ReplayDeadLetter mengantrekan body yang sama untuk dikirim ulang (dengan
+// signature baru) ke subscription-nya oleh Dispatcher. Hasilnya terlihat di
+// dead letter: replayed_at terisi jika berhasil, last_error diperbarui jika
+// gagal lagi. Dead letter yang sudah di-replay atau masih diantrekan ditolak
+// dengan ErrConflict.
+func (s *WebhookUseCase) ReplayDeadLetter(ctx context.Context, id int64) (*webhookModel.DeadLetter, error) {
+	deadLetter, err := s.webhookRepo.FindDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetter.ReplayedAt != nil {
+		return nil, errorUtils.ErrConflict
+	}
+
+	if err := s.webhookRepo.EnqueueReplay(ctx, deadLetter); err != nil {
+		if err != errorUtils.ErrConflict {
+			logger.Errorf("ReplayDeadLetter %d fail, error: %s", id, err)
+		}
+		return nil, err
+	}
+
+	return deadLetter, nil
+}
@@ -0,0 +1,252 @@
+package webhookcase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/eventModel"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/domain/webhookModel"
+	Repository "github.com/dona-dllollin/belajar-clean-arch/internal/repository/webhookrepo"
+	"github.com/dona-dllollin/belajar-clean-arch/internal/usecase/webhookcase/mocks"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/logger"
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.Initialize("test")
+	os.Exit(m.Run())
+}
+
+// receiver adalah endpoint webhook palsu yang memverifikasi signature dan
+// membalas dengan status dari statuses secara berurutan
+type receiver struct {
+	*httptest.Server
+	secret   string
+	statuses []int
+	calls    atomic.Int32
+	bodies   chan []byte
+}
+
+func newReceiver(t *testing.T, secret string, statuses ...int) *receiver {
+	rcv := &receiver{secret: secret, statuses: statuses, bodies: make(chan []byte, 10)}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(rcv.calls.Add(1))
+
+		body, _ := io.ReadAll(r.Body)
+		timestamp, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, "v1="+Sign(rcv.secret, timestamp, body), r.Header.Get(HeaderSignature))
+		assert.NotEmpty(t, r.Header.Get(HeaderEventID))
+
+		status := http.StatusOK
+		if n <= len(rcv.statuses) {
+			status = rcv.statuses[n-1]
+		}
+		if status < 300 {
+			rcv.bodies <- body
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.Close)
+	return rcv
+}
+
+func newTestDispatcher(repo *mocks.WebhookRepository) *Dispatcher {
+	d := NewDispatcher(repo, mocks.TxManager{})
+	d.maxAttempts = 3
+	return d
+}
+
+func testEvent(t *testing.T) eventModel.Event {
+	e, err := eventModel.New(eventModel.ProductCreated, eventModel.AggregateProduct, 7, eventModel.ProductPayload{ID: 7, Status: "active"})
+	require.NoError(t, err)
+	e.ID = 42
+	return e
+}
+
+func TestDispatcher_Publish_Enqueues(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+
+	repo.On("EnqueueDeliveries", mock.Anything, int64(42), eventModel.ProductCreated, mock.MatchedBy(func(body []byte) bool {
+		var msg eventModel.Message
+		return json.Unmarshal(body, &msg) == nil && msg.ID == 42 && msg.Type == eventModel.ProductCreated
+	})).Return(nil).Once()
+
+	require.NoError(t, newTestDispatcher(repo).Publish(context.Background(), testEvent(t)))
+
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_DeliverOnce(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	down := newReceiver(t, "whsec_down", http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	rejected := newReceiver(t, "whsec_rejected", http.StatusGone)
+	ok := newReceiver(t, "whsec_ok")
+
+	payload := json.RawMessage(`{"id":42,"type":"product.created"}`)
+	delivery := func(id int64, rcv *receiver, attempts int) webhookModel.Delivery {
+		return webhookModel.Delivery{ID: id, SubscriptionID: id, URL: rcv.URL, Secret: rcv.secret,
+			EventID: 42, EventType: eventModel.ProductCreated, Payload: payload, Attempts: attempts}
+	}
+
+	repo.On("ClaimDeliveries", mock.Anything, defaultBatchSize, claimLease).Return([]webhookModel.Delivery{
+		delivery(1, down, 0),
+		delivery(2, down, 2),
+		delivery(3, rejected, 0),
+		delivery(4, ok, 0),
+	}, nil).Once()
+
+	// gagal sementara: dijadwalkan ulang, percobaan ke-1 menunggu 1s
+	repo.On("RescheduleDelivery", mock.Anything, int64(1), mock.Anything, mock.Anything, now.Add(time.Second)).Return(nil).Once()
+
+	// percobaan terakhir dan 4xx masuk dead letter
+	var deadLetters []*webhookModel.DeadLetter
+	repo.On("CreateDeadLetter", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { deadLetters = append(deadLetters, args.Get(1).(*webhookModel.DeadLetter)) }).
+		Return(nil).Twice()
+	repo.On("DeleteDelivery", mock.Anything, int64(2)).Return(nil).Once()
+	repo.On("DeleteDelivery", mock.Anything, int64(3)).Return(nil).Once()
+
+	// terkirim
+	repo.On("DeleteDelivery", mock.Anything, int64(4)).Return(nil).Once()
+
+	d := newTestDispatcher(repo)
+	d.now = func() time.Time { return now }
+
+	n, err := d.DeliverOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+
+	// satu request per pengiriman, tidak ada retry di tempat
+	assert.Equal(t, int32(2), down.calls.Load())
+	assert.Equal(t, int32(1), rejected.calls.Load())
+	assert.Equal(t, int32(1), ok.calls.Load())
+	assert.JSONEq(t, string(payload), string(<-ok.bodies))
+
+	require.Len(t, deadLetters, 2)
+	bySubscription := map[int64]*webhookModel.DeadLetter{}
+	for _, dl := range deadLetters {
+		bySubscription[dl.SubscriptionID] = dl
+	}
+	assert.Equal(t, 3, bySubscription[2].Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, *bySubscription[2].ResponseStatus)
+	assert.Equal(t, 1, bySubscription[3].Attempts)
+	assert.Equal(t, int64(42), bySubscription[3].EventID)
+
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_DeliverOnce_Replay(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+	ok := newReceiver(t, "whsec_ok")
+	rejected := newReceiver(t, "whsec_rejected", http.StatusBadRequest)
+
+	deadLetterA, deadLetterB := int64(9), int64(10)
+	repo.On("ClaimDeliveries", mock.Anything, defaultBatchSize, claimLease).Return([]webhookModel.Delivery{
+		{ID: 1, SubscriptionID: 1, URL: ok.URL, Secret: ok.secret, EventID: 42, Payload: json.RawMessage(`{}`), DeadLetterID: &deadLetterA},
+		{ID: 2, SubscriptionID: 2, URL: rejected.URL, Secret: rejected.secret, EventID: 43, Payload: json.RawMessage(`{}`), DeadLetterID: &deadLetterB},
+	}, nil).Once()
+
+	repo.On("MarkDeadLetterReplayed", mock.Anything, int64(9), 1).Return(nil).Once()
+	repo.On("DeleteDelivery", mock.Anything, int64(1)).Return(nil).Once()
+	repo.On("MarkDeadLetterFailed", mock.Anything, int64(10), 1, mock.MatchedBy(func(reason string) bool {
+		return strings.Contains(reason, "400")
+	}), mock.Anything).Return(nil).Once()
+	repo.On("DeleteDelivery", mock.Anything, int64(2)).Return(nil).Once()
+
+	_, err := newTestDispatcher(repo).DeliverOnce(context.Background())
+	require.NoError(t, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil, nil)
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, maxDelay, d.backoff(10))
+}
+
+func TestWebhookUseCase_ReplayDeadLetter(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+	uc := NewWebhookService(repo)
+
+	payload := json.RawMessage(`{"id":42,"type":"product.created"}`)
+	deadLetter := &webhookModel.DeadLetter{ID: 9, SubscriptionID: 1, EventID: 42, EventType: eventModel.ProductCreated, Payload: payload}
+
+	repo.On("FindDeadLetter", mock.Anything, int64(9)).Return(deadLetter, nil).Once()
+	repo.On("EnqueueReplay", mock.Anything, deadLetter).Return(nil).Once()
+
+	got, err := uc.ReplayDeadLetter(context.Background(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, deadLetter, got)
+
+	// masih diantrekan
+	repo.On("FindDeadLetter", mock.Anything, int64(9)).Return(deadLetter, nil).Once()
+	repo.On("EnqueueReplay", mock.Anything, deadLetter).Return(errorUtils.ErrConflict).Once()
+	_, err = uc.ReplayDeadLetter(context.Background(), 9)
+	assert.Equal(t, errorUtils.ErrConflict, err)
+
+	// sudah di-replay
+	replayedAt := time.Now()
+	replayed := *deadLetter
+	replayed.ReplayedAt = &replayedAt
+	repo.On("FindDeadLetter", mock.Anything, int64(9)).Return(&replayed, nil).Once()
+	_, err = uc.ReplayDeadLetter(context.Background(), 9)
+	assert.Equal(t, errorUtils.ErrConflict, err)
+
+	repo.AssertExpectations(t)
+}
+
+func TestWebhookUseCase_CreateSubscription(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+	uc := NewWebhookService(repo)
+
+	repo.On("CreateSubscription", mock.Anything, mock.Anything).Return(int64(5), nil).Once()
+
+	sub := &webhookModel.Subscription{URL: "https://shop.example/hooks", Events: []string{eventModel.ProductCreated}, IsActive: true}
+	require.NoError(t, uc.CreateSubscription(context.Background(), sub))
+	assert.Equal(t, int64(5), sub.ID)
+	assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+
+	invalid := []*webhookModel.Subscription{
+		{URL: "ftp://shop.example", Events: []string{eventModel.ProductCreated}},
+		{URL: "https://shop.example/hooks"},
+		{URL: "https://shop.example/hooks", Events: []string{"product.deleted"}},
+	}
+	for _, sub := range invalid {
+		assert.Equal(t, errorUtils.ErrBadRequest, uc.CreateSubscription(context.Background(), sub))
+	}
+
+	repo.AssertExpectations(t)
+}
+
+func TestWebhookUseCase_ListDeadLetters_HasMore(t *testing.T) {
+	repo := new(mocks.WebhookRepository)
+	uc := NewWebhookService(repo)
+
+	deadLetters := []webhookModel.DeadLetter{{ID: 9}, {ID: 8}, {ID: 7}}
+	repo.On("FindAllDeadLetters", mock.Anything, Repository.DeadLetterFilter{BeforeID: 10, Limit: 3}).
+		Return(deadLetters, nil).Once()
+
+	page, err := uc.ListDeadLetters(context.Background(), Repository.DeadLetterFilter{BeforeID: 10, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, deadLetters[:2], page.DeadLetters)
+	assert.Equal(t, 2, page.Limit)
+	assert.True(t, page.HasMore)
+	repo.AssertExpectations(t)
+}
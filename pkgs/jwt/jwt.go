@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// leeway toleransi selisih jam antar server saat memeriksa exp/nbf
+const leeway = 30 * time.Second
+
+type Claims struct {
+	jwt.Claims
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// UserID membaca subject sebagai id user
+func (c Claims) UserID() (int64, error) {
+	return strconv.ParseInt(c.Subject, 10, 64)
+}
+
+// Manager menandatangani dan memverifikasi access token dengan ES256
+type Manager struct {
+	signer    jose.Signer
+	publicKey *ecdsa.PublicKey
+	issuer    string
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+func New(key *ecdsa.PrivateKey, issuer string, ttl time.Duration) (*Manager, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: key},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		signer:    signer,
+		publicKey: &key.PublicKey,
+		issuer:    issuer,
+		ttl:       ttl,
+		now:       time.Now,
+	}, nil
+}
+
+// Issue membuat access token untuk user dan mengembalikan waktu kedaluwarsanya
+func (m *Manager) Issue(userID int64, username, role string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.ttl)
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", time.Time{}, err
+	}
+
+	claims := Claims{
+		Claims: jwt.Claims{
+			ID:        hex.EncodeToString(jti),
+			Issuer:    m.issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Expiry:    jwt.NewNumericDate(expiresAt),
+		},
+		Username: username,
+		Role:     role,
+	}
+
+	token, err := jwt.Signed(m.signer).Claims(claims).Serialize()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// Verify memeriksa signature, issuer dan masa berlaku token
+func (m *Manager) Verify(token string) (*Claims, error) {
+	parsed, err := jwt.ParseSigned(token, []jose.SignatureAlgorithm{jose.ES256})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := parsed.Claims(m.publicKey, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = claims.ValidateWithLeeway(jwt.Expected{
+		Issuer: m.issuer,
+		Time:   m.now(),
+	}, leeway)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// GenerateKey membuat private key P-256 baru (untuk test dan development)
+func GenerateKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// LoadKey membaca private key P-256 dari file PEM (SEC 1 "EC PRIVATE KEY"
+// atau PKCS #8 "PRIVATE KEY")
+func LoadKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("jwt: no PEM block found in key file")
+	}
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("jwt: key is not an ECDSA private key")
+		}
+		return ecKey, nil
+	}
+
+	return nil, errors.New("jwt: unsupported PEM block " + block.Type)
+}
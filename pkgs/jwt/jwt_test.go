@@ -0,0 +1,95 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	m, err := New(key, "test", 15*time.Minute)
+	require.NoError(t, err)
+	return m
+}
+
+func TestManager_IssueVerify(t *testing.T) {
+	m := newTestManager(t)
+
+	token, expiresAt, err := m.Issue(42, "kasir1", "cashier")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), expiresAt, time.Second)
+
+	claims, err := m.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "kasir1", claims.Username)
+	assert.Equal(t, "cashier", claims.Role)
+
+	userID, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), userID)
+}
+
+func TestManager_Verify_Rejects(t *testing.T) {
+	m := newTestManager(t)
+	token, _, err := m.Issue(1, "admin", "admin")
+	require.NoError(t, err)
+
+	t.Run("other key", func(t *testing.T) {
+		_, err := newTestManager(t).Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		parts[1] = parts[1][:len(parts[1])-2] + "AA"
+		_, err := m.Verify(strings.Join(parts, "."))
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		m.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { m.now = time.Now }()
+
+		_, err := m.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("other issuer", func(t *testing.T) {
+		other := *m
+		other.issuer = "someone-else"
+		_, err := other.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("garbage", func(t *testing.T) {
+		_, err := m.Verify("not-a-token")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestLoadKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwt.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	loaded, err := LoadKey(path)
+	require.NoError(t, err)
+	assert.True(t, key.Equal(loaded))
+}
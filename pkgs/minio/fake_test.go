@@ -0,0 +1,212 @@
+package minio
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeS3 adalah server S3 in-process secukupnya untuk minio-go: bucket,
+// put/get/head/delete/list object. Signature tidak diperiksa.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{buckets: make(map[string]map[string]fakeObject)}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if key == "" {
+		f.serveBucket(w, r, bucket)
+		return
+	}
+
+	objects, ok := f.buckets[bucket]
+	if !ok {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", bucket, key)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readPayload(r)
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody", bucket, key)
+			return
+		}
+		objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now().UTC()}
+		w.Header().Set("ETag", etag(data))
+		w.WriteHeader(http.StatusOK)
+
+	case http.MethodGet, http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey", bucket, key)
+			return
+		}
+		w.Header().Set("ETag", etag(obj.data))
+		w.Header().Set("Content-Type", obj.contentType)
+		http.ServeContent(w, r, key, obj.modTime, bytes.NewReader(obj.data))
+
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", bucket, key)
+	}
+}
+
+func (f *fakeS3) serveBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	_, exists := f.buckets[bucket]
+
+	switch {
+	case r.URL.Query().Has("location"):
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`)
+
+	case r.Method == http.MethodHead:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodPut:
+		if !exists {
+			f.buckets[bucket] = make(map[string]fakeObject)
+		}
+		w.WriteHeader(http.StatusOK)
+
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		if !exists {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket", bucket, "")
+			return
+		}
+		f.listObjects(w, r, bucket)
+
+	default:
+		writeS3Error(w, r, http.StatusNotImplemented, "NotImplemented", bucket, "")
+	}
+}
+
+// listObjects menjawab ListObjectsV2 dalam satu halaman, tanpa delimiter
+func (f *fakeS3) listObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+
+	var keys []string
+	for key := range f.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	contents := make([]content, 0, len(keys))
+	for _, key := range keys {
+		obj := f.buckets[bucket][key]
+		contents = append(contents, content{
+			Key:          key,
+			LastModified: obj.modTime.Format(time.RFC3339Nano),
+			ETag:         etag(obj.data),
+			Size:         int64(len(obj.data)),
+			StorageClass: "STANDARD",
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(struct {
+		XMLName     xml.Name  `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name        string    `xml:"Name"`
+		Prefix      string    `xml:"Prefix"`
+		KeyCount    int       `xml:"KeyCount"`
+		MaxKeys     int       `xml:"MaxKeys"`
+		IsTruncated bool      `xml:"IsTruncated"`
+		Contents    []content `xml:"Contents"`
+	}{Name: bucket, Prefix: prefix, KeyCount: len(contents), MaxKeys: 1000, Contents: contents})
+}
+
+// readPayload membaca body, termasuk format aws-chunked yang dipakai
+// minio-go saat upload lewat http biasa
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code, bucket, key string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	xml.NewEncoder(w).Encode(struct {
+		XMLName    xml.Name `xml:"Error"`
+		Code       string   `xml:"Code"`
+		Message    string   `xml:"Message"`
+		BucketName string   `xml:"BucketName"`
+		Key        string   `xml:"Key"`
+	}{Code: code, Message: code, BucketName: bucket, Key: key})
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
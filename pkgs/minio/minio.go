@@ -3,20 +3,23 @@ package minio
 import (
 	"context"
 	"fmt"
-	"mime/multipart"
+	"io"
 	"strings"
-	"time"
 
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+// MinioClient adalah storage.Driver untuk endpoint S3 compatible (MinIO, S3)
 type MinioClient struct {
 	Client  *minio.Client
 	Bucket  string
 	BaseURL string
 }
 
+var _ storage.Driver = (*MinioClient)(nil)
+
 func NewMinioClient(
 	endpoint string,
 	accessKey string,
@@ -47,42 +50,80 @@ func NewMinioClient(
 	return &MinioClient{
 		Client:  client,
 		Bucket:  bucket,
-		BaseURL: baseURL,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
 	}, nil
 }
 
-func (m *MinioClient) UploadFile(ctx context.Context, file *multipart.FileHeader, folder string) (string, error) {
-	src, err := file.Open()
+func (m *MinioClient) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := m.Client.PutObject(ctx, m.Bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (m *MinioClient) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	// GetObject baru request saat dibaca, jadi cek keberadaan lewat Stat dulu
+	obj, err := m.Client.GetObject(ctx, m.Bucket, key, minio.GetObjectOptions{})
 	if err != nil {
-		return "", err
+		return nil, mapError(err)
 	}
-	defer src.Close()
-
-	if folder == "" {
-		folder = "avatars"
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, mapError(err)
 	}
+	return obj, nil
+}
 
-	objectName := fmt.Sprintf("%s/%d-%s", folder, time.Now().UnixNano(), file.Filename)
+func (m *MinioClient) Delete(ctx context.Context, key string) error {
+	err := m.Client.RemoveObject(ctx, m.Bucket, key, minio.RemoveObjectOptions{})
+	if mapError(err) == storage.ErrNotExist {
+		return nil
+	}
+	return err
+}
 
-	_, err = m.Client.PutObject(ctx, m.Bucket, objectName, src, file.Size, minio.PutObjectOptions{
-		ContentType: file.Header.Get("Content-Type"),
-	})
+func (m *MinioClient) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := m.Client.StatObject(ctx, m.Bucket, key, minio.StatObjectOptions{})
 	if err != nil {
-		return "", err
+		if mapError(err) == storage.ErrNotExist {
+			return false, nil
+		}
+		return false, err
 	}
-
-	return fmt.Sprintf("%s/%s/%s", m.BaseURL, m.Bucket, objectName), nil
+	return true, nil
 }
 
-func (m *MinioClient) DeleteFile(ctx context.Context, fileURL string) error {
-	err := m.Client.RemoveObject(ctx, m.Bucket, extractFilePath(fileURL, m.BaseURL, m.Bucket), minio.RemoveObjectOptions{})
-	if err != nil {
-		return err
+func (m *MinioClient) List(ctx context.Context, prefix string, fn func(storage.Object) error) error {
+	// cancel menghentikan goroutine listing minio-go jika fn berhenti lebih awal
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for obj := range m.Client.ListObjects(ctx, m.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(storage.Object{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified}); err != nil {
+			return err
+		}
 	}
-	return nil
+	return ctx.Err()
+}
+
+func (m *MinioClient) URL(key string) string {
+	return fmt.Sprintf("%s/%s/%s", m.BaseURL, m.Bucket, key)
+}
+
+func (m *MinioClient) Key(url string) string {
+	return strings.TrimPrefix(url, fmt.Sprintf("%s/%s/", m.BaseURL, m.Bucket))
 }
 
-func extractFilePath(fileURL, baseURL, bucket string) string {
-	trimmed := strings.TrimPrefix(fileURL, fmt.Sprintf("%s/%s/", baseURL, bucket))
-	return trimmed
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return storage.ErrNotExist
+	}
+	return err
 }
@@ -0,0 +1,30 @@
+package minio
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage/storagetest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T) *MinioClient {
+	t.Helper()
+
+	server := httptest.NewServer(newFakeS3())
+	t.Cleanup(server.Close)
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	client, err := NewMinioClient(endpoint, "access", "secret", "images", "http://cdn.local", false)
+	require.NoError(t, err)
+
+	return client
+}
+
+func TestMinioClient_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Driver {
+		return newTestClient(t)
+	})
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local menyimpan file di bawah root, URL-nya adalah key itu sendiri yang
+// kemudian dilayani oleh file server /static.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{
+		root: root,
+	}
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dstPath, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return err
+	}
+
+	// tulis ke file sementara dulu supaya pembaca tidak melihat file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(dstPath), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dstPath)
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *Local) List(ctx context.Context, prefix string, fn func(Object) error) error {
+	err := filepath.WalkDir(l.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(Object{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) URL(key string) string {
+	return key
+}
+
+func (l *Local) Key(url string) string {
+	return strings.TrimPrefix(url, "/")
+}
+
+// path menolak key yang keluar dari root, misalnya "../.env"
+func (l *Local) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("storage: invalid key %q", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
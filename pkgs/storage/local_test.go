@@ -0,0 +1,26 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal_Contract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Driver {
+		return storage.NewLocal(t.TempDir())
+	})
+}
+
+func TestLocal_RejectsKeyOutsideRoot(t *testing.T) {
+	d := storage.NewLocal(t.TempDir())
+
+	for _, key := range []string{"../escape.jpg", "/etc/passwd", ""} {
+		err := d.Put(context.Background(), key, bytes.NewReader([]byte("x")), 1, "image/jpeg")
+		assert.Error(t, err, key)
+	}
+}
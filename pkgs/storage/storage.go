@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Driver menyimpan file berdasarkan key relatif, misalnya "uploads/123.jpg".
+// Implementasi: Local (disk) dan pkgs/minio (S3 compatible).
+type Driver interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get mengembalikan ErrNotExist jika key tidak ada
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete tidak error jika key memang sudah tidak ada
+	Delete(ctx context.Context, key string) error
+
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// List memanggil fn untuk setiap object dengan key berawalan prefix.
+	// Error dari fn menghentikan listing dan dikembalikan apa adanya.
+	List(ctx context.Context, prefix string, fn func(Object) error) error
+
+	// URL adalah alamat publik yang disimpan di database, Key kebalikannya
+	URL(key string) string
+	Key(url string) string
+}
+
+// Object adalah hasil List
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
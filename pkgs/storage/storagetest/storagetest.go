@@ -0,0 +1,139 @@
+// Package storagetest berisi contract test yang wajib dilewati setiap
+// implementasi storage.Driver.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/dona-dllollin/belajar-clean-arch/pkgs/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run menjalankan contract suite. newDriver dipanggil sekali per subtest dan
+// harus mengembalikan driver dengan storage kosong.
+func Run(t *testing.T, newDriver func(t *testing.T) storage.Driver) {
+	ctx := context.Background()
+
+	t.Run("PutGet", func(t *testing.T) {
+		d := newDriver(t)
+		content := []byte("fake image content")
+
+		require.NoError(t, d.Put(ctx, "uploads/a.jpg", bytes.NewReader(content), int64(len(content)), "image/jpeg"))
+
+		rc, err := d.Get(ctx, "uploads/a.jpg")
+		require.NoError(t, err)
+		defer rc.Close()
+
+		got, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+	})
+
+	t.Run("PutOverwrites", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Put(ctx, "uploads/a.jpg", bytes.NewReader([]byte("old")), 3, "image/jpeg"))
+		require.NoError(t, d.Put(ctx, "uploads/a.jpg", bytes.NewReader([]byte("new!")), 4, "image/jpeg"))
+
+		assertContent(t, d, "uploads/a.jpg", "new!")
+	})
+
+	t.Run("NestedKey", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Put(ctx, "uploads/2026/10/b.png", bytes.NewReader([]byte("png")), 3, "image/png"))
+		assertContent(t, d, "uploads/2026/10/b.png", "png")
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		d := newDriver(t)
+
+		ok, err := d.Exists(ctx, "uploads/c.jpg")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, d.Put(ctx, "uploads/c.jpg", bytes.NewReader([]byte("c")), 1, "image/jpeg"))
+
+		ok, err = d.Exists(ctx, "uploads/c.jpg")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		d := newDriver(t)
+
+		_, err := d.Get(ctx, "uploads/missing.jpg")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		d := newDriver(t)
+
+		require.NoError(t, d.Put(ctx, "uploads/d.jpg", bytes.NewReader([]byte("d")), 1, "image/jpeg"))
+		require.NoError(t, d.Delete(ctx, "uploads/d.jpg"))
+
+		ok, err := d.Exists(ctx, "uploads/d.jpg")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		// delete kedua kali tidak error
+		assert.NoError(t, d.Delete(ctx, "uploads/d.jpg"))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		d := newDriver(t)
+
+		// root kosong tidak error
+		require.NoError(t, d.List(ctx, "uploads/", func(storage.Object) error { return nil }))
+
+		for _, key := range []string{"uploads/a.jpg", "uploads/2026/b.png", "other/c.jpg"} {
+			require.NoError(t, d.Put(ctx, key, bytes.NewReader([]byte("abc")), 3, "image/jpeg"))
+		}
+
+		var keys []string
+		err := d.List(ctx, "uploads/", func(obj storage.Object) error {
+			keys = append(keys, obj.Key)
+			assert.Equal(t, int64(3), obj.Size)
+			assert.WithinDuration(t, time.Now(), obj.ModTime, time.Minute)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"uploads/a.jpg", "uploads/2026/b.png"}, keys)
+
+		// error dari fn menghentikan listing
+		stop := errors.New("stop")
+		calls := 0
+		err = d.List(ctx, "", func(storage.Object) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("URLKeyRoundTrip", func(t *testing.T) {
+		d := newDriver(t)
+
+		url := d.URL("uploads/e.jpg")
+		assert.NotEmpty(t, url)
+		assert.Equal(t, "uploads/e.jpg", d.Key(url))
+	})
+}
+
+func assertContent(t *testing.T, d storage.Driver, key, want string) {
+	t.Helper()
+
+	rc, err := d.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+}
@@ -1,7 +1,7 @@
 package validation
 
 import (
-	"fmt"
+	"errors"
 
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -30,7 +30,7 @@ func (v *validation) ValidateStruct(s interface{}) error {
 
 func (v *validation) Translate(err error) error {
 	for _, e := range err.(validator.ValidationErrors) {
-		return fmt.Errorf(e.Translate(*v.trans))
+		return errors.New(e.Translate(*v.trans))
 	}
 	return err
 }
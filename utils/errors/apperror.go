@@ -9,4 +9,19 @@ var (
 	ErrForbidden    = errors.New("forbidden")
 	ErrUnauthorized = errors.New("unauthorized")
 	ErrInternal     = errors.New("internal server error")
+
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	ErrPreconditionFailed   = errors.New("resource has been modified, reload and retry")
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+	ErrPayloadTooLarge      = errors.New("request body is too large")
+
+	ErrIdempotencyKeyReused   = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this Idempotency-Key is still being processed")
+	ErrIdempotencyWithheld    = errors.New("the request with this Idempotency-Key already succeeded, its response contained a secret and cannot be replayed")
+
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrVariantInUse      = errors.New("variant has stock or stock history and cannot be deleted")
+	ErrCategoryCycle     = errors.New("category cannot be moved under itself or its descendants")
+	ErrInvalidTransition = errors.New("product status transition is not allowed")
 )
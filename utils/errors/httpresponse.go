@@ -15,9 +15,10 @@ func WriteHTTPError(w http.ResponseWriter, err error) {
 	var status int
 
 	switch err {
-	case ErrBadRequest:
+	case ErrBadRequest, ErrCategoryCycle:
 		status = http.StatusBadRequest
-	case ErrConflict:
+	case ErrConflict, ErrInsufficientStock, ErrInvalidTransition, ErrVariantInUse,
+		ErrIdempotencyKeyReused, ErrIdempotencyKeyInFlight, ErrIdempotencyWithheld:
 		status = http.StatusConflict
 	case ErrUnauthorized:
 		status = http.StatusUnauthorized
@@ -25,6 +26,14 @@ func WriteHTTPError(w http.ResponseWriter, err error) {
 		status = http.StatusForbidden
 	case ErrNotFound:
 		status = http.StatusNotFound
+	case ErrUnsupportedMediaType:
+		status = http.StatusUnsupportedMediaType
+	case ErrPayloadTooLarge:
+		status = http.StatusRequestEntityTooLarge
+	case ErrPreconditionFailed:
+		status = http.StatusPreconditionFailed
+	case ErrPreconditionRequired:
+		status = http.StatusPreconditionRequired
 	default:
 		status = http.StatusInternalServerError
 	}
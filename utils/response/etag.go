@@ -0,0 +1,40 @@
+package response
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+)
+
+// SetETag mengirim versi resource sebagai ETag (strong, "<version>")
+func SetETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// IfMatchVersion membaca versi dari header If-Match untuk update dengan
+// optimistic locking. Header wajib ada (ErrPreconditionRequired); "*" berarti
+// tanpa pengecekan versi (0). ETag yang bukan buatan server tidak akan pernah
+// cocok, jadi langsung ErrPreconditionFailed.
+func IfMatchVersion(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		return 0, errorUtils.ErrPreconditionRequired
+	}
+	if value == "*" {
+		return 0, nil
+	}
+
+	// If-Match memakai perbandingan strong, ETag weak tidak pernah cocok
+	if strings.HasPrefix(value, "W/") || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, errorUtils.ErrPreconditionFailed
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errorUtils.ErrPreconditionFailed
+	}
+
+	return version, nil
+}
@@ -0,0 +1,51 @@
+package response
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	errorUtils "github.com/dona-dllollin/belajar-clean-arch/utils/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	cases := []struct {
+		header  string
+		version int64
+		err     error
+	}{
+		{"", 0, errorUtils.ErrPreconditionRequired},
+		{"*", 0, nil},
+		{`"7"`, 7, nil},
+		{` "12" `, 12, nil},
+		{`W/"7"`, 0, errorUtils.ErrPreconditionFailed},
+		{`7`, 0, errorUtils.ErrPreconditionFailed},
+		{`"abc"`, 0, errorUtils.ErrPreconditionFailed},
+		{`"0"`, 0, errorUtils.ErrPreconditionFailed},
+		{`"1", "2"`, 0, errorUtils.ErrPreconditionFailed},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("PUT", "/", nil)
+		if c.header != "" {
+			r.Header.Set("If-Match", c.header)
+		}
+
+		version, err := IfMatchVersion(r)
+		assert.Equal(t, c.err, err, c.header)
+		assert.Equal(t, c.version, version, c.header)
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SetETag(rec, 3)
+
+	assert.Equal(t, `"3"`, rec.Header().Get("ETag"))
+
+	r := httptest.NewRequest("PUT", "/", nil)
+	r.Header.Set("If-Match", rec.Header().Get("ETag"))
+	version, err := IfMatchVersion(r)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+}
@@ -0,0 +1,68 @@
+package response
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+// Page adalah metadata pagination yang dipakai semua endpoint list.
+// NextCursor kosong berarti sudah halaman terakhir.
+type Page struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+	Total      *int64 `json:"total,omitempty"` // hanya jika diminta (include_total)
+}
+
+// PageResponse adalah envelope respons list: data berupa array langsung
+// (tidak dibungkus seperti Response) ditambah metadata page.
+type PageResponse struct {
+	Status string      `json:"status"`
+	Data   interface{} `json:"data"`
+	Page   Page        `json:"page"`
+}
+
+// Paginated menulis respons list dengan envelope pagination standar.
+func Paginated(w http.ResponseWriter, statusCode int, status string, data interface{}, page Page) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	resp := PageResponse{
+		Status: status,
+		Data:   data,
+		Page:   page,
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// FullPage untuk list yang selalu dikirim utuh (tanpa cursor), supaya
+// bentuk respons tetap sama dengan list yang di-paging.
+func FullPage(n int) Page {
+	total := int64(n)
+	return Page{Limit: n, Total: &total}
+}
+
+// EncodeCursor membungkus posisi halaman menjadi string opaque untuk klien.
+func EncodeCursor(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor kebalikan EncodeCursor. Error berarti cursor rusak atau
+// bukan buatan server.
+func DecodeCursor(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
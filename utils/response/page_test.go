@@ -0,0 +1,49 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	type cursor struct {
+		Value string `json:"v"`
+		ID    int64  `json:"id"`
+	}
+
+	encoded, err := EncodeCursor(cursor{Value: "2026-10-18 09:00:00+07", ID: 42})
+	require.NoError(t, err)
+	assert.NotContains(t, encoded, "=")
+
+	var got cursor
+	require.NoError(t, DecodeCursor(encoded, &got))
+	assert.Equal(t, cursor{Value: "2026-10-18 09:00:00+07", ID: 42}, got)
+
+	assert.Error(t, DecodeCursor("bukan cursor!", &got))
+	assert.Error(t, DecodeCursor("bm90IGpzb24", &got))
+}
+
+func TestPaginated(t *testing.T) {
+	rec := httptest.NewRecorder()
+	total := int64(3)
+
+	Paginated(rec, http.StatusOK, "success", []int{1, 2}, Page{Limit: 2, NextCursor: "abc", HasMore: true, Total: &total})
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.JSONEq(t,
+		`{"status":"success","data":[1,2],"page":{"limit":2,"next_cursor":"abc","has_more":true,"total":3}}`,
+		rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	Paginated(rec, http.StatusOK, "success", []int{}, FullPage(0))
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.JSONEq(t, `{"limit":0,"has_more":false,"total":0}`, string(body["page"]))
+}